@@ -35,6 +35,12 @@ func setDefaults() {
 	viper.SetDefault("server.port", "8080")
 	viper.SetDefault("server.mode", "debug")
 
+	// 站点配置
+	viper.SetDefault("site.title", "简约活力博客")
+	viper.SetDefault("site.description", "")
+	viper.SetDefault("site.url", "http://localhost:8080")
+	viper.SetDefault("site.language", "zh-CN")
+
 	// 数据库配置
 	viper.SetDefault("database.host", "localhost")
 	viper.SetDefault("database.port", "3306")
@@ -56,6 +62,25 @@ func setDefaults() {
 	viper.SetDefault("jwt.access_token_ttl", 24)   // 小时
 	viper.SetDefault("jwt.refresh_token_ttl", 168) // 小时 (7天)
 
+	// 登录防暴力破解配置，连续失败达到次数后锁定，之后每次失败锁定时长翻倍
+	viper.SetDefault("login.max_attempts", 5)
+	viper.SetDefault("login.ip_max_attempts", 20)
+	viper.SetDefault("login.lockout_seconds", 60)
+	viper.SetDefault("login.max_lockout_minutes", 30)
+	viper.SetDefault("login.window_minutes", 60)
+	viper.SetDefault("login.store", "database") // memory或database，database在重启后保留锁定
+	viper.SetDefault("login.log_retention_days", 90)
+
+	// 两步验证配置，totp_issuer为空时使用站点标题
+	viper.SetDefault("login.totp_issuer", "")
+	viper.SetDefault("login.challenge_ttl_minutes", 5)
+	viper.SetDefault("login.recovery_codes", 10)
+
+	// 个人访问令牌配置
+	viper.SetDefault("tokens.default_expire_days", 90)
+	viper.SetDefault("tokens.max_expire_days", 365) // 0为不限制
+	viper.SetDefault("tokens.max_per_user", 20)
+
 	// 日志配置
 	viper.SetDefault("log.level", "info")
 	viper.SetDefault("log.format", "json")
@@ -64,10 +89,100 @@ func setDefaults() {
 	viper.SetDefault("storage.type", "local")
 	viper.SetDefault("storage.local.base_path", "./uploads")
 	viper.SetDefault("storage.local.base_url", "http://localhost:8080/uploads/")
+	viper.SetDefault("storage.s3.region", "us-east-1")
+	viper.SetDefault("storage.s3.path_style", false)
+	viper.SetDefault("storage.s3.part_size_mb", 8) // 超过该大小的文件使用分片上传
+
+	// 分片上传配置，分片状态保存在chunk_path中，超过chunk_expire_hours未更新的上传会话会被清理
+	viper.SetDefault("upload.chunk_path", "./data/chunks")
+	viper.SetDefault("upload.chunk_size_mb", 8)
+	viper.SetDefault("upload.chunk_max_size_mb", 2048)
+	viper.SetDefault("upload.chunk_expire_hours", 24)
+	// 每个用户未完成的上传会话数和文件总大小上限，0表示不限制
+	viper.SetDefault("upload.chunk_max_sessions", 5)
+	viper.SetDefault("upload.chunk_max_pending_mb", 4096)
+
+	// 图片处理配置，sizes格式为 名称:最大宽度
+	viper.SetDefault("image.enabled", true)
+	viper.SetDefault("image.strip_metadata", true)
+	viper.SetDefault("image.quality", 82)
+	viper.SetDefault("image.max_pixels", 40000000) // 超过该像素数的图片不做处理，避免解码时占用过多内存
+	viper.SetDefault("image.webp", true)
+	viper.SetDefault("image.sizes", []string{"thumb:320", "medium:800", "large:1600"})
+
+	// 附件回收配置
+	viper.SetDefault("attachments.gc_interval_hours", 24)
+	viper.SetDefault("attachments.unused_days", 30)
+	viper.SetDefault("attachments.auto_delete", false)
 
 	// 模板配置
 	viper.SetDefault("theme.current", "default")
 	viper.SetDefault("theme.path", "./web/templates")
+	viper.SetDefault("theme.watch_interval_seconds", 2)
+
+	// 邮件配置，driver: smtp/file/log/none
+	viper.SetDefault("mail.driver", "log")
+	viper.SetDefault("mail.from", "简约活力博客 <noreply@localhost>")
+	viper.SetDefault("mail.admin_email", "")
+	viper.SetDefault("mail.template_path", "./web/templates/mail")
+	viper.SetDefault("mail.file_path", "./data/mail")
+	viper.SetDefault("mail.smtp.host", "")
+	viper.SetDefault("mail.smtp.port", 587)
+	viper.SetDefault("mail.smtp.username", "")
+	viper.SetDefault("mail.smtp.password", "")
+	viper.SetDefault("mail.smtp.security", "starttls")
+	viper.SetDefault("mail.smtp.timeout_seconds", 30)
+
+	// 订阅源配置
+	viper.SetDefault("feed.limit", 20)
+	viper.SetDefault("feed.full_content", true)
+
+	// 缓存配置，driver: memory/redis/none
+	viper.SetDefault("cache.driver", "memory")
+	viper.SetDefault("cache.capacity", 1000)
+	viper.SetDefault("cache.ttl_seconds", 300)
+	viper.SetDefault("cache.redis.prefix", "matuto:")
+
+	// 全文搜索配置
+	viper.SetDefault("search.index_path", "./data/search.idx")
+
+	// 评论配置，page_size为每页顶层评论数量
+	viper.SetDefault("comment.page_size", 10)
+
+	// 垃圾评论检查配置
+	viper.SetDefault("comment.spam.enabled", true)
+	viper.SetDefault("comment.spam.min_submit_seconds", 3)
+	viper.SetDefault("comment.spam.token_max_age_hours", 24)
+	viper.SetDefault("comment.spam.rate_limit", 5)
+	viper.SetDefault("comment.spam.rate_window_seconds", 600)
+	viper.SetDefault("comment.spam.max_links", 2)
+	viper.SetDefault("comment.spam.blacklist", []string{})
+	viper.SetDefault("comment.spam.bayes_path", "./data/spam.bayes")
+	viper.SetDefault("comment.spam.bayes_min_samples", 20)
+	viper.SetDefault("comment.spam.ham_threshold", 0.1)
+	viper.SetDefault("comment.spam.spam_threshold", 0.9)
+
+	// 固定链接配置，支持 {id}、{slug}、{year}、{month}、{day} 占位符
+	viper.SetDefault("permalink.article", "/article/{slug}")
+	viper.SetDefault("permalink.page", "/{slug}")
+	viper.SetDefault("permalink.category", "/category/{slug}")
+	viper.SetDefault("permalink.tag", "/tag/{slug}")
+
+	// robots.txt配置
+	viper.SetDefault("robots.user_agent", "*")
+	viper.SetDefault("robots.allow", []string{"/"})
+	viper.SetDefault("robots.disallow", []string{"/api/"})
+	viper.SetDefault("robots.crawl_delay", 0)
+	viper.SetDefault("robots.extra", "")
+
+	// 定时任务配置
+	viper.SetDefault("scheduler.publish_interval_seconds", 30)
+
+	// 友情链接检测配置，连续失败dead_after_failures次后标记为失效
+	viper.SetDefault("links.check_interval_hours", 24)
+	viper.SetDefault("links.check_timeout_seconds", 10)
+	viper.SetDefault("links.dead_after_failures", 3)
+	viper.SetDefault("links.hide_dead", true)
 
 	// CORS配置
 	viper.SetDefault("cors.allowed_origins", "http://localhost:3000,http://localhost:8080")
@@ -90,6 +205,11 @@ func GetBool(key string) bool {
 	return viper.GetBool(key)
 }
 
+// GetStringSlice 获取字符串切片配置
+func GetStringSlice(key string) []string {
+	return viper.GetStringSlice(key)
+}
+
 // GetFloat64 获取浮点数配置
 func GetFloat64(key string) float64 {
 	return viper.GetFloat64(key)
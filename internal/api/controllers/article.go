@@ -1,6 +1,10 @@
 package controllers
 
 import (
+	"fmt"
+	"html/template"
+	"matuto-blog/pkg/fulltext"
+	"matuto-blog/pkg/logger"
 	"matuto-blog/pkg/utils"
 	"net/http"
 	"strconv"
@@ -20,23 +24,25 @@ type ArticleController struct{}
 
 // ArticleRequest 文章请求结构
 type ArticleRequest struct {
-	Id              int      `json:"id"`
-	Title           string   `json:"title" binding:"required"`
-	Slug            string   `json:"slug"`
-	Summary         string   `json:"summary"`
-	Content         string   `json:"content" binding:"required"`
-	Thumbnail       string   `json:"thumbnail"`
-	CategoryIds     []int    `json:"categoryIds"`
-	MetaTitle       string   `json:"metaTitle"`
-	MetaKeywords    string   `json:"metaKeywords"`
-	MetaDescription string   `json:"metaDescription"`
-	ContentModel    string   `json:"contentModel"`
-	Type            string   `json:"type"`
-	TagIDs          []int    `json:"tagIds"`
-	AddTags         []string `json:"addTags"`
-	IsTop           int8     `json:"isTop"`
-	IsComment       int8     `json:"isComment"`
-	Status          int8     `json:"status"`
+	Id              int             `json:"id"`
+	Title           string          `json:"title" binding:"required"`
+	Slug            string          `json:"slug"`
+	Summary         string          `json:"summary"`
+	Content         string          `json:"content" binding:"required"`
+	Thumbnail       string          `json:"thumbnail"`
+	CategoryIds     []int           `json:"categoryIds"`
+	MetaTitle       string          `json:"metaTitle"`
+	MetaKeywords    string          `json:"metaKeywords"`
+	MetaDescription string          `json:"metaDescription"`
+	ContentModel    string          `json:"contentModel"`
+	Type            string          `json:"type"`
+	TagIDs          []int           `json:"tagIds"`
+	AddTags         []string        `json:"addTags"`
+	IsTop           int8            `json:"isTop"`
+	IsComment       int8            `json:"isComment"`
+	Status          int8            `json:"status"`
+	PublishAt       *utils.DateTime `json:"publishAt"`
+	Template        string          `json:"template"` // 自定义文章模板，为空时使用分类或主题默认的模板
 }
 
 // ArticleResponse 文章响应结构
@@ -50,6 +56,9 @@ type ArticleViewResponse struct {
 	models.Article
 	Categories []CategoryResponse `json:"categories"`
 	Tags       []models.Tag       `json:"tags"`
+	// 搜索结果的高亮标题和摘要片段
+	HighlightTitle template.HTML `json:"highlightTitle,omitempty"`
+	Snippet        template.HTML `json:"snippet,omitempty"`
 }
 
 type ArticlePageRequest struct {
@@ -57,6 +66,9 @@ type ArticlePageRequest struct {
 	CategoryID uint   `json:"categoryId" form:"categoryId"`
 	Title      string `json:"title" form:"title"`
 	Status     *int8  `json:"status" form:"status"`
+	// 发布时间范围，可配合定时发布状态筛选待发布文章
+	PublishStart string `json:"publishStart" form:"publishStart"`
+	PublishEnd   string `json:"publishEnd" form:"publishEnd"`
 }
 
 // ArticlePage 文章分页
@@ -72,11 +84,29 @@ func (a *ArticleController) ArticlePage(c *gin.Context) {
 		return
 	}
 
+	publishStart, publishEnd, err := utils.ParseTimeRange(pageParam.PublishStart, pageParam.PublishEnd)
+	if err != nil {
+		common.BadRequest(c, err.Error())
+		return
+	}
+
 	var articles []models.Article
 	var total int64
 
 	query := database.DB.Model(&models.Article{})
 
+	// 没有管理他人文章权限的用户只能看到自己的文章
+	if !can(c, models.PermArticleEditOthers) {
+		query = query.Where("created_by = ?", c.GetInt("user_id"))
+	}
+
+	if publishStart != nil {
+		query = query.Where("publish_at >= ?", publishStart)
+	}
+	if publishEnd != nil {
+		query = query.Where("publish_at <= ?", publishEnd)
+	}
+
 	if pageParam.Status != nil {
 		query = query.Where("status = ?", pageParam.Status)
 	}
@@ -113,6 +143,9 @@ func (a *ArticleController) GetArticle(c *gin.Context) {
 		common.ServerError(c, "文章不存在")
 		return
 	}
+	if !requireOwned(c, article.CreatedBy, models.PermArticleEditOthers) {
+		return
+	}
 
 	// 查询关联分类ID，不使用关联查询
 	var categoryIds []int
@@ -143,6 +176,15 @@ func (a *ArticleController) DeleteArticle(c *gin.Context) {
 		return
 	}
 
+	var article models.Article
+	if err := database.DB.First(&article, id).Error; err != nil {
+		common.NotFound(c, "文章不存在")
+		return
+	}
+	if !requireOwned(c, article.CreatedBy, models.PermArticleEditOthers) {
+		return
+	}
+
 	// 开启事务删除文章及其关联
 	tx := database.DB.Begin()
 
@@ -160,6 +202,20 @@ func (a *ArticleController) DeleteArticle(c *gin.Context) {
 		return
 	}
 
+	// 删除文章历史版本
+	if err := tx.Where("article_id = ?", id).Delete(&models.ArticleRevision{}).Error; err != nil {
+		tx.Rollback()
+		common.ServerError(c, "删除文章历史版本失败: "+err.Error())
+		return
+	}
+
+	// 删除文章slug历史
+	if err := deleteSlugHistory(tx, models.SlugTypeArticle, int(id)); err != nil {
+		tx.Rollback()
+		common.ServerError(c, "删除文章slug历史失败: "+err.Error())
+		return
+	}
+
 	// 删除文章
 	if err := tx.Delete(&models.Article{}, id).Error; err != nil {
 		tx.Rollback()
@@ -168,109 +224,114 @@ func (a *ArticleController) DeleteArticle(c *gin.Context) {
 	}
 
 	tx.Commit()
+	InvalidateSEOCache()
+	invalidateCache(cacheTagArticles, articleCacheTag(int(id)))
+	IndexArticles(int(id))
 	common.SuccessWithMessage(c, "文章删除成功", nil)
 }
 
 // Index 文章列表页面
 func (a *ArticleController) Index(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize := 10
 	categoryID, _ := strconv.Atoi(c.Query("category_id"))
 	tagID, _ := strconv.Atoi(c.Query("tag_id"))
+	a.renderIndex(c, categoryID, tagID)
+}
+
+// renderIndex 渲染文章列表页面，可按分类或标签筛选，渲染结果按请求URI缓存
+func (a *ArticleController) renderIndex(c *gin.Context, categoryID, tagID int) {
+	serveCachedPage(c, []string{cacheTagArticles, cacheTagCategories, cacheTagTags}, func() {
+		a.renderIndexPage(c, categoryID, tagID)
+	})
+}
+
+// renderIndexPage 查询并渲染文章列表页面
+func (a *ArticleController) renderIndexPage(c *gin.Context, categoryID, tagID int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize := 10
 	keyword := strings.TrimSpace(c.Query("keyword"))
 	sortType := strings.TrimSpace(c.DefaultQuery("sort", "latest")) // latest 或 hot
 
 	var articles []models.Article
 	var total int64
-	query := database.DB.Model(&models.Article{}).Where("status = ?", models.ArticleStatusPublished) // 只显示已发布的文章
+	query := database.DB.Model(&models.Article{}).Scopes(models.ScopePublished) // 只显示已发布的文章
 
 	if categoryID > 0 {
 		query = query.Joins("left join m_article_category ac ON m_article.id = ac.article_id").
 			Where("ac.category_id = ?", categoryID)
 	}
 	if tagID > 0 {
-		query = query.Joins("left join m_article_tags at ON m_article.id = at.article_id").
+		query = query.Joins("left join m_article_tag at ON m_article.id = at.article_id").
 			Where("at.tag_id = ?", tagID)
 	}
-	if keyword != "" {
-		query = query.Where("title LIKE ? OR content LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
-	}
 
-	// 根据排序类型设置排序规则
-	if sortType == "hot" {
-		query.Order("m_article.is_top DESC, m_article.view_count DESC, m_article.created_at DESC")
+	offset := (page - 1) * pageSize
+	var hits map[int]fulltext.Hit
+	if keyword != "" && searchIndex != nil {
+		// 使用全文索引按相关度排序
+		articles, total, hits = searchArticles(query, keyword, offset, pageSize)
 	} else {
-		query.Order("m_article.is_top DESC, m_article.created_at DESC")
-	}
+		if keyword != "" {
+			query = query.Where("title LIKE ? OR content LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+		}
 
-	query.Count(&total)
+		// 根据排序类型设置排序规则
+		if sortType == "hot" {
+			query.Order("m_article.is_top DESC, m_article.view_count DESC, COALESCE(m_article.publish_at, m_article.created_at) DESC")
+		} else {
+			query.Order("m_article.is_top DESC, COALESCE(m_article.publish_at, m_article.created_at) DESC")
+		}
 
-	offset := (page - 1) * pageSize
-	query.Limit(pageSize).
-		Offset(offset).
-		Find(&articles)
+		query.Count(&total)
+
+		query.Limit(pageSize).
+			Offset(offset).
+			Find(&articles)
+	}
 	// 获取文章和分类、标签的关联
 	articleResArray, err := utils.ConvertSliceTo[ArticleViewResponse](articles)
 	if err != nil {
 		common.ServerError(c, "参数错误: "+err.Error())
 		return
 	}
+	ctx := c.Request.Context()
+	// 获取分类列表及文章数量
+	categoriesRes, err := cachedCategoryList(ctx)
+	if err != nil {
+		common.ServerError(c, "查询分类失败: "+err.Error())
+		return
+	}
+	counts := make(map[int]int64, len(categoriesRes))
+	for _, category := range categoriesRes {
+		counts[category.Id] = category.ArticleCount
+	}
+	articleIds := make([]int, 0, len(articles))
+	for _, article := range articles {
+		articleIds = append(articleIds, article.Id)
+	}
+	articleCategories, articleTags := loadArticleTaxonomies(articleIds, counts)
 	for i := range articleResArray {
-		var categories []models.Category
-		database.DB.Joins("JOIN m_article_category ac ON m_category.id = ac.category_id").
-			Where("ac.article_id = ?", articles[i].Id).
-			Find(&categories)
-		categoriesRes, err := utils.ConvertSliceTo[CategoryResponse](&categories)
-		if err != nil {
-			common.ServerError(c, "参数错误: "+err.Error())
-			return
+		articleResArray[i].Categories = articleCategories[articles[i].Id]
+		articleResArray[i].Tags = articleTags[articles[i].Id]
+		if hit, ok := hits[articles[i].Id]; ok {
+			articleResArray[i].HighlightTitle = template.HTML(hit.Title)
+			articleResArray[i].Snippet = template.HTML(hit.Snippet)
 		}
-		// 获取分类文章数量
-		for j := range categoriesRes {
-			var count int64
-			database.DB.Model(&models.ArticleCategory{}).
-				Where("category_id = ?", categoriesRes[j].Id).
-				Count(&count)
-			categoriesRes[j].ArticleCount = count
-		}
-		articleResArray[i].Categories = categoriesRes
-		var tags []models.Tag
-		database.DB.Joins("JOIN m_article_tag at ON m_tag.id = at.tag_id").
-			Where("at.article_id = ?", articles[i].Id).
-			Find(&tags)
-		articleResArray[i].Tags = tags
 	}
 	// 获取推荐阅读
-	var recommendArticles []models.Article
-	database.DB.Model(&models.Article{}).
-		Where("status = ?", models.ArticleStatusPublished).
-		Order("great_count DESC").
-		Limit(5).
-		Find(&recommendArticles)
-
-	// 获取分类列表
-	var categories []models.Category
-	database.DB.Find(&categories)
-	// 转换为响应结构
-	categoriesRes, err := utils.ConvertSliceTo[CategoryResponse](&categories)
+	recommendArticles, err := cachedRecommendList(ctx)
 	if err != nil {
-		common.ServerError(c, "参数错误: "+err.Error())
+		common.ServerError(c, "查询推荐文章失败: "+err.Error())
 		return
 	}
-	// 获取分类文章数量
-	for i := range categoriesRes {
-		var count int64
-		database.DB.Model(&models.ArticleCategory{}).
-			Where("category_id = ?", categoriesRes[i].Id).
-			Count(&count)
-		categoriesRes[i].ArticleCount = count
-	}
 
 	// 获取标签列表
-	var tags []models.Tag
-	database.DB.Find(&tags)
+	tags, err := cachedTagList(ctx)
+	if err != nil {
+		common.ServerError(c, "查询标签失败: "+err.Error())
+		return
+	}
 
-	c.HTML(http.StatusOK, "default/index.html", gin.H{
+	renderHTML(c, "index.html", gin.H{
 		"articles":   articleResArray,
 		"categories": categoriesRes,
 		"tags":       tags,
@@ -288,38 +349,44 @@ func (a *ArticleController) Index(c *gin.Context) {
 	})
 }
 
-// Show 文章详情页面
+// Show 文章详情页面，支持ID、slug及历史slug访问
 func (a *ArticleController) Show(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.HTML(http.StatusNotFound, "error/error.html", gin.H{
-			"message": "文章不存在",
-		})
-		return
-	}
+	a.showArticle(c, c.Param("id"))
+}
 
+// showArticle 根据ID或slug渲染文章详情，非规范链接301跳转到固定链接
+func (a *ArticleController) showArticle(c *gin.Context, value string) {
 	var article models.Article
-	if err := database.DB.Where("id = ? AND status = ?", id, models.ArticleStatusPublished).
-		First(&article).Error; err != nil {
-		c.HTML(http.StatusNotFound, "error/error.html", gin.H{
-			"message": "文章不存在",
-		})
+	query := database.DB.Model(&models.Article{}).Scopes(models.ScopePublished)
+	if err := resolveSlug(query, models.SlugTypeArticle, value, &article); err != nil {
+		pageNotFound(c, "文章不存在")
 		return
 	}
-	articleRes, err := utils.ConvertTo[ArticleViewResponse](article)
-	if err != nil {
-		common.ServerError(c, "参数错误: "+err.Error())
+	if redirectCanonical(c, article.Permalink()) {
 		return
 	}
 
-	// 增加访问量
+	// 增加访问量，页面缓存命中时同样计数
 	database.DB.Model(&article).Update("view_count", gorm.Expr("view_count + ?", 1))
 	article.ViewCount++
 
+	serveCachedPage(c, []string{articleCacheTag(article.Id), cacheTagCategories, cacheTagTags}, func() {
+		a.renderArticlePage(c, &article)
+	})
+}
+
+// renderArticlePage 查询文章分类、标签并渲染文章详情页面
+func (a *ArticleController) renderArticlePage(c *gin.Context, article *models.Article) {
+	articleRes, err := utils.ConvertTo[ArticleViewResponse](article)
+	if err != nil {
+		common.ServerError(c, "参数错误: "+err.Error())
+		return
+	}
+
 	// 获取文章分类
 	var categories []models.Category
 	database.DB.Joins("JOIN m_article_category ac ON m_category.id = ac.category_id").
-		Where("ac.article_id = ?", id).
+		Where("ac.article_id = ?", article.Id).
 		Find(&categories)
 	to, err := utils.ConvertSliceTo[CategoryResponse](&categories)
 	if err != nil {
@@ -331,13 +398,35 @@ func (a *ArticleController) Show(c *gin.Context) {
 	// 获取文章标签
 	var tags []models.Tag
 	database.DB.Joins("JOIN m_article_tag at ON m_tag.id = at.tag_id").
-		Where("at.article_id = ?", id).
+		Where("at.article_id = ?", article.Id).
 		Find(&tags)
 	articleRes.Tags = tags
 
-	c.HTML(http.StatusOK, "default/article.html", gin.H{
-		"article": articleRes,
-		"title":   article.Title,
+	// 获取评论树，按顶层评论分页
+	commentPage, _ := strconv.Atoi(c.DefaultQuery("comment_page", "1"))
+	if commentPage < 1 {
+		commentPage = 1
+	}
+	comments := loadCommentTree(article.Id, commentPage, commentPageSize())
+
+	name := articleTemplate(article, categories)
+	if name == "" {
+		logger.Error("No article template found for article", article.Id)
+		c.String(http.StatusInternalServerError, "文章模板不存在")
+		return
+	}
+
+	renderHTML(c, name, gin.H{
+		"article":       articleRes,
+		"title":         article.Title,
+		"comments":      comments.List,
+		"comment_count": comments.CommentCount,
+		"comment_pagination": gin.H{
+			"page":      comments.Page,
+			"page_size": comments.PageSize,
+			"total":     comments.Total,
+			"pages":     comments.Pages,
+		},
 	})
 }
 
@@ -348,25 +437,15 @@ func (a *ArticleController) PublishArticle(c *gin.Context) {
 		common.BadRequest(c, "参数错误: "+err.Error())
 		return
 	}
-	tagIds := req.TagIDs
-
-	// 生成slug
-	if req.Slug == "" {
-		req.Slug = utils.GenerateSlug(req.Title)
-	}
-	// 如果addTag不为空，则创建标签
-	if len(req.AddTags) > 0 {
-		for _, tagName := range req.AddTags {
-			tag := models.Tag{
-				Name: tagName,
-			}
-			if err := database.DB.Where("name = ?", tagName).FirstOrCreate(&tag).Error; err != nil {
-				common.ServerError(c, "创建标签失败: "+err.Error())
-				return
-			}
-			tagIds = append(tagIds, tag.Id)
-		}
+	if !requirePublishPermission(c, req.Status, nil) {
+		return
+	}
+	if err := checkArticleTemplate(req.Template); err != nil {
+		common.BadRequest(c, err.Error())
+		return
 	}
+	// 生成唯一slug
+	req.Slug = uniqueSlug(database.DB, models.SlugTypeArticle, req.Slug, req.Title, 0)
 
 	// 创建文章
 	article, err := utils.ConvertTo[models.Article](req)
@@ -375,44 +454,40 @@ func (a *ArticleController) PublishArticle(c *gin.Context) {
 		return
 	}
 
-	if req.Status == 1 {
-		article.CreatedAt = time.Now()
+	if userId := c.GetInt("user_id"); userId > 0 {
+		article.UserId = &userId
+		article.SetCreator(userId)
 	}
-
-	if err := database.DB.Create(&article).Error; err != nil {
-		common.ServerError(c, "创建文章失败: "+err.Error())
+	if err := applyPublishStatus(article, req, nil); err != nil {
+		common.BadRequest(c, err.Error())
 		return
 	}
 
-	// 关联标签
-	if len(tagIds) > 0 {
-		var tags []models.ArticleTag
-		for _, tagID := range tagIds {
-			tags = append(tags, models.ArticleTag{
-				ArticleId: article.Id,
-				TagId:     tagID,
-			})
+	// 文章、标签、关联和版本快照在同一事务中保存，避免失败时留下不完整的文章
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// 如果addTag不为空，则创建标签
+		tagIds, err := createArticleTags(tx, req.TagIDs, req.AddTags, c.GetInt("user_id"))
+		if err != nil {
+			return err
 		}
-		if err := database.DB.Create(&tags).Error; err != nil {
-			common.ServerError(c, "关联标签失败: "+err.Error())
-			return
+		if err := tx.Create(&article).Error; err != nil {
+			return fmt.Errorf("创建文章失败: %w", err)
 		}
-	}
-	// 关联分类
-	if len(req.CategoryIds) > 0 {
-		var categories []models.ArticleCategory
-		for _, categoryID := range req.CategoryIds {
-			categories = append(categories, models.ArticleCategory{
-				ArticleId:  article.Id,
-				CategoryId: categoryID,
-			})
-		}
-		if err := database.DB.Create(&categories).Error; err != nil {
-			common.ServerError(c, "关联分类失败: "+err.Error())
-			return
+		// 关联标签和分类
+		if err := saveArticleRelations(tx, article.Id, tagIds, req.CategoryIds); err != nil {
+			return err
 		}
+		// 保存版本快照
+		return saveArticleRevision(tx, article, req.CategoryIds, tagIds, c.GetInt("user_id"), "发布文章")
+	})
+	if err != nil {
+		common.ServerError(c, err.Error())
+		return
 	}
 
+	InvalidateSEOCache()
+	invalidateCache(cacheTagArticles, cacheTagTags)
+	IndexArticles(article.Id)
 	common.Success(c, gin.H{
 		"id": article.Id,
 	})
@@ -426,15 +501,27 @@ func (a *ArticleController) UpdateArticle(c *gin.Context) {
 		return
 	}
 
-	if err := database.DB.First(&models.Article{}, req.Id).Error; err != nil {
+	var existing models.Article
+	if err := database.DB.First(&existing, req.Id).Error; err != nil {
 		common.NotFound(c, "文章不存在")
 		return
 	}
+	if !requireOwned(c, existing.CreatedBy, models.PermArticleEditOthers) || !requirePublishPermission(c, req.Status, &existing) {
+		return
+	}
+	// 沿用原有模板时不再校验，切换主题后原模板可能不存在，渲染时会依次回退
+	if req.Template != existing.Template {
+		if err := checkArticleTemplate(req.Template); err != nil {
+			common.BadRequest(c, err.Error())
+			return
+		}
+	}
 
-	// 生成slug
+	// 未指定slug时沿用原slug，避免修改标题导致链接变化
 	if req.Slug == "" {
-		req.Slug = utils.GenerateSlug(req.Title)
+		req.Slug = existing.Slug
 	}
+	req.Slug = uniqueSlug(database.DB, models.SlugTypeArticle, req.Slug, req.Title, existing.Id)
 
 	// 更新文章
 	article, err := utils.ConvertTo[models.Article](req)
@@ -442,39 +529,119 @@ func (a *ArticleController) UpdateArticle(c *gin.Context) {
 		common.ServerError(c, "参数错误: "+err.Error())
 		return
 	}
-	// 如果状态从草稿改为已发布，设置发布时间
-	if article.Status == 0 && req.Status == 1 {
-		article.CreatedAt = time.Now()
+	article.CreatedAt = existing.CreatedAt
+	article.CreatedBy = existing.CreatedBy
+	article.SetUpdater(c.GetInt("user_id"))
+	article.UserId = existing.UserId
+	if err := applyPublishStatus(article, req, &existing); err != nil {
+		common.BadRequest(c, err.Error())
+		return
+	}
+
+	// 文章、slug变更记录、标签、关联和版本快照在同一事务中保存
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&article).Error; err != nil {
+			return fmt.Errorf("更新文章失败: %w", err)
+		}
+		if err := recordSlugChange(tx, models.SlugTypeArticle, article.Id, existing.Slug, article.Slug); err != nil {
+			return err
+		}
+		// 如果addTag不为空，则创建标签
+		tagIds, err := createArticleTags(tx, req.TagIDs, req.AddTags, c.GetInt("user_id"))
+		if err != nil {
+			return err
+		}
+		// 重建标签和分类关联
+		if err := saveArticleRelations(tx, article.Id, tagIds, req.CategoryIds); err != nil {
+			return err
+		}
+		// 保存版本快照
+		return saveArticleRevision(tx, article, req.CategoryIds, tagIds, c.GetInt("user_id"), "更新文章")
+	})
+	if err != nil {
+		common.ServerError(c, err.Error())
+		return
+	}
+
+	InvalidateSEOCache()
+	invalidateCache(cacheTagArticles, cacheTagTags, articleCacheTag(article.Id))
+	IndexArticles(article.Id)
+	common.SuccessWithMessage(c, "文章更新成功", nil)
+}
+
+// requirePublishPermission 检查当前用户能否将文章设为指定状态，没有发布权限时只能保存草稿，
+// 也不能修改已发布或定时发布的文章；没有权限时直接返回错误响应
+func requirePublishPermission(c *gin.Context, status int8, existing *models.Article) bool {
+	if can(c, models.PermArticlePublish) {
+		return true
+	}
+	if status != models.ArticleStatusDraft {
+		common.Forbidden(c, "没有发布文章的权限，只能保存为草稿")
+		return false
+	}
+	if existing != nil && !existing.IsDraft() {
+		common.Forbidden(c, "没有发布文章的权限，不能修改已发布的文章")
+		return false
 	}
+	return true
+}
+
+// applyPublishStatus 根据请求状态设置文章发布时间
+// 立即发布时记录发布时间（已发布的文章保留原发布时间），定时发布要求发布时间晚于当前时间
+func applyPublishStatus(article *models.Article, req ArticleRequest, existing *models.Article) error {
+	now := time.Now()
 	article.Status = req.Status
 
-	if err := database.DB.Save(&article).Error; err != nil {
-		common.ServerError(c, "更新文章失败: "+err.Error())
-		return
-	}
-	tagIds := req.TagIDs
-	// 如果addTag不为空，则创建标签
-	if len(req.AddTags) > 0 {
-		for _, tagName := range req.AddTags {
-			tag := models.Tag{
-				Name: tagName,
-			}
-			if err := database.DB.Where("name = ?", tagName).FirstOrCreate(&tag).Error; err != nil {
-				common.ServerError(c, "创建标签失败: "+err.Error())
-				return
-			}
-			tagIds = append(tagIds, tag.Id)
+	switch req.Status {
+	case models.ArticleStatusPublished:
+		if existing != nil && existing.IsPublished() && existing.PublishAt != nil {
+			article.PublishAt = existing.PublishAt
+		} else {
+			article.PublishAt = &now
+		}
+	case models.ArticleStatusScheduled:
+		if req.PublishAt == nil || !req.PublishAt.IsValid() {
+			return fmt.Errorf("定时发布必须设置发布时间")
+		}
+		if !req.PublishAt.After(now) {
+			return fmt.Errorf("定时发布时间必须晚于当前时间")
+		}
+		publishAt := req.PublishAt.Time
+		article.PublishAt = &publishAt
+	case models.ArticleStatusDraft:
+		article.PublishAt = nil
+	default:
+		return fmt.Errorf("无效的文章状态: %d", req.Status)
+	}
+	return nil
+}
+
+// createArticleTags 按名称查找或创建标签，返回已选标签和新标签的ID
+func createArticleTags(db *gorm.DB, tagIds []int, names []string, userId int) ([]int, error) {
+	for _, tagName := range names {
+		tag := models.Tag{
+			Name: tagName,
 		}
+		slug := uniqueSlug(db, models.SlugTypeTag, "", tagName, 0)
+		attrs := models.Tag{Slug: slug}
+		attrs.SetCreator(userId)
+		if err := db.Where("name = ?", tagName).Attrs(attrs).FirstOrCreate(&tag).Error; err != nil {
+			return nil, fmt.Errorf("创建标签失败: %w", err)
+		}
+		tagIds = append(tagIds, tag.Id)
 	}
+	return tagIds, nil
+}
+
+// saveArticleRelations 重建文章的标签和分类关联
+func saveArticleRelations(db *gorm.DB, articleId int, tagIds, categoryIds []int) error {
 	// 删除旧的标签关联
-	if err := database.DB.Where("article_id = ?", req.Id).Delete(&models.ArticleTag{}).Error; err != nil {
-		common.ServerError(c, "删除旧标签关联失败: "+err.Error())
-		return
+	if err := db.Where("article_id = ?", articleId).Delete(&models.ArticleTag{}).Error; err != nil {
+		return fmt.Errorf("删除旧标签关联失败: %w", err)
 	}
 	// 删除旧的分类关联
-	if err := database.DB.Where("article_id = ?", req.Id).Delete(&models.ArticleCategory{}).Error; err != nil {
-		common.ServerError(c, "删除旧分类关联失败: "+err.Error())
-		return
+	if err := db.Where("article_id = ?", articleId).Delete(&models.ArticleCategory{}).Error; err != nil {
+		return fmt.Errorf("删除旧分类关联失败: %w", err)
 	}
 
 	// 关联标签
@@ -482,29 +649,26 @@ func (a *ArticleController) UpdateArticle(c *gin.Context) {
 		var tags []models.ArticleTag
 		for _, tagID := range tagIds {
 			tags = append(tags, models.ArticleTag{
-				ArticleId: article.Id,
+				ArticleId: articleId,
 				TagId:     tagID,
 			})
 		}
-		if err := database.DB.Create(&tags).Error; err != nil {
-			common.ServerError(c, "关联标签失败: "+err.Error())
-			return
+		if err := db.Create(&tags).Error; err != nil {
+			return fmt.Errorf("关联标签失败: %w", err)
 		}
 	}
 	// 关联分类
-	if len(req.CategoryIds) > 0 {
+	if len(categoryIds) > 0 {
 		var categories []models.ArticleCategory
-		for _, categoryID := range req.CategoryIds {
+		for _, categoryID := range categoryIds {
 			categories = append(categories, models.ArticleCategory{
-				ArticleId:  article.Id,
+				ArticleId:  articleId,
 				CategoryId: categoryID,
 			})
 		}
-		if err := database.DB.Create(&categories).Error; err != nil {
-			common.ServerError(c, "关联分类失败: "+err.Error())
-			return
+		if err := db.Create(&categories).Error; err != nil {
+			return fmt.Errorf("关联分类失败: %w", err)
 		}
 	}
-
-	common.SuccessWithMessage(c, "文章更新成功", nil)
+	return nil
 }
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"fmt"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+	"matuto-blog/pkg/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RevisionDiffResponse 版本差异响应结构
+type RevisionDiffResponse struct {
+	From    int              `json:"from"`
+	To      int              `json:"to"`
+	Title   []utils.DiffLine `json:"title"`
+	Summary []utils.DiffLine `json:"summary"`
+	Content []utils.DiffLine `json:"content"`
+}
+
+// RevisionList 文章历史版本列表
+func (a *ArticleController) RevisionList(c *gin.Context) {
+	articleId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.BadRequest(c, "无效的文章ID")
+		return
+	}
+	if _, ok := a.findOwnedArticle(c, articleId); !ok {
+		return
+	}
+
+	var revisions []models.ArticleRevision
+	database.DB.Model(&models.ArticleRevision{}).
+		Select("id, created_at, created_by, article_id, version, title, status, slug, remark").
+		Where("article_id = ?", articleId).
+		Order("version DESC").
+		Find(&revisions)
+
+	common.Success(c, revisions)
+}
+
+// RevisionDetail 文章历史版本详情
+func (a *ArticleController) RevisionDetail(c *gin.Context) {
+	revision, ok := a.findRevision(c, c.Param("revisionId"))
+	if !ok {
+		return
+	}
+	common.Success(c, gin.H{
+		"revision":    revision,
+		"categoryIds": revision.GetCategoryIds(),
+		"tagIds":      revision.GetTagIds(),
+	})
+}
+
+// RevisionDiff 比较两个历史版本的差异，未指定to时与文章当前内容比较
+func (a *ArticleController) RevisionDiff(c *gin.Context) {
+	from, ok := a.findRevision(c, c.Query("from"))
+	if !ok {
+		return
+	}
+
+	var to *models.ArticleRevision
+	if c.Query("to") != "" {
+		if to, ok = a.findRevision(c, c.Query("to")); !ok {
+			return
+		}
+	} else {
+		var article models.Article
+		if err := database.DB.First(&article, from.ArticleId).Error; err != nil {
+			common.NotFound(c, "文章不存在")
+			return
+		}
+		to = models.NewArticleRevision(&article, nil, nil)
+	}
+
+	common.Success(c, RevisionDiffResponse{
+		From:    from.Version,
+		To:      to.Version,
+		Title:   utils.DiffLines(from.Title, to.Title),
+		Summary: utils.DiffLines(from.Summary, to.Summary),
+		Content: utils.DiffLines(from.Content, to.Content),
+	})
+}
+
+// RevisionRestore 将文章恢复到指定历史版本
+func (a *ArticleController) RevisionRestore(c *gin.Context) {
+	revision, ok := a.findRevision(c, c.Param("revisionId"))
+	if !ok {
+		return
+	}
+
+	var article models.Article
+	if err := database.DB.First(&article, revision.ArticleId).Error; err != nil {
+		common.NotFound(c, "文章不存在")
+		return
+	}
+	if !requirePublishPermission(c, article.Status, &article) {
+		return
+	}
+
+	oldSlug := article.Slug
+	revision.ApplyTo(&article)
+	article.SetUpdater(c.GetInt("user_id"))
+	article.Slug = uniqueSlug(database.DB, models.SlugTypeArticle, article.Slug, article.Title, article.Id)
+	categoryIds := revision.GetCategoryIds()
+	tagIds := revision.GetTagIds()
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&article).Error; err != nil {
+			return fmt.Errorf("恢复文章失败: %w", err)
+		}
+		if err := recordSlugChange(tx, models.SlugTypeArticle, article.Id, oldSlug, article.Slug); err != nil {
+			return err
+		}
+		if err := saveArticleRelations(tx, article.Id, tagIds, categoryIds); err != nil {
+			return err
+		}
+		remark := fmt.Sprintf("恢复自版本 %d", revision.Version)
+		return saveArticleRevision(tx, &article, categoryIds, tagIds, c.GetInt("user_id"), remark)
+	})
+	if err != nil {
+		common.ServerError(c, err.Error())
+		return
+	}
+
+	InvalidateSEOCache()
+	invalidateCache(cacheTagArticles, articleCacheTag(article.Id))
+	IndexArticles(article.Id)
+	common.SuccessWithMessage(c, "文章已恢复到版本 "+strconv.Itoa(revision.Version), nil)
+}
+
+// findRevision 根据ID查询属于当前文章的历史版本
+func (a *ArticleController) findRevision(c *gin.Context, revisionIdStr string) (*models.ArticleRevision, bool) {
+	articleId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.BadRequest(c, "无效的文章ID")
+		return nil, false
+	}
+	revisionId, err := strconv.Atoi(revisionIdStr)
+	if err != nil {
+		common.BadRequest(c, "无效的版本ID")
+		return nil, false
+	}
+	if _, ok := a.findOwnedArticle(c, articleId); !ok {
+		return nil, false
+	}
+
+	var revision models.ArticleRevision
+	if err := database.DB.Where("id = ? AND article_id = ?", revisionId, articleId).First(&revision).Error; err != nil {
+		common.NotFound(c, "版本不存在")
+		return nil, false
+	}
+	return &revision, true
+}
+
+// findOwnedArticle 查询当前用户可以操作的文章，不存在或没有权限时直接返回错误响应
+func (a *ArticleController) findOwnedArticle(c *gin.Context, articleId int) (*models.Article, bool) {
+	var article models.Article
+	if err := database.DB.First(&article, articleId).Error; err != nil {
+		common.NotFound(c, "文章不存在")
+		return nil, false
+	}
+	if !requireOwned(c, article.CreatedBy, models.PermArticleEditOthers) {
+		return nil, false
+	}
+	return &article, true
+}
+
+// saveArticleRevision 保存文章版本快照，版本号在该文章内递增
+func saveArticleRevision(db *gorm.DB, article *models.Article, categoryIds, tagIds []int, userId int, remark string) error {
+	var maxVersion int
+	db.Model(&models.ArticleRevision{}).
+		Where("article_id = ?", article.Id).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&maxVersion)
+
+	revision := models.NewArticleRevision(article, categoryIds, tagIds)
+	revision.Version = maxVersion + 1
+	revision.Remark = remark
+	revision.CreatedBy = userId
+	revision.UpdatedBy = userId
+
+	if err := db.Create(revision).Error; err != nil {
+		return fmt.Errorf("保存文章版本失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/logger"
+	"matuto-blog/pkg/storage"
+
+	"gorm.io/gorm"
+)
+
+// storeAttachBlob 保存附件内容，同一存储中已有相同内容的文件时复用该文件并增加引用计数，否则上传到path
+// hash为内容的SHA-256，调用方需要预先计算，复用时只在文件丢失的情况下读取reader
+func storeAttachBlob(ctx context.Context, adapter storage.StorageAdapter, hash, path string, reader io.Reader, size int64, contentType string) (*models.AttachBlob, error) {
+	storageType := adapter.GetStorageType()
+	blob, err := acquireAttachBlob(storageType, hash)
+	if err != nil {
+		return nil, err
+	}
+	if blob != nil {
+		// 存储中的文件丢失时重新上传到原路径，修复所有引用该文件的附件
+		if exists, err := adapter.Exists(ctx, blob.Path); err == nil && !exists {
+			logger.Warn("Attachment blob missing, uploading again:", blob.Path)
+			if _, err := storage.Store(ctx, adapter, blob.Path, reader, size, blob.MimeType); err != nil {
+				releaseAttachBlob(ctx, blob.Id)
+				return nil, err
+			}
+		}
+		return blob, nil
+	}
+
+	result, err := storage.Store(ctx, adapter, path, reader, size, contentType)
+	if err != nil {
+		return nil, err
+	}
+	blob = &models.AttachBlob{
+		Storage:  storageType,
+		Hash:     result.Hash,
+		Path:     result.Path,
+		URL:      result.URL,
+		Size:     result.Size,
+		MimeType: result.ContentType,
+		RefCount: 1,
+	}
+	if err := database.DB.Create(blob).Error; err != nil {
+		// 并发上传了相同的内容，改为引用先保存的文件
+		adapter.Delete(ctx, result.Path)
+		if existing, acquireErr := acquireAttachBlob(storageType, result.Hash); acquireErr == nil && existing != nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return blob, nil
+}
+
+// acquireAttachBlob 为已有的文件增加一个引用，没有相同内容的文件时返回nil
+func acquireAttachBlob(storageType, hash string) (*models.AttachBlob, error) {
+	// 只复用仍有引用的文件，引用数为0的文件正在被删除
+	result := database.DB.Model(&models.AttachBlob{}).
+		Where("storage = ? AND hash = ? AND ref_count > 0", storageType, hash).
+		Update("ref_count", gorm.Expr("ref_count + 1"))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	var blob models.AttachBlob
+	if err := database.DB.Where("storage = ? AND hash = ?", storageType, hash).First(&blob).Error; err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+// releaseAttachBlob 减少文件的引用计数，最后一个引用释放后删除文件
+func releaseAttachBlob(ctx context.Context, blobId int) {
+	var blob models.AttachBlob
+	if err := database.DB.First(&blob, blobId).Error; err != nil {
+		return
+	}
+	if err := database.DB.Model(&models.AttachBlob{}).Where("id = ? AND ref_count > 0", blobId).
+		Update("ref_count", gorm.Expr("ref_count - 1")).Error; err != nil {
+		logger.Error("Failed to release attachment blob", blob.Path+":", err)
+		return
+	}
+
+	// 条件删除保证并发释放时只有一方删除文件
+	result := database.DB.Where("id = ? AND ref_count <= 0", blobId).Delete(&models.AttachBlob{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+	adapter, err := storage.GetAdapterByType(blob.Storage)
+	if err != nil {
+		logger.Warn("Failed to delete attachment file", blob.Path+":", err)
+		return
+	}
+	deleteImageVariants(ctx, adapter, blob.Id)
+	if err := adapter.Delete(ctx, blob.Path); err != nil {
+		logger.Warn("Failed to delete attachment file", blob.Path+":", err)
+	}
+	invalidateCache(cacheTagAttachments)
+}
+
+// releaseAttachFile 释放附件对文件的引用，去重之前上传的附件直接删除文件
+func releaseAttachFile(ctx context.Context, attachment *models.Attach) {
+	if attachment.BlobId == nil {
+		deleteAttachFile(ctx, attachment)
+		return
+	}
+	releaseAttachBlob(ctx, *attachment.BlobId)
+}
@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"errors"
+	"matuto-blog/config"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+	"matuto-blog/pkg/logger"
+	"matuto-blog/pkg/resumable"
+	"matuto-blog/pkg/storage"
+	"matuto-blog/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 分片大小的取值范围，下限与S3分片上传的最小分片一致
+const (
+	minChunkSize = 5 * 1024 * 1024
+	maxChunkSize = 64 * 1024 * 1024
+)
+
+// chunkStore 分片上传会话存储
+var chunkStore *resumable.Store
+
+// InitChunkUpload 初始化分片上传
+func InitChunkUpload() error {
+	store, err := resumable.NewStore(
+		config.GetString("upload.chunk_path"),
+		time.Duration(config.GetInt("upload.chunk_expire_hours"))*time.Hour,
+	)
+	if err != nil {
+		return err
+	}
+	store.SetOwnerLimits(
+		config.GetInt("upload.chunk_max_sessions"),
+		int64(config.GetInt("upload.chunk_max_pending_mb"))*1024*1024,
+	)
+	chunkStore = store
+	if removed := store.Cleanup(); removed > 0 {
+		logger.Info("Removed expired upload sessions:", removed)
+	}
+	return nil
+}
+
+// ChunkUploadRequest 创建分片上传请求
+type ChunkUploadRequest struct {
+	Filename  string `json:"filename" binding:"required"`
+	Size      int64  `json:"size" binding:"required"`
+	ChunkSize int64  `json:"chunkSize"` // 为空时使用配置的分片大小
+	Hash      string `json:"hash"`      // 完整文件的SHA-256，可选，完成时校验
+}
+
+// CreateChunkUpload 创建分片上传会话
+//
+// 上传流程：创建会话 -> 逐个上传分片，请求头X-Chunk-Checksum为分片内容的SHA-256
+// -> 全部上传后完成上传。中断后通过查询会话获取缺少的分片继续上传
+func (a *AttachmentController) CreateChunkUpload(ctx *gin.Context) {
+	if chunkStore == nil {
+		common.ServerError(ctx, "分片上传未启用")
+		return
+	}
+	var req ChunkUploadRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	req.Filename = strings.TrimSpace(req.Filename)
+	if err := models.ValidateGeneralFile(req.Filename, 0); err != nil {
+		common.BadRequest(ctx, err.Error())
+		return
+	}
+	if !allowedUploadExt(req.Filename) {
+		common.BadRequest(ctx, "不支持的文件类型")
+		return
+	}
+	maxSize := int64(config.GetInt("upload.chunk_max_size_mb")) * 1024 * 1024
+	if req.Size <= 0 || req.Size > maxSize {
+		common.BadRequest(ctx, "文件大小超过限制")
+		return
+	}
+	if req.Hash != "" && !validSHA256(req.Hash) {
+		common.BadRequest(ctx, "无效的文件哈希")
+		return
+	}
+	chunkSize := req.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = int64(config.GetInt("upload.chunk_size_mb")) * 1024 * 1024
+	}
+	if chunkSize < minChunkSize || chunkSize > maxChunkSize {
+		common.BadRequest(ctx, "分片大小应在5MB到64MB之间")
+		return
+	}
+
+	session, err := chunkStore.Create(req.Filename, req.Size, chunkSize, req.Hash, ctx.GetInt("user_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, resumable.ErrTooManySessions):
+			common.TooManyRequests(ctx, "未完成的上传过多，请完成或取消后再试", 0)
+		case errors.Is(err, resumable.ErrQuotaExceeded):
+			common.TooManyRequests(ctx, "未完成上传的文件总大小超过限制，请完成或取消后再试", 0)
+		default:
+			common.ServerError(ctx, "创建上传会话失败: "+err.Error())
+		}
+		return
+	}
+	common.Success(ctx, chunkSessionResponse(session))
+}
+
+// ChunkUploadStatus 查询分片上传会话，用于中断后继续上传
+func (a *AttachmentController) ChunkUploadStatus(ctx *gin.Context) {
+	session, ok := loadChunkSession(ctx)
+	if !ok {
+		return
+	}
+	common.Success(ctx, chunkSessionResponse(session))
+}
+
+// UploadChunk 上传一个分片，请求体为分片的原始内容
+func (a *AttachmentController) UploadChunk(ctx *gin.Context) {
+	session, ok := loadChunkSession(ctx)
+	if !ok {
+		return
+	}
+	index, err := strconv.Atoi(ctx.Param("index"))
+	if err != nil {
+		common.BadRequest(ctx, "无效的分片序号")
+		return
+	}
+	checksum := ctx.GetHeader("X-Chunk-Checksum")
+	if !validSHA256(checksum) {
+		common.BadRequest(ctx, "缺少分片校验和")
+		return
+	}
+
+	session, err = chunkStore.WriteChunk(session.ID, index, ctx.Request.Body, checksum)
+	if err != nil {
+		switch {
+		case errors.Is(err, resumable.ErrSessionNotFound):
+			common.NotFound(ctx, "上传会话不存在或已过期")
+		case errors.Is(err, resumable.ErrChunkIndex):
+			common.BadRequest(ctx, "分片序号超出范围")
+		case errors.Is(err, resumable.ErrChunkSize):
+			common.BadRequest(ctx, "分片大小不正确")
+		case errors.Is(err, resumable.ErrChecksumMismatch):
+			common.BadRequest(ctx, "分片校验和不匹配，请重新上传该分片")
+		default:
+			common.ServerError(ctx, "保存分片失败: "+err.Error())
+		}
+		return
+	}
+	common.Success(ctx, chunkSessionResponse(session))
+}
+
+// CompleteChunkUpload 合并全部分片并通过存储适配器保存为附件
+func (a *AttachmentController) CompleteChunkUpload(ctx *gin.Context) {
+	session, ok := loadChunkSession(ctx)
+	if !ok {
+		return
+	}
+	adapter := storage.GetCurrentAdapter()
+	if adapter == nil {
+		common.ServerError(ctx, "存储系统未初始化")
+		return
+	}
+
+	session, data, err := chunkStore.Open(session.ID)
+	if errors.Is(err, resumable.ErrIncomplete) {
+		common.BadRequest(ctx, "还有"+strconv.Itoa(len(session.Missing()))+"个分片未上传")
+		return
+	}
+	if err != nil {
+		common.ServerError(ctx, "读取分片失败: "+err.Error())
+		return
+	}
+	defer data.Close()
+
+	if session.Hash != "" {
+		hash, _, err := utils.CalculateSHA256(data)
+		if err != nil {
+			common.ServerError(ctx, "读取分片失败: "+err.Error())
+			return
+		}
+		if hash != session.Hash {
+			// 每个分片都通过了校验，整体哈希不一致说明分片本身有误，只能重新上传
+			chunkStore.Remove(session.ID)
+			common.BadRequest(ctx, "文件哈希不匹配，请重新上传")
+			return
+		}
+		if _, err := data.Seek(0, 0); err != nil {
+			common.ServerError(ctx, "读取分片失败: "+err.Error())
+			return
+		}
+	}
+
+	image := isProcessableImage(session.Filename, session.Size, "")
+	attachment, err := saveAttachment(ctx.Request.Context(), adapter, session.Filename, data, session.Size, image, ctx.GetInt("user_id"))
+	if err != nil {
+		common.ServerError(ctx, err.Error())
+		return
+	}
+	if err := chunkStore.Remove(session.ID); err != nil {
+		logger.Warn("Failed to remove upload session", session.ID+":", err)
+	}
+	common.SuccessWithMessage(ctx, "文件上传成功", attachResponse(attachment))
+}
+
+// CancelChunkUpload 取消分片上传并删除已上传的分片
+func (a *AttachmentController) CancelChunkUpload(ctx *gin.Context) {
+	session, ok := loadChunkSession(ctx)
+	if !ok {
+		return
+	}
+	if err := chunkStore.Remove(session.ID); err != nil {
+		common.ServerError(ctx, "取消上传失败: "+err.Error())
+		return
+	}
+	common.SuccessWithMessage(ctx, "已取消上传", nil)
+}
+
+// loadChunkSession 读取请求中的上传会话，只能访问自己创建的会话；失败时直接返回错误响应
+func loadChunkSession(ctx *gin.Context) (*resumable.Session, bool) {
+	if chunkStore == nil {
+		common.ServerError(ctx, "分片上传未启用")
+		return nil, false
+	}
+	session, err := chunkStore.Get(ctx.Param("uploadId"))
+	if errors.Is(err, resumable.ErrSessionNotFound) || (err == nil && session.Owner != ctx.GetInt("user_id")) {
+		common.NotFound(ctx, "上传会话不存在或已过期")
+		return nil, false
+	}
+	if err != nil {
+		common.ServerError(ctx, "读取上传会话失败: "+err.Error())
+		return nil, false
+	}
+	return session, true
+}
+
+// chunkSessionResponse 上传会话的状态
+func chunkSessionResponse(session *resumable.Session) gin.H {
+	return gin.H{
+		"uploadId":    session.ID,
+		"filename":    session.Filename,
+		"size":        session.Size,
+		"chunkSize":   session.ChunkSize,
+		"totalChunks": session.TotalChunks,
+		"uploaded":    session.Uploaded,
+		"missing":     session.Missing(),
+		"expiresAt":   session.UpdatedAt.Add(time.Duration(config.GetInt("upload.chunk_expire_hours")) * time.Hour),
+	}
+}
+
+// validSHA256 是否为SHA-256的十六进制值
+func validSHA256(value string) bool {
+	value = strings.TrimSpace(value)
+	if len(value) != 64 {
+		return false
+	}
+	for _, c := range strings.ToLower(value) {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"io"
+	"matuto-blog/config"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/cache"
+	"matuto-blog/pkg/imaging"
+	"matuto-blog/pkg/logger"
+	"matuto-blog/pkg/storage"
+	"matuto-blog/pkg/utils"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cacheTagAttachments 附件的缓存标签
+const cacheTagAttachments = "attachments"
+
+// imageSize 派生图片尺寸
+type imageSize struct {
+	Name  string
+	Width int
+}
+
+// imageSizes 配置的派生图片尺寸，配置格式为 名称:最大宽度，按宽度从小到大排列
+func imageSizes() []imageSize {
+	var sizes []imageSize
+	for _, item := range config.GetStringSlice("image.sizes") {
+		name, width, ok := strings.Cut(item, ":")
+		w, err := strconv.Atoi(strings.TrimSpace(width))
+		if !ok || err != nil || w <= 0 || strings.TrimSpace(name) == "" {
+			logger.Warn("Invalid image size config:", item)
+			continue
+		}
+		sizes = append(sizes, imageSize{Name: strings.TrimSpace(name), Width: w})
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i].Width < sizes[j].Width })
+	return sizes
+}
+
+// isProcessableImage 上传的文件是否需要进行图片处理
+func isProcessableImage(filename string, size int64, contentType string) bool {
+	return config.GetBool("image.enabled") && utils.IsImageFile(filename) &&
+		models.ValidateImageFile(filename, size, contentType) == nil
+}
+
+// readUploadImage 读取上传的图片，按配置去除元数据
+func readUploadImage(reader io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if !config.GetBool("image.strip_metadata") {
+		return data, nil
+	}
+	stripped, err := imaging.StripMetadata(data, config.GetInt("image.quality"), config.GetInt("image.max_pixels"))
+	if err != nil {
+		// 无法处理的图片保持原样上传
+		logger.Warn("Failed to strip image metadata:", err)
+		return data, nil
+	}
+	return stripped, nil
+}
+
+// generateImageVariants 为图片文件生成配置的派生尺寸，已处理过的文件直接返回
+// 只生成比原图小的尺寸；开启WebP时同时生成WebP版本，但只保留比原格式更小的
+func generateImageVariants(ctx context.Context, adapter storage.StorageAdapter, blob *models.AttachBlob, data []byte) {
+	if blob.Width > 0 {
+		return
+	}
+	img, format, err := imaging.Decode(data, config.GetInt("image.max_pixels"))
+	if err != nil {
+		logger.Warn("Skip image variants for", blob.Path+":", err)
+		return
+	}
+
+	bounds := img.Bounds()
+	blob.Width, blob.Height = bounds.Dx(), bounds.Dy()
+	if err := database.DB.Model(blob).Select("width", "height").Updates(blob).Error; err != nil {
+		logger.Error("Failed to save image size", blob.Path+":", err)
+		return
+	}
+
+	quality := config.GetInt("image.quality")
+	outputFormat := imaging.OutputFormat(format)
+	base := strings.TrimSuffix(blob.Path, path.Ext(blob.Path))
+	for _, size := range imageSizes() {
+		if size.Width >= blob.Width {
+			break
+		}
+		resized := imaging.Resize(img, size.Width)
+
+		var primary bytes.Buffer
+		if err := imaging.Encode(&primary, resized, outputFormat, quality); err != nil {
+			logger.Error("Failed to encode image variant", size.Name+":", err)
+			continue
+		}
+		saveImageVariant(ctx, adapter, blob, size.Name, outputFormat, base, resized.Bounds().Dx(), resized.Bounds().Dy(), primary.Bytes())
+
+		if !config.GetBool("image.webp") {
+			continue
+		}
+		var webp bytes.Buffer
+		if err := imaging.EncodeWebP(&webp, resized); err != nil {
+			logger.Error("Failed to encode webp variant", size.Name+":", err)
+			continue
+		}
+		if webp.Len() < primary.Len() {
+			saveImageVariant(ctx, adapter, blob, size.Name, imaging.FormatWebP, base, resized.Bounds().Dx(), resized.Bounds().Dy(), webp.Bytes())
+		}
+	}
+	invalidateCache(cacheTagAttachments)
+}
+
+// saveImageVariant 上传派生图片并保存记录
+func saveImageVariant(ctx context.Context, adapter storage.StorageAdapter, blob *models.AttachBlob, name, format, base string, width, height int, data []byte) {
+	variantPath := base + "_" + name + imaging.Extension(format)
+	result, err := storage.Store(ctx, adapter, variantPath, bytes.NewReader(data), int64(len(data)), imaging.MimeType(format))
+	if err != nil {
+		logger.Error("Failed to store image variant", variantPath+":", err)
+		return
+	}
+	variant := models.AttachVariant{
+		BlobId: blob.Id,
+		Name:   name,
+		Format: format,
+		Width:  width,
+		Height: height,
+		Path:   result.Path,
+		URL:    result.URL,
+		Size:   result.Size,
+	}
+	if err := database.DB.Create(&variant).Error; err != nil {
+		adapter.Delete(ctx, result.Path)
+		logger.Error("Failed to save image variant", variantPath+":", err)
+	}
+}
+
+// deleteImageVariants 删除文件的所有派生图片
+func deleteImageVariants(ctx context.Context, adapter storage.StorageAdapter, blobId int) {
+	var variants []models.AttachVariant
+	database.DB.Where("blob_id = ?", blobId).Find(&variants)
+	for _, variant := range variants {
+		if err := adapter.Delete(ctx, variant.Path); err != nil {
+			logger.Warn("Failed to delete image variant", variant.Path+":", err)
+		}
+	}
+	if len(variants) > 0 {
+		database.DB.Where("blob_id = ?", blobId).Delete(&models.AttachVariant{})
+	}
+}
+
+// loadAttachVariants 为附件列表加载派生图片
+func loadAttachVariants(attachments []models.Attach) {
+	var blobIds []int
+	for _, attachment := range attachments {
+		if attachment.BlobId != nil {
+			blobIds = append(blobIds, *attachment.BlobId)
+		}
+	}
+	if len(blobIds) == 0 {
+		return
+	}
+
+	var variants []models.AttachVariant
+	database.DB.Where("blob_id IN ?", blobIds).Order("width ASC").Find(&variants)
+	byBlob := make(map[int][]models.AttachVariant)
+	for _, variant := range variants {
+		byBlob[variant.BlobId] = append(byBlob[variant.BlobId], variant)
+	}
+	for i := range attachments {
+		if attachments[i].BlobId != nil {
+			attachments[i].Variants = byBlob[*attachments[i].BlobId]
+		}
+	}
+}
+
+// imageSet 图片及其派生尺寸
+type imageSet struct {
+	Width    int                    `json:"width"`
+	Variants []models.AttachVariant `json:"variants"`
+}
+
+// cachedImageSet 根据访问URL查找图片的派生尺寸，不是上传的图片时没有派生尺寸
+func cachedImageSet(url string) imageSet {
+	if url == "" {
+		return imageSet{}
+	}
+	set, err := cache.Remember(context.Background(), cache.Default(), "image:"+url, cache.DefaultTTL(), []string{cacheTagAttachments}, func() (imageSet, error) {
+		var blobs []models.AttachBlob
+		if err := database.DB.Where("url = ?", url).Limit(1).Find(&blobs).Error; err != nil || len(blobs) == 0 {
+			return imageSet{}, err
+		}
+		set := imageSet{Width: blobs[0].Width}
+		err := database.DB.Where("blob_id = ?", blobs[0].Id).Order("width ASC").Find(&set.Variants).Error
+		return set, err
+	})
+	if err != nil {
+		logger.Error("Failed to load image variants", url+":", err)
+	}
+	return set
+}
+
+// ImageTemplateFuncs 图片相关的模板函数
+//
+//	imageSrc URL 尺寸名称：指定尺寸的图片地址，没有该尺寸时返回原图
+//	imageSrcset URL [格式]：srcset属性值，不指定格式时为原格式的各尺寸和原图，指定webp时为WebP版本
+func ImageTemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"imageSrc":    imageSrc,
+		"imageSrcset": imageSrcset,
+	}
+}
+
+// imageSrc 指定尺寸的图片地址
+func imageSrc(url, name string) string {
+	for _, variant := range cachedImageSet(url).Variants {
+		if variant.Name == name && variant.Format != imaging.FormatWebP {
+			return variant.URL
+		}
+	}
+	return url
+}
+
+// imageSrcset 生成srcset属性值，没有派生尺寸时返回空字符串
+func imageSrcset(url string, format ...string) string {
+	set := cachedImageSet(url)
+	webp := len(format) > 0 && format[0] == imaging.FormatWebP
+
+	var candidates []string
+	for _, variant := range set.Variants {
+		if (variant.Format == imaging.FormatWebP) == webp {
+			candidates = append(candidates, variant.URL+" "+strconv.Itoa(variant.Width)+"w")
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	if !webp && set.Width > 0 {
+		candidates = append(candidates, url+" "+strconv.Itoa(set.Width)+"w")
+	}
+	return strings.Join(candidates, ", ")
+}
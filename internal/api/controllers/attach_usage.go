@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/jobs"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// attachCollector 附件回收任务，由main注入，用于后台手动扫描和回收
+var attachCollector *jobs.AttachCollector
+
+// SetAttachCollector 设置附件回收任务
+func SetAttachCollector(collector *jobs.AttachCollector) {
+	attachCollector = collector
+}
+
+// OnAttachmentsCollected 回收的附件记录删除后释放存储中的文件
+func OnAttachmentsCollected(attachments []models.Attach) {
+	for i := range attachments {
+		releaseAttachFile(context.Background(), &attachments[i])
+	}
+	invalidateCache(cacheTagAttachments)
+}
+
+// loadAttachUsages 为附件列表加载使用位置
+func loadAttachUsages(attachments []models.Attach) {
+	if len(attachments) == 0 {
+		return
+	}
+	ids := make([]int, len(attachments))
+	for i, attachment := range attachments {
+		ids[i] = attachment.Id
+	}
+
+	var usages []models.AttachUsage
+	database.DB.Where("attach_id IN ?", ids).Order("source_type, source_id").Find(&usages)
+	byAttach := make(map[int][]models.AttachUsage)
+	for _, usage := range usages {
+		byAttach[usage.AttachId] = append(byAttach[usage.AttachId], usage)
+	}
+	for i := range attachments {
+		attachments[i].Usages = byAttach[attachments[i].Id]
+	}
+}
+
+// AttachUsages 附件的使用位置，基于最近一次引用扫描
+func (a *AttachmentController) AttachUsages(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		common.BadRequest(ctx, "无效的附件ID")
+		return
+	}
+	var attachment models.Attach
+	if err := database.DB.First(&attachment, id).Error; err != nil {
+		common.NotFound(ctx, "附件不存在")
+		return
+	}
+	if !requireOwned(ctx, attachment.CreatedBy, models.PermAttachManage) {
+		return
+	}
+
+	var usages []models.AttachUsage
+	database.DB.Where("attach_id = ?", id).Order("source_type, source_id").Find(&usages)
+	common.Success(ctx, usages)
+}
+
+// ScanAttachUsages 立即扫描附件引用，重建使用记录
+func (a *AttachmentController) ScanAttachUsages(ctx *gin.Context) {
+	if attachCollector == nil {
+		common.ServerError(ctx, "附件回收未启用")
+		return
+	}
+	used, err := attachCollector.Scan()
+	if err != nil {
+		common.ServerError(ctx, "扫描附件引用失败: "+err.Error())
+		return
+	}
+	common.Success(ctx, gin.H{"used": used})
+}
+
+// UnusedAttachList 列出闲置超过days天的附件，不指定时使用配置的天数，列出前先重新扫描引用
+func (a *AttachmentController) UnusedAttachList(ctx *gin.Context) {
+	days, ok := unusedDaysParam(ctx, ctx.Query("days"))
+	if !ok {
+		return
+	}
+	if _, err := attachCollector.Scan(); err != nil {
+		common.ServerError(ctx, "扫描附件引用失败: "+err.Error())
+		return
+	}
+	attachments, err := attachCollector.Orphans(days)
+	if err != nil {
+		common.ServerError(ctx, "查询闲置附件失败: "+err.Error())
+		return
+	}
+	loadAttachVariants(attachments)
+	common.Success(ctx, attachments)
+}
+
+// CollectUnusedAttach 删除闲置超过days天的附件及其文件
+func (a *AttachmentController) CollectUnusedAttach(ctx *gin.Context) {
+	var req struct {
+		Days *int `json:"days"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil && ctx.Request.ContentLength > 0 {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+	raw := ""
+	if req.Days != nil {
+		raw = strconv.Itoa(*req.Days)
+	}
+	days, ok := unusedDaysParam(ctx, raw)
+	if !ok {
+		return
+	}
+
+	attachments, err := attachCollector.Collect(days)
+	if err != nil {
+		common.ServerError(ctx, "回收附件失败: "+err.Error())
+		return
+	}
+	common.SuccessWithMessage(ctx, "已删除"+strconv.Itoa(len(attachments))+"个闲置附件", attachments)
+}
+
+// unusedDaysParam 解析闲置天数参数，为空时使用配置的天数；参数错误或回收任务未启用时直接返回错误响应
+func unusedDaysParam(ctx *gin.Context, raw string) (int, bool) {
+	if attachCollector == nil {
+		common.ServerError(ctx, "附件回收未启用")
+		return 0, false
+	}
+	if raw == "" {
+		return attachCollector.UnusedDays(), true
+	}
+	days, err := strconv.Atoi(raw)
+	if err != nil || days < 0 {
+		common.BadRequest(ctx, "无效的天数")
+		return 0, false
+	}
+	return days, true
+}
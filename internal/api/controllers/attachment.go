@@ -1,12 +1,16 @@
 package controllers
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"matuto-blog/internal/database"
 	"matuto-blog/internal/models"
 	"matuto-blog/pkg/common"
-	"os"
+	"matuto-blog/pkg/logger"
+	"matuto-blog/pkg/storage"
+	"matuto-blog/pkg/utils"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -37,6 +41,11 @@ func (r *AttachmentController) AttachPage(ctx *gin.Context) {
 
 	query := database.DB.Model(&models.Attach{})
 
+	// 没有管理附件权限的用户只能看到自己上传的附件
+	if !can(ctx, models.PermAttachManage) {
+		query = query.Where("created_by = ?", ctx.GetInt("user_id"))
+	}
+
 	if req.Name != "" {
 		query = query.Where("name LIKE ?", "%"+req.Name+"%")
 	}
@@ -48,6 +57,8 @@ func (r *AttachmentController) AttachPage(ctx *gin.Context) {
 		Limit(req.PageSize).
 		Offset(offset).
 		Find(&attachments)
+	loadAttachVariants(attachments)
+	loadAttachUsages(attachments)
 
 	common.SuccessPage(ctx, attachments, total, req.Page, req.PageSize)
 }
@@ -72,87 +83,124 @@ func (a *AttachmentController) Upload(ctx *gin.Context) {
 	}
 
 	// 检查文件类型
-	allowedExts := []string{".jpg", ".jpeg", ".png", ".gif", ".pdf", ".doc", ".docx", ".txt", ".zip", ".rar"}
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	allowed := false
-	for _, allowedExt := range allowedExts {
-		if ext == allowedExt {
-			allowed = true
-			break
-		}
-	}
-	if !allowed {
+	if !allowedUploadExt(file.Filename) {
 		common.ServerError(ctx, "不支持的文件类型")
 		return
 	}
 
-	// 创建上传目录
-	uploadPath := viper.GetString("storage.local.base_path")
-	if uploadPath == "" {
-		uploadPath = "./uploads"
+	adapter := storage.GetCurrentAdapter()
+	if adapter == nil {
+		common.ServerError(ctx, "存储系统未初始化")
+		return
 	}
 
-	// 按年月分目录
-	now := time.Now()
-	datePath := fmt.Sprintf("%d-%02d-%02d", now.Year(), now.Month(), now.Day())
-	fullPath := filepath.Join(uploadPath, datePath)
+	src, err := file.Open()
+	if err != nil {
+		common.ServerError(ctx, "读取文件失败: "+err.Error())
+		return
+	}
+	defer src.Close()
 
-	if err := os.MkdirAll(fullPath, 0755); err != nil {
-		common.ServerError(ctx, "创建上传目录失败")
+	image := isProcessableImage(file.Filename, file.Size, file.Header.Get("Content-Type"))
+	attachment, err := saveAttachment(ctx.Request.Context(), adapter, file.Filename, src, file.Size, image, ctx.GetInt("user_id"))
+	if err != nil {
+		common.ServerError(ctx, err.Error())
 		return
 	}
+	common.SuccessWithMessage(ctx, "文件上传成功", attachResponse(attachment))
+}
 
-	// 生成新文件名
-	filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), file.Filename)
-	filePath := filepath.Join(fullPath, filename)
+// allowedUploadExts 允许上传的文件扩展名
+var allowedUploadExts = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true,
+	".pdf": true, ".doc": true, ".docx": true, ".txt": true, ".zip": true, ".rar": true,
+	".mp4": true, ".webm": true, ".mov": true, ".mp3": true, ".m4a": true, ".ogg": true, ".wav": true, ".flac": true,
+}
 
-	// 保存文件
-	if err := ctx.SaveUploadedFile(file, filePath); err != nil {
-		common.ServerError(ctx, "保存文件失败: "+err.Error())
-		return
+// allowedUploadExt 是否允许上传该类型的文件
+func allowedUploadExt(filename string) bool {
+	return allowedUploadExts[strings.ToLower(filepath.Ext(filename))]
+}
+
+// saveAttachment 保存上传的文件并创建附件记录，image为true时去除图片元数据并生成派生尺寸，userId为上传人
+// 返回的错误信息可以直接展示给用户
+func saveAttachment(ctx context.Context, adapter storage.StorageAdapter, name string, src io.ReadSeeker, size int64, image bool, userId int) (*models.Attach, error) {
+	// 按日期分目录，生成新文件名
+	now := time.Now()
+	datePath := fmt.Sprintf("%d-%02d-%02d", now.Year(), now.Month(), now.Day())
+	filename := fmt.Sprintf("%d_%s", now.UnixNano(), filepath.Base(name))
+
+	// 图片先去除元数据，再计算哈希和保存
+	content := src
+	var imageData []byte
+	if image {
+		var err error
+		if imageData, err = readUploadImage(src); err != nil {
+			return nil, fmt.Errorf("读取文件失败: %w", err)
+		}
+		content = bytes.NewReader(imageData)
+		size = int64(len(imageData))
 	}
 
-	// 获取文件MIME类型
-	src, err := file.Open()
+	// 先计算内容哈希，存储中已有相同内容的文件时直接复用
+	hash, _, err := utils.CalculateSHA256(content)
 	if err != nil {
-		os.Remove(filePath) // 删除已保存的文件
-		common.ServerError(ctx, "读取文件失败: "+err.Error())
-		return
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
 	}
-	defer src.Close()
 
-	buffer := make([]byte, 512)
-	_, err = src.Read(buffer)
-	if err != nil && err != io.EOF {
-		os.Remove(filePath)
-		common.ServerError(ctx, "读取文件失败: "+err.Error())
-		return
+	// 通过存储适配器保存文件，内容类型根据文件内容识别
+	blob, err := storeAttachBlob(ctx, adapter, hash, datePath+"/"+filename, content, size, "")
+	if err != nil {
+		return nil, fmt.Errorf("保存文件失败: %w", err)
+	}
+
+	// 生成图片的派生尺寸，复用已处理过的文件时跳过
+	if imageData != nil {
+		generateImageVariants(ctx, adapter, blob, imageData)
 	}
 
 	// 保存到数据库
 	attachment := models.Attach{
-		Name: file.Filename,
-		Path: strings.ReplaceAll(filepath.Join(datePath, filename), "\\", "/"),
+		Name:     name,
+		Path:     blob.Path,
+		URL:      blob.URL,
+		Type:     models.AttachTypeFromMime(blob.MimeType),
+		Storage:  blob.Storage,
+		Size:     blob.Size,
+		MimeType: blob.MimeType,
+		Hash:     blob.Hash,
+		BlobId:   &blob.Id,
+		Width:    blob.Width,
+		Height:   blob.Height,
 	}
+	attachment.SetCreator(userId)
 
 	if err := database.DB.Create(&attachment).Error; err != nil {
-		os.Remove(filePath) // 删除已保存的文件
-		common.ServerError(ctx, "保存文件记录失败: "+err.Error())
-		return
+		releaseAttachBlob(ctx, blob.Id) // 释放文件引用
+		return nil, fmt.Errorf("保存文件记录失败: %w", err)
 	}
 
-	// 生成访问URL
-	baseURL := viper.GetString("storage.local.base_url")
-	if baseURL == "" {
-		baseURL = "http://localhost:8080/uploads/"
-	}
-	fileURL := baseURL + attachment.Path
+	uploaded := []models.Attach{attachment}
+	loadAttachVariants(uploaded)
+	attachment.Variants = uploaded[0].Variants
+	return &attachment, nil
+}
 
-	common.SuccessWithMessage(ctx, "文件上传成功", gin.H{
-		"id":   attachment.Id,
-		"name": attachment.Name,
-		"url":  fileURL,
-	})
+// attachResponse 上传成功后返回的附件信息
+func attachResponse(attachment *models.Attach) gin.H {
+	return gin.H{
+		"id":       attachment.Id,
+		"name":     attachment.Name,
+		"url":      attachment.URL,
+		"type":     attachment.Type,
+		"size":     attachment.Size,
+		"width":    attachment.Width,
+		"height":   attachment.Height,
+		"variants": attachment.Variants,
+	}
 }
 
 // DeleteAttach 删除附件
@@ -168,17 +216,8 @@ func (a *AttachmentController) DeleteAttach(ctx *gin.Context) {
 		common.ServerError(ctx, "附件不存在")
 		return
 	}
-
-	// 删除物理文件
-	uploadPath := viper.GetString("storage.local.base_path")
-	if uploadPath == "" {
-		uploadPath = "./uploads"
-	}
-
-	filePath := filepath.Join(uploadPath, strings.ReplaceAll(attachment.Path, "/", string(os.PathSeparator)))
-	if err := os.Remove(filePath); err != nil {
-		// 文件不存在也继续删除数据库记录
-		fmt.Printf("删除文件失败: %v\n", err)
+	if !requireOwned(ctx, attachment.CreatedBy, models.PermAttachManage) {
+		return
 	}
 
 	// 删除数据库记录
@@ -186,6 +225,9 @@ func (a *AttachmentController) DeleteAttach(ctx *gin.Context) {
 		common.ServerError(ctx, "删除附件记录失败")
 		return
 	}
+
+	// 释放文件引用，没有其他附件引用时删除存储中的文件
+	releaseAttachFile(ctx.Request.Context(), &attachment)
 	common.SuccessWithMessage(ctx, "附件删除成功", nil)
 }
 
@@ -208,17 +250,9 @@ func (a *AttachmentController) BatchDeleteAttach(ctx *gin.Context) {
 	// 获取要删除的附件信息
 	var attachments []models.Attach
 	database.DB.Where("id IN ?", req.IDs).Find(&attachments)
-
-	uploadPath := viper.GetString("storage.local.base_path")
-	if uploadPath == "" {
-		uploadPath = "./uploads"
-	}
-
-	// 删除物理文件
 	for _, attachment := range attachments {
-		filePath := filepath.Join(uploadPath, strings.ReplaceAll(attachment.Path, "/", string(os.PathSeparator)))
-		if err := os.Remove(filePath); err != nil {
-			fmt.Printf("删除文件失败: %v\n", err)
+		if !requireOwned(ctx, attachment.CreatedBy, models.PermAttachManage) {
+			return
 		}
 	}
 
@@ -227,5 +261,27 @@ func (a *AttachmentController) BatchDeleteAttach(ctx *gin.Context) {
 		common.ServerError(ctx, "批量删除失败")
 		return
 	}
+
+	// 释放文件引用
+	for i := range attachments {
+		releaseAttachFile(ctx.Request.Context(), &attachments[i])
+	}
 	common.SuccessWithMessage(ctx, "批量删除成功", nil)
 }
+
+// attachAdapter 获取保存附件的存储适配器
+func attachAdapter(attachment *models.Attach) (storage.StorageAdapter, error) {
+	return storage.GetAdapterByType(attachment.GetStorage())
+}
+
+// deleteAttachFile 从附件所在的存储中删除文件，失败时记录日志
+func deleteAttachFile(ctx context.Context, attachment *models.Attach) {
+	adapter, err := attachAdapter(attachment)
+	if err != nil {
+		logger.Warn("Failed to delete attachment file", attachment.Path+":", err)
+		return
+	}
+	if err := adapter.Delete(ctx, attachment.Path); err != nil {
+		logger.Warn("Failed to delete attachment file", attachment.Path+":", err)
+	}
+}
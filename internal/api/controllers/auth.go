@@ -1,11 +1,14 @@
 package controllers
 
 import (
+	"errors"
 	"matuto-blog/internal/api/middlewares"
 	"matuto-blog/internal/database"
 	"matuto-blog/internal/models"
 	"matuto-blog/pkg/common"
+	"matuto-blog/pkg/logger"
 	"matuto-blog/pkg/utils"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,58 +22,150 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-// Login 用户登录
+// Login 用户登录，连续失败过多时按账号和IP临时锁定，每次登录都写入审计日志
 func (a *AuthController) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		common.ServerError(c, "参数错误: "+err.Error())
+		common.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+	account := strings.TrimSpace(req.Account)
+	if !checkLoginAllowed(c, account) {
 		return
 	}
 
-	// 查找用户
+	// 查找用户，账号不存在时同样校验一次密码，避免通过响应时间判断账号是否存在
 	var user models.User
-	if err := database.DB.Where("account = ?", req.Account).First(&user).Error; err != nil {
-		common.ServerError(c, "账户名或密码错误")
+	if err := database.DB.Where("account = ?", account).First(&user).Error; err != nil {
+		utils.CheckPassword(req.Password, dummyPasswordHash())
+		loginFailed(c, 0, account, models.LoginResultBadCredentials, "账户名或密码错误")
 		return
 	}
 
 	// 验证密码
 	if !utils.CheckPassword(req.Password, user.Password) {
-		common.ServerError(c, "账户名或密码错误")
+		loginFailed(c, user.Id, account, models.LoginResultBadCredentials, "账户名或密码错误")
 		return
 	}
 
 	// 检查用户状态
-	if user.Status != 1 {
-		common.ServerError(c, "账户已被禁用")
+	if !user.IsActive() {
+		recordLogin(c, user.Id, account, models.LoginResultDisabled)
+		common.Forbidden(c, "账户已被禁用")
+		return
+	}
+
+	// 启用两步验证时先返回挑战令牌，验证码通过后再签发访问令牌
+	if user.TotpEnabled {
+		challenge, expiresAt, err := middlewares.GenerateChallengeToken(&user)
+		if err != nil {
+			common.ServerError(c, "生成令牌失败")
+			return
+		}
+		recordLogin(c, user.Id, account, models.LoginResultTwoFactor)
+		common.SuccessWithMessage(c, "请输入两步验证码", gin.H{
+			"twoFactorRequired": true,
+			"challengeToken":    challenge,
+			"expiresAt":         expiresAt,
+		})
 		return
 	}
 
-	// 生成token
-	token, err := middlewares.GenerateToken(&user)
+	// 签发访问令牌和刷新令牌
+	tokens, err := middlewares.IssueTokens(&user, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		common.ServerError(c, "生成令牌失败")
 		return
 	}
 
+	loginSucceeded(c, &user, account)
+
 	// 返回登录信息
-	common.SuccessWithMessage(c, "登录成功", gin.H{
-		"token": token,
+	common.SuccessWithMessage(c, "登录成功", loginResponse(&user, tokens))
+}
+
+// loginResponse 登录成功后返回的令牌和用户信息
+func loginResponse(user *models.User, tokens *middlewares.TokenPair) gin.H {
+	return gin.H{
+		"token":            tokens.AccessToken,
+		"refreshToken":     tokens.RefreshToken,
+		"expiresAt":        tokens.ExpiresAt,
+		"refreshExpiresAt": tokens.RefreshExpiresAt,
 		"user": gin.H{
 			"id":       user.Id,
 			"username": user.Username,
 			"account":  user.Account,
 			"email":    user.Email,
 			"avatar":   user.Avatar,
+			"role":     user.Role,
 		},
-	})
+	}
+}
+
+// RefreshTokenRequest 刷新令牌请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// RefreshToken 使用刷新令牌换取新的访问令牌和刷新令牌，旧令牌随即失效
+func (a *AuthController) RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	tokens, _, err := middlewares.RefreshTokens(req.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, middlewares.ErrRefreshTokenInvalid), errors.Is(err, middlewares.ErrRefreshTokenReused),
+			errors.Is(err, middlewares.ErrUserDisabled):
+			common.Unauthorized(c, err.Error())
+		default:
+			common.ServerError(c, "刷新令牌失败")
+		}
+		return
+	}
+	common.Success(c, tokens)
 }
 
-// AdminLogout 管理员退出登录
+// Logout 退出登录，吊销当前会话的访问令牌和刷新令牌
+// 访问令牌过期后可以只提交刷新令牌
 func (a *AuthController) Logout(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		common.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		if claims, err := middlewares.ParseToken(token); err == nil && claims.SessionID != "" {
+			if err := middlewares.RevokeSession(claims.UserID, claims.SessionID); err != nil {
+				common.ServerError(c, "退出登录失败")
+				return
+			}
+		}
+	}
+	if req.RefreshToken != "" {
+		if err := middlewares.RevokeRefreshToken(req.RefreshToken); err != nil {
+			common.ServerError(c, "退出登录失败")
+			return
+		}
+	}
 	common.SuccessWithMessage(c, "退出登录成功", nil)
 }
 
+// LogoutAll 退出所有设备，吊销当前用户的全部会话
+func (a *AuthController) LogoutAll(c *gin.Context) {
+	if err := middlewares.RevokeUserSessions(c.GetInt("user_id")); err != nil {
+		common.ServerError(c, "退出登录失败")
+		return
+	}
+	common.SuccessWithMessage(c, "已退出所有设备", nil)
+}
+
 // GetProfile 获取用户信息
 func (a *AuthController) GetProfile(c *gin.Context) {
 	value, exists := c.Get("user")
@@ -85,10 +180,100 @@ func (a *AuthController) GetProfile(c *gin.Context) {
 		return
 	}
 	common.SuccessWithMessage(c, "获取成功", gin.H{
-		"id":       user.Id,
-		"username": user.Username,
-		"account":  user.Account,
-		"email":    user.Email,
-		"avatar":   user.Avatar,
+		"id":          user.Id,
+		"username":    user.Username,
+		"account":     user.Account,
+		"email":       user.Email,
+		"avatar":      user.Avatar,
+		"role":        user.Role,
+		"permissions": user.Permissions(),
+		"totpEnabled": user.TotpEnabled,
 	})
 }
+
+// ProfileRequest 修改个人资料请求
+type ProfileRequest struct {
+	Username string `json:"username" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Avatar   string `json:"avatar"`
+}
+
+// ChangePasswordRequest 修改密码请求
+type ChangePasswordRequest struct {
+	OldPassword string `json:"oldPassword" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
+// UpdateProfile 修改当前用户的用户名、邮箱和头像
+func (a *AuthController) UpdateProfile(c *gin.Context) {
+	user := middlewares.CurrentUser(c)
+	if user == nil {
+		common.ServerError(c, "未找到用户信息")
+		return
+	}
+	var req ProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.TrimSpace(req.Email)
+	if err := checkUserUnique("", req.Username, req.Email, user.Id); err != nil {
+		common.Conflict(c, err.Error())
+		return
+	}
+	updates := map[string]interface{}{
+		"username": req.Username,
+		"email":    req.Email,
+		"avatar":   req.Avatar,
+	}
+	if err := database.DB.Model(user).Updates(updates).Error; err != nil {
+		common.ServerError(c, "修改个人资料失败: "+err.Error())
+		return
+	}
+	common.SuccessWithMessage(c, "个人资料已更新", userResponse(user))
+}
+
+// ChangePassword 修改当前用户的密码，成功后其他设备上的登录会话全部失效
+func (a *AuthController) ChangePassword(c *gin.Context) {
+	user := middlewares.CurrentUser(c)
+	if user == nil {
+		common.ServerError(c, "未找到用户信息")
+		return
+	}
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	if !user.CheckPassword(req.OldPassword) {
+		common.BadRequest(c, "原密码错误")
+		return
+	}
+	if req.NewPassword == req.OldPassword {
+		common.BadRequest(c, "新密码不能与原密码相同")
+		return
+	}
+	strength, err := checkNewPassword(req.NewPassword)
+	if err != nil {
+		common.BadRequest(c, err.Error())
+		return
+	}
+	if err := user.HashPassword(req.NewPassword); err != nil {
+		common.ServerError(c, "密码加密失败")
+		return
+	}
+	if err := database.DB.Model(user).Update("password", user.Password).Error; err != nil {
+		common.ServerError(c, "修改密码失败: "+err.Error())
+		return
+	}
+
+	if claims, ok := c.Get("claims"); ok {
+		if err := middlewares.RevokeOtherSessions(user.Id, claims.(*middlewares.Claims).SessionID); err != nil {
+			logger.Error("Failed to revoke sessions after password change", user.Id, ":", err)
+		}
+	}
+	common.SuccessWithMessage(c, "密码修改成功", gin.H{"strength": strength})
+}
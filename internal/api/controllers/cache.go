@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/cache"
+	"matuto-blog/pkg/logger"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 缓存标签，写操作按标签失效相关缓存
+const (
+	cacheTagArticles   = "articles"   // 文章列表、推荐列表及分类文章数量
+	cacheTagCategories = "categories" // 分类列表
+	cacheTagTags       = "tags"       // 标签列表
+)
+
+// articleCacheTag 单篇文章页面的缓存标签
+func articleCacheTag(id int) string {
+	return "article:" + strconv.Itoa(id)
+}
+
+// OnArticlesPublished 定时发布的文章到期后刷新缓存和全文索引
+func OnArticlesPublished(ids []int) {
+	InvalidateSEOCache()
+	tags := []string{cacheTagArticles}
+	for _, id := range ids {
+		tags = append(tags, articleCacheTag(id))
+	}
+	invalidateCache(tags...)
+	IndexArticles(ids...)
+}
+
+// invalidateCache 按标签失效缓存
+func invalidateCache(tags ...string) {
+	if err := cache.Default().InvalidateTags(context.Background(), tags...); err != nil {
+		logger.Error("Failed to invalidate cache:", err)
+	}
+}
+
+// bodyCaptureWriter 在输出响应的同时记录响应内容
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write 写入响应并记录
+func (w *bodyCaptureWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 写入响应并记录
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// serveCachedPage 以请求URI为键输出缓存的HTML页面，未命中时调用render渲染，成功的HTML响应写入缓存
+// 页面缓存都带有站点公共数据标签，站点数据变化时全部失效
+func serveCachedPage(c *gin.Context, tags []string, render func()) {
+	tags = append(tags, cacheTagSite)
+	ctx := c.Request.Context()
+	key := "page:" + c.Request.URL.RequestURI()
+	if body, ok, err := cache.Default().Get(ctx, key); err == nil && ok {
+		c.Header("X-Cache", "HIT")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", body)
+		return
+	}
+
+	c.Header("X-Cache", "MISS")
+	writer := &bodyCaptureWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	render()
+	c.Writer = writer.ResponseWriter
+
+	if writer.Status() == http.StatusOK && strings.HasPrefix(writer.Header().Get("Content-Type"), "text/html") {
+		if err := cache.Default().Set(ctx, key, writer.body.Bytes(), cache.DefaultTTL(), tags...); err != nil {
+			logger.Error("Failed to cache page:", err)
+		}
+	}
+}
+
+// cachedCategoryList 带文章数量的分类列表
+func cachedCategoryList(ctx context.Context) ([]CategoryResponse, error) {
+	tags := []string{cacheTagCategories, cacheTagArticles}
+	return cache.Remember(ctx, cache.Default(), "categories:all", cache.DefaultTTL(), tags, func() ([]CategoryResponse, error) {
+		var categories []models.Category
+		if err := database.DB.Find(&categories).Error; err != nil {
+			return nil, err
+		}
+		counts := categoryArticleCounts()
+		result := make([]CategoryResponse, 0, len(categories))
+		for _, category := range categories {
+			result = append(result, CategoryResponse{Category: category, ArticleCount: counts[category.Id]})
+		}
+		return result, nil
+	})
+}
+
+// cachedTagList 标签列表
+func cachedTagList(ctx context.Context) ([]models.Tag, error) {
+	return cache.Remember(ctx, cache.Default(), "tags:all", cache.DefaultTTL(), []string{cacheTagTags}, func() ([]models.Tag, error) {
+		var tags []models.Tag
+		err := database.DB.Find(&tags).Error
+		return tags, err
+	})
+}
+
+// cachedRecommendList 推荐阅读列表
+func cachedRecommendList(ctx context.Context) ([]models.Article, error) {
+	return cache.Remember(ctx, cache.Default(), "articles:recommend", cache.DefaultTTL(), []string{cacheTagArticles}, func() ([]models.Article, error) {
+		var articles []models.Article
+		err := database.DB.Model(&models.Article{}).
+			Omit("content", "parse_content").
+			Scopes(models.ScopePublished).
+			Order("great_count DESC").
+			Limit(5).
+			Find(&articles).Error
+		return articles, err
+	})
+}
+
+// categoryArticleCounts 一次查询所有分类的文章数量
+func categoryArticleCounts() map[int]int64 {
+	var rows []struct {
+		CategoryId int
+		Count      int64
+	}
+	database.DB.Model(&models.ArticleCategory{}).
+		Select("category_id, COUNT(*) AS count").
+		Group("category_id").
+		Scan(&rows)
+	counts := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CategoryId] = row.Count
+	}
+	return counts
+}
+
+// loadArticleTaxonomies 批量查询文章的分类和标签
+func loadArticleTaxonomies(articleIds []int, counts map[int]int64) (map[int][]CategoryResponse, map[int][]models.Tag) {
+	categories := make(map[int][]CategoryResponse)
+	tags := make(map[int][]models.Tag)
+	if len(articleIds) == 0 {
+		return categories, tags
+	}
+
+	var categoryRows []struct {
+		models.Category
+		ArticleId int
+	}
+	database.DB.Model(&models.Category{}).
+		Select("m_category.*, ac.article_id").
+		Joins("JOIN m_article_category ac ON m_category.id = ac.category_id").
+		Where("ac.article_id IN ?", articleIds).
+		Scan(&categoryRows)
+	for _, row := range categoryRows {
+		categories[row.ArticleId] = append(categories[row.ArticleId], CategoryResponse{
+			Category:     row.Category,
+			ArticleCount: counts[row.Id],
+		})
+	}
+
+	var tagRows []struct {
+		models.Tag
+		ArticleId int
+	}
+	database.DB.Model(&models.Tag{}).
+		Select("m_tag.*, at.article_id").
+		Joins("JOIN m_article_tag at ON m_tag.id = at.tag_id").
+		Where("at.article_id IN ?", articleIds).
+		Scan(&tagRows)
+	for _, row := range tagRows {
+		tags[row.ArticleId] = append(tags[row.ArticleId], row.Tag)
+	}
+	return categories, tags
+}
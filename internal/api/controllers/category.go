@@ -4,8 +4,6 @@ import (
 	"matuto-blog/internal/database"
 	"matuto-blog/internal/models"
 	"matuto-blog/pkg/common"
-	"matuto-blog/pkg/utils"
-	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -24,6 +22,7 @@ type CategoryRequest struct {
 	MetaKeywords    string `json:"metaKeywords"`
 	MetaDescription string `json:"metaDescription"`
 	Status          int    `json:"status"`
+	Template        string `json:"template"` // 分类下文章的默认模板，文章未指定模板时使用
 }
 
 type CategoryResponse struct {
@@ -106,6 +105,12 @@ func (c *CategoryController) DeleteCategory(ctx *gin.Context) {
 		common.ServerError(ctx, "删除分类失败: "+err.Error())
 		return
 	}
+	if err := deleteSlugHistory(database.DB, models.SlugTypeCategory, int(id)); err != nil {
+		common.ServerError(ctx, "删除分类slug历史失败: "+err.Error())
+		return
+	}
+	InvalidateSEOCache()
+	invalidateCache(cacheTagCategories)
 	common.SuccessWithMessage(ctx, "分类删除成功", nil)
 }
 
@@ -117,11 +122,14 @@ func (c *CategoryController) CreateCategory(ctx *gin.Context) {
 		return
 	}
 
-	// 生成slug
-	if req.Slug == "" {
-		req.Slug = utils.GenerateSlug(req.Name)
+	if err := checkArticleTemplate(req.Template); err != nil {
+		common.BadRequest(ctx, err.Error())
+		return
 	}
 
+	// 生成唯一slug
+	req.Slug = uniqueSlug(database.DB, models.SlugTypeCategory, req.Slug, req.Name, 0)
+
 	category := models.Category{
 		Name:            req.Name,
 		Pid:             req.Pid,
@@ -131,12 +139,16 @@ func (c *CategoryController) CreateCategory(ctx *gin.Context) {
 		MetaKeywords:    req.MetaKeywords,
 		MetaDescription: req.MetaDescription,
 		Status:          req.Status,
+		Template:        req.Template,
 	}
+	category.SetCreator(ctx.GetInt("user_id"))
 
 	if err := database.DB.Create(&category).Error; err != nil {
 		common.ServerError(ctx, "创建分类失败: "+err.Error())
 		return
 	}
+	InvalidateSEOCache()
+	invalidateCache(cacheTagCategories)
 	common.SuccessWithMessage(ctx, "分类创建成功", nil)
 }
 
@@ -165,11 +177,20 @@ func (c *CategoryController) UpdateCategory(ctx *gin.Context) {
 		common.ServerError(ctx, "父分类不能是自己")
 		return
 	}
+	if req.Template != category.Template {
+		if err := checkArticleTemplate(req.Template); err != nil {
+			common.BadRequest(ctx, err.Error())
+			return
+		}
+	}
 
-	// 生成slug
+	// 未指定slug时沿用原slug，避免修改名称导致链接变化
 	if req.Slug == "" {
-		req.Slug = utils.GenerateSlug(req.Name)
+		req.Slug = category.Slug
 	}
+	oldSlug := category.Slug
+	oldName := category.Name
+	req.Slug = uniqueSlug(database.DB, models.SlugTypeCategory, req.Slug, req.Name, category.Id)
 
 	// 更新字段
 	category.Name = req.Name
@@ -180,11 +201,22 @@ func (c *CategoryController) UpdateCategory(ctx *gin.Context) {
 	category.MetaKeywords = req.MetaKeywords
 	category.MetaDescription = req.MetaDescription
 	category.Status = req.Status
+	category.Template = req.Template
+	category.SetUpdater(ctx.GetInt("user_id"))
 
 	if err := database.DB.Save(&category).Error; err != nil {
 		common.ServerError(ctx, "更新分类失败: "+err.Error())
 		return
 	}
+	if err := recordSlugChange(database.DB, models.SlugTypeCategory, category.Id, oldSlug, category.Slug); err != nil {
+		common.ServerError(ctx, err.Error())
+		return
+	}
+	InvalidateSEOCache()
+	invalidateCache(cacheTagCategories)
+	if oldName != category.Name {
+		reindexCategoryArticles(category.Id)
+	}
 	common.SuccessWithMessage(ctx, "分类更新成功", nil)
 }
 
@@ -214,7 +246,7 @@ func (c *CategoryController) CategoryListPage(ctx *gin.Context) {
 		})
 	}
 
-	ctx.HTML(http.StatusOK, "default/category.html", gin.H{
+	renderHTML(ctx, "category.html", gin.H{
 		"categories": categoriesWithCount,
 		"title":      "文章分类",
 	})
@@ -73,6 +73,8 @@ type CommentRequest struct {
 	Email     string `json:"email" form:"email"`
 	Website   string `json:"website" form:"website"`
 	Content   string `json:"content" form:"content" binding:"required"`
+	Homepage  string `json:"homepage" form:"homepage"` // 蜜罐字段，页面上对用户隐藏
+	Token     string `json:"token" form:"token"`       // 评论表单令牌
 }
 
 // Submit 提交评论
@@ -95,7 +97,9 @@ func (c *CommentController) Submit(ctx *gin.Context) {
 
 	// 验证文章是否存在且允许评论
 	var article models.Article
-	if err := database.DB.Where("id = ? AND status = ? AND is_comment = ?", req.ArticleID, 1, true).First(&article).Error; err != nil {
+	if err := database.DB.Scopes(models.ScopePublished).
+		Where("id = ? AND is_comment = ?", req.ArticleID, 1).
+		First(&article).Error; err != nil {
 		if ctx.GetHeader("X-Requested-With") == "XMLHttpRequest" {
 			ctx.JSON(http.StatusBadRequest, gin.H{
 				"code": 400,
@@ -107,6 +111,34 @@ func (c *CommentController) Submit(ctx *gin.Context) {
 		return
 	}
 
+	// 确定父评论和顶层评论
+	pid, topPid, err := resolveCommentParent(article.Id, req.Pid)
+	if err != nil {
+		if ctx.GetHeader("X-Requested-With") == "XMLHttpRequest" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  err.Error(),
+			})
+			return
+		}
+		ctx.Redirect(http.StatusFound, article.Permalink())
+		return
+	}
+
+	// 垃圾评论检查
+	status, ok := checkCommentSpam(ctx, &req)
+	if !ok {
+		if ctx.GetHeader("X-Requested-With") == "XMLHttpRequest" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  "评论未通过垃圾评论检测",
+			})
+			return
+		}
+		ctx.Redirect(http.StatusFound, article.Permalink()+"?comment=rejected")
+		return
+	}
+
 	// 获取客户端IP和User-Agent
 	clientIP := ctx.ClientIP()
 	device := ctx.GetHeader("User-Agent")
@@ -114,14 +146,15 @@ func (c *CommentController) Submit(ctx *gin.Context) {
 	// 创建评论
 	comment := models.Comment{
 		ArticleId: req.ArticleID,
-		Pid:       req.Pid,
+		Pid:       pid,
+		TopPid:    topPid,
 		Username:  req.UserName,
 		Email:     req.Email,
 		Website:   req.Website,
 		Content:   req.Content,
 		Ip:        clientIP,
 		Device:    device,
-		Status:    0, // 待审核
+		Status:    status,
 	}
 
 	if err := database.DB.Create(&comment).Error; err != nil {
@@ -132,17 +165,26 @@ func (c *CommentController) Submit(ctx *gin.Context) {
 			})
 			return
 		}
-		ctx.Redirect(http.StatusFound, "/article/"+strconv.Itoa(int(req.ArticleID)))
+		ctx.Redirect(http.StatusFound, article.Permalink())
 		return
 	}
 
+	msg := "评论提交成功，请等待审核"
+	if comment.IsApproved() {
+		refreshCommentCount(article.Id)
+		invalidateCache(articleCacheTag(article.Id))
+		notifyCommentReply(&comment)
+		msg = "评论提交成功"
+	}
+	notifyNewComment(&article, &comment)
+
 	if ctx.GetHeader("X-Requested-With") == "XMLHttpRequest" {
 		ctx.JSON(http.StatusOK, gin.H{
 			"code": 200,
-			"msg":  "评论提交成功，请等待审核",
+			"msg":  msg,
 		})
 	} else {
-		ctx.Redirect(http.StatusFound, "/article/"+strconv.Itoa(int(req.ArticleID))+"?comment=success")
+		ctx.Redirect(http.StatusFound, article.Permalink()+"?comment=success")
 	}
 }
 
@@ -173,16 +215,19 @@ func (c *CommentController) ReviewComment(ctx *gin.Context) {
 	}
 
 	// 更新评论状态
+	oldStatus := comment.Status
 	comment.Status = status
+	comment.SetUpdater(ctx.GetInt("user_id"))
 	if err := database.DB.Save(&comment).Error; err != nil {
 		common.ServerError(ctx, "更新评论状态失败: "+err.Error())
 		return
 	}
 
-	// 如果评论通过审核，更新文章评论数量
-	if status == 1 {
-		var total int64
-		database.DB.Model(&models.Article{}).Where("id = ?", comment.ArticleId).Update("comment_count", database.DB.Model(&models.Comment{}).Where("article_id = ? AND status = ?", comment.ArticleId, 1).Count(&total))
+	// 更新文章评论数量，并用审核结果训练垃圾评论分类器
+	refreshCommentCount(comment.ArticleId)
+	learnCommentReview(&comment, oldStatus, status)
+	if oldStatus != models.CommentStatusApproved && comment.IsApproved() {
+		notifyCommentReply(&comment)
 	}
 
 	var statusText string
@@ -194,6 +239,7 @@ func (c *CommentController) ReviewComment(ctx *gin.Context) {
 	case 2:
 		statusText = "已拒绝"
 	}
+	invalidateCache(articleCacheTag(comment.ArticleId))
 	common.SuccessWithMessage(ctx, "评论状态已更新为: "+statusText, nil)
 }
 
@@ -213,17 +259,21 @@ func (c *CommentController) DestroyComment(ctx *gin.Context) {
 
 	articleID := comment.ArticleId
 
-	// 删除评论及其回复
-	if err := database.DB.Where("id = ? OR parent_id = ?", id, id).Delete(&models.Comment{}).Error; err != nil {
+	// 删除评论及其所有回复
+	ids, err := commentDescendantIds(comment)
+	if err != nil {
+		common.ServerError(ctx, "删除评论失败: "+err.Error())
+		return
+	}
+	if err := database.DB.Where("id IN ?", ids).Delete(&models.Comment{}).Error; err != nil {
 		common.ServerError(ctx, "删除评论失败: "+err.Error())
 		return
 	}
 
 	// 更新文章评论数量
-	var total int64
-	database.DB.Model(&models.Comment{}).Where("article_id = ? AND status = ?", articleID, 1).Count(&total)
-	database.DB.Model(&models.Article{}).Where("id = ?", articleID).Update("comment_count", total)
+	refreshCommentCount(articleID)
 
+	invalidateCache(articleCacheTag(articleID))
 	common.SuccessWithMessage(ctx, "评论删除成功", nil)
 
 }
@@ -251,28 +301,30 @@ func (c *CommentController) BatchReviewComment(ctx *gin.Context) {
 		return
 	}
 
+	var comments []models.Comment
+	database.DB.Where("id IN ?", req.IDs).Find(&comments)
+
 	// 批量更新状态
-	if err := database.DB.Model(&models.Comment{}).Where("id IN ?", req.IDs).Update("status", req.Status).Error; err != nil {
+	if err := database.DB.Model(&models.Comment{}).Where("id IN ?", req.IDs).
+		Updates(map[string]interface{}{"status": req.Status, "updated_by": ctx.GetInt("user_id")}).Error; err != nil {
 		common.ServerError(ctx, "批量更新失败: "+err.Error())
 		return
 	}
 
-	// 如果是通过审核，需要更新相关文章的评论数量
-	if req.Status == 1 {
-		var comments []models.Comment
-		database.DB.Where("id IN ?", req.IDs).Find(&comments)
-
-		// 按文章ID分组更新评论数量
-		articleIDs := make(map[int]bool)
-		for _, comment := range comments {
-			articleIDs[comment.ArticleId] = true
+	// 按文章ID分组，并用审核结果训练垃圾评论分类器
+	articleIDs := make(map[int]bool)
+	for i := range comments {
+		articleIDs[comments[i].ArticleId] = true
+		learnCommentReview(&comments[i], comments[i].Status, req.Status)
+		if !comments[i].IsApproved() && req.Status == models.CommentStatusApproved {
+			comments[i].Status = req.Status
+			notifyCommentReply(&comments[i])
 		}
+	}
 
-		for articleID := range articleIDs {
-			var total int64
-			database.DB.Model(&models.Comment{}).Where("article_id = ? AND status = ?", articleID, 1).Count(&total)
-			database.DB.Model(&models.Article{}).Where("id = ?", articleID).Update("comment_count", total)
-		}
+	// 更新相关文章的评论数量
+	for articleID := range articleIDs {
+		refreshCommentCount(articleID)
 	}
 
 	var statusText string
@@ -284,5 +336,15 @@ func (c *CommentController) BatchReviewComment(ctx *gin.Context) {
 	case 2:
 		statusText = "已拒绝"
 	}
+	for articleID := range articleIDs {
+		invalidateCache(articleCacheTag(articleID))
+	}
 	common.SuccessWithMessage(ctx, fmt.Sprintf("已将 %d 条评论状态更新为: %s", len(req.IDs), statusText), nil)
 }
+
+// refreshCommentCount 重新统计文章已通过审核的评论数量
+func refreshCommentCount(articleId int) {
+	var total int64
+	database.DB.Model(&models.Comment{}).Where("article_id = ? AND status = ?", articleId, models.CommentStatusApproved).Count(&total)
+	database.DB.Model(&models.Article{}).Where("id = ?", articleId).Update("comment_count", total)
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"matuto-blog/config"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/mailer"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Unsubscribe 通过邮件中的链接一键退订会话通知，同时支持邮件客户端的List-Unsubscribe-Post请求
+func (c *CommentController) Unsubscribe(ctx *gin.Context) {
+	email, thread, ok := parseUnsubscribeToken(ctx.Query("token"))
+	if !ok {
+		ctx.String(http.StatusBadRequest, "退订链接无效")
+		return
+	}
+
+	record := models.MailUnsubscribe{Email: email, Thread: thread}
+	if err := database.DB.Where(&record).FirstOrCreate(&record).Error; err != nil {
+		ctx.String(http.StatusInternalServerError, "退订失败，请稍后重试")
+		return
+	}
+	ctx.String(http.StatusOK, "已退订，%s 不会再收到该会话的邮件通知", email)
+}
+
+// notifyNewComment 通知文章作者有新评论，没有作者的文章通知站点管理员
+func notifyNewComment(article *models.Article, comment *models.Comment) {
+	if mailer.Default() == nil {
+		return
+	}
+
+	to := config.GetString("mail.admin_email")
+	if article.UserId != nil {
+		var author models.User
+		if err := database.DB.Select("id, email").First(&author, *article.UserId).Error; err == nil && author.Email != "" {
+			to = author.Email
+		}
+	}
+	if to == "" || strings.EqualFold(to, comment.Email) {
+		return
+	}
+
+	sendNotification(to, models.ArticleThread(article.Id), "comment_new", gin.H{
+		"article":     article,
+		"comment":     comment,
+		"pending":     !comment.IsApproved(),
+		"article_url": absoluteURL(article.Permalink()),
+		"comment_url": absoluteURL(article.Permalink() + "#comment-" + strconv.Itoa(comment.Id)),
+	})
+}
+
+// notifyCommentReply 回复通过审核后通知被回复的评论人
+func notifyCommentReply(comment *models.Comment) {
+	if mailer.Default() == nil || comment.IsRoot() {
+		return
+	}
+
+	var parent models.Comment
+	if err := database.DB.First(&parent, comment.Pid).Error; err != nil {
+		return
+	}
+	if parent.Email == "" || strings.EqualFold(parent.Email, comment.Email) {
+		return
+	}
+
+	var article models.Article
+	if err := database.DB.Scopes(models.ScopePublished).
+		Omit("content", "parse_content").
+		First(&article, comment.ArticleId).Error; err != nil {
+		return
+	}
+
+	sendNotification(parent.Email, models.CommentThread(comment.TopPid), "comment_reply", gin.H{
+		"article":     &article,
+		"comment":     comment,
+		"parent":      &parent,
+		"article_url": absoluteURL(article.Permalink()),
+		"comment_url": absoluteURL(article.Permalink() + "#comment-" + strconv.Itoa(comment.Id)),
+	})
+}
+
+// sendNotification 发送会话通知，已退订该会话的邮箱不发送
+func sendNotification(to, thread, template string, data gin.H) {
+	var count int64
+	database.DB.Model(&models.MailUnsubscribe{}).Where("email = ? AND thread = ?", to, thread).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	unsubscribeURL := absoluteURL("/comment/unsubscribe?token=" + url.QueryEscape(unsubscribeToken(to, thread)))
+	data["site_title"] = config.GetString("site.title")
+	data["site_url"] = config.GetString("site.url")
+	data["unsubscribe_url"] = unsubscribeURL
+	mailer.Default().SendAsync([]string{to}, template, data, map[string]string{
+		"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	})
+}
+
+// unsubscribeToken 生成退订令牌，令牌包含邮箱和会话标识并签名，无需在数据库中保存
+func unsubscribeToken(email, thread string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(email + "\n" + thread))
+	return payload + "." + signUnsubscribe(payload)
+}
+
+// parseUnsubscribeToken 校验退订令牌并解析邮箱和会话标识
+func parseUnsubscribeToken(token string) (email, thread string, ok bool) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(signUnsubscribe(payload))) {
+		return "", "", false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", false
+	}
+	email, thread, ok = strings.Cut(string(data), "\n")
+	return email, thread, ok && email != "" && thread != ""
+}
+
+// signUnsubscribe 计算退订令牌签名
+func signUnsubscribe(payload string) string {
+	mac := hmac.New(sha256.New, []byte(config.GetString("jwt.secret")))
+	mac.Write([]byte("unsubscribe|" + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"matuto-blog/config"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/logger"
+	"matuto-blog/pkg/spam"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// commentSpam 评论垃圾检查链，未初始化时所有评论转人工审核
+	commentSpam *spam.Pipeline
+	// commentTokens 评论表单令牌，用于最短提交时间检查
+	commentTokens *spam.TimeTokenChecker
+	// commentClassifier 由审核结果训练的评论分类器
+	commentClassifier *spam.Classifier
+)
+
+// InitCommentSpam 根据配置初始化评论垃圾检查链
+func InitCommentSpam() error {
+	if !config.GetBool("comment.spam.enabled") {
+		return nil
+	}
+
+	classifier, err := spam.OpenClassifier(config.GetString("comment.spam.bayes_path"))
+	if err != nil {
+		return err
+	}
+	commentClassifier = classifier
+	commentTokens = spam.NewTimeTokenChecker(
+		config.GetString("jwt.secret"),
+		time.Duration(config.GetInt("comment.spam.min_submit_seconds"))*time.Second,
+		time.Duration(config.GetInt("comment.spam.token_max_age_hours"))*time.Hour,
+	)
+
+	// 开销小且能直接拒绝的检查在前
+	commentSpam = spam.NewPipeline(
+		spam.HoneypotChecker{},
+		commentTokens,
+		spam.NewRateLimitChecker(
+			config.GetInt("comment.spam.rate_limit"),
+			time.Duration(config.GetInt("comment.spam.rate_window_seconds"))*time.Second,
+		),
+		spam.NewKeywordChecker(config.GetStringSlice("comment.spam.blacklist")),
+		spam.NewLinkCountChecker(config.GetInt("comment.spam.max_links")),
+		spam.NewBayesChecker(
+			classifier,
+			config.GetInt("comment.spam.bayes_min_samples"),
+			config.GetFloat64("comment.spam.ham_threshold"),
+			config.GetFloat64("comment.spam.spam_threshold"),
+		),
+	)
+	return nil
+}
+
+// commentToken 为文章的评论表单签发令牌
+func commentToken(articleId int) string {
+	if commentTokens == nil {
+		return ""
+	}
+	return commentTokens.Issue(strconv.Itoa(articleId))
+}
+
+// checkCommentSpam 执行垃圾检查并返回评论状态，ok为false表示评论被拒绝
+func checkCommentSpam(ctx *gin.Context, req *CommentRequest) (status int, ok bool) {
+	if commentSpam == nil {
+		return models.CommentStatusPending, true
+	}
+
+	decision := commentSpam.Check(&spam.Input{
+		Target:    strconv.Itoa(req.ArticleID),
+		Username:  req.UserName,
+		Email:     req.Email,
+		Website:   req.Website,
+		Content:   req.Content,
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.GetHeader("User-Agent"),
+		Honeypot:  req.Homepage,
+		Token:     req.Token,
+	})
+	switch decision.Verdict {
+	case spam.Reject:
+		logger.Warn("Comment rejected from", ctx.ClientIP(), ":", decision.Reasons())
+		return models.CommentStatusRejected, false
+	case spam.Approve:
+		return models.CommentStatusApproved, true
+	default:
+		return models.CommentStatusPending, true
+	}
+}
+
+// learnCommentReview 用管理员的审核结果训练分类器，通过为正常样本，拒绝为垃圾样本
+// 修改审核结果时撤销之前的样本
+func learnCommentReview(comment *models.Comment, oldStatus, newStatus int) {
+	if commentClassifier == nil || oldStatus == newStatus {
+		return
+	}
+
+	text := (&spam.Input{
+		Username: comment.Username,
+		Email:    comment.Email,
+		Website:  comment.Website,
+		Content:  comment.Content,
+	}).Text()
+	switch oldStatus {
+	case models.CommentStatusApproved:
+		logTrainError(commentClassifier.Forget(text, false))
+	case models.CommentStatusRejected:
+		logTrainError(commentClassifier.Forget(text, true))
+	}
+	switch newStatus {
+	case models.CommentStatusApproved:
+		logTrainError(commentClassifier.Learn(text, false))
+	case models.CommentStatusRejected:
+		logTrainError(commentClassifier.Learn(text, true))
+	}
+}
+
+// logTrainError 记录分类器训练错误
+func logTrainError(err error) {
+	if err != nil {
+		logger.Error("Failed to train comment classifier:", err)
+	}
+}
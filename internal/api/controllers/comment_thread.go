@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"fmt"
+	"matuto-blog/config"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CommentNode 评论树节点，仅包含可公开的字段
+type CommentNode struct {
+	Id        int            `json:"id"`
+	Pid       int            `json:"pId"`
+	TopPid    int            `json:"topPId"`
+	Username  string         `json:"username"`
+	Website   string         `json:"website"`
+	Avatar    string         `json:"avatar"`
+	Content   string         `json:"content"`
+	CreatedAt time.Time      `json:"createdAt"`
+	ReplyTo   string         `json:"replyTo,omitempty"` // 回复的评论人，仅楼中楼回复有值
+	Children  []*CommentNode `json:"children"`
+}
+
+// CommentTreeResponse 按顶层评论分页的评论树
+type CommentTreeResponse struct {
+	common.PageResponse
+	CommentCount int64 `json:"commentCount"` // 已通过审核的评论总数
+}
+
+// ArticleComments 文章评论树，按顶层评论分页
+func (c *CommentController) ArticleComments(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		common.BadRequest(ctx, "无效的文章ID")
+		return
+	}
+
+	var article models.Article
+	if err := database.DB.Scopes(models.ScopePublished).Select("id").First(&article, articleId).Error; err != nil {
+		common.NotFound(ctx, "文章不存在")
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", strconv.Itoa(commentPageSize())))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = commentPageSize()
+	}
+
+	common.Success(ctx, loadCommentTree(article.Id, page, pageSize))
+}
+
+// CommentToken 为文章的评论表单签发令牌
+// 文章页面会被缓存，令牌不能写在页面中，由页面加载后单独请求，响应不允许缓存
+func (c *CommentController) CommentToken(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		common.BadRequest(ctx, "无效的文章ID")
+		return
+	}
+
+	var article models.Article
+	if err := database.DB.Scopes(models.ScopePublished).Select("id", "is_comment").First(&article, articleId).Error; err != nil {
+		common.NotFound(ctx, "文章不存在")
+		return
+	}
+	if !article.AllowComment() {
+		common.Forbidden(ctx, "该文章不允许评论")
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	common.Success(ctx, gin.H{
+		"token": commentToken(article.Id),
+	})
+}
+
+// commentPageSize 每页顶层评论数量
+func commentPageSize() int {
+	if size := config.GetInt("comment.page_size"); size > 0 {
+		return size
+	}
+	return 10
+}
+
+// loadCommentTree 查询文章已通过审核的评论，按顶层评论分页并组装为树
+func loadCommentTree(articleId, page, pageSize int) *CommentTreeResponse {
+	approved := database.DB.Model(&models.Comment{}).
+		Where("article_id = ? AND status = ?", articleId, models.CommentStatusApproved)
+
+	var commentCount, threadCount int64
+	approved.Session(&gorm.Session{}).Count(&commentCount)
+	approved.Session(&gorm.Session{}).Where("top_pid = ?", -1).Count(&threadCount)
+
+	var roots []models.Comment
+	approved.Session(&gorm.Session{}).
+		Where("top_pid = ?", -1).
+		Order("created_at DESC, id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&roots)
+
+	var replies []models.Comment
+	if len(roots) > 0 {
+		rootIds := make([]int, 0, len(roots))
+		for _, root := range roots {
+			rootIds = append(rootIds, root.Id)
+		}
+		approved.Session(&gorm.Session{}).
+			Where("top_pid IN ?", rootIds).
+			Order("created_at ASC, id ASC").
+			Find(&replies)
+	}
+
+	return &CommentTreeResponse{
+		PageResponse: common.PageResponse{
+			List:     buildCommentTree(roots, replies),
+			Total:    threadCount,
+			Page:     page,
+			PageSize: pageSize,
+			Pages:    (int(threadCount) + pageSize - 1) / pageSize,
+		},
+		CommentCount: commentCount,
+	}
+}
+
+// buildCommentTree 将回复挂到父评论下，父评论未通过审核时挂到所属顶层评论下
+func buildCommentTree(roots, replies []models.Comment) []*CommentNode {
+	nodes := make(map[int]*CommentNode, len(roots)+len(replies))
+	tree := make([]*CommentNode, 0, len(roots))
+	for i := range roots {
+		node := newCommentNode(&roots[i])
+		nodes[node.Id] = node
+		tree = append(tree, node)
+	}
+	for i := range replies {
+		nodes[replies[i].Id] = newCommentNode(&replies[i])
+	}
+
+	for i := range replies {
+		node := nodes[replies[i].Id]
+		parent, ok := nodes[node.Pid]
+		if !ok {
+			if parent, ok = nodes[node.TopPid]; !ok {
+				continue
+			}
+		}
+		if parent.Id != node.TopPid {
+			node.ReplyTo = parent.Username
+		}
+		parent.Children = append(parent.Children, node)
+	}
+	return tree
+}
+
+// newCommentNode 创建评论树节点
+func newCommentNode(comment *models.Comment) *CommentNode {
+	return &CommentNode{
+		Id:        comment.Id,
+		Pid:       comment.Pid,
+		TopPid:    comment.TopPid,
+		Username:  comment.Username,
+		Website:   comment.Website,
+		Avatar:    comment.Avatar,
+		Content:   comment.Content,
+		CreatedAt: comment.CreatedAt,
+		Children:  []*CommentNode{},
+	}
+}
+
+// resolveCommentParent 根据回复的评论计算父评论ID和顶层评论ID，pid小于等于0表示顶层评论
+func resolveCommentParent(articleId, pid int) (int, int, error) {
+	if pid <= 0 {
+		return -1, -1, nil
+	}
+
+	var parent models.Comment
+	if err := database.DB.Where("id = ? AND article_id = ? AND status = ?", pid, articleId, models.CommentStatusApproved).
+		First(&parent).Error; err != nil {
+		return 0, 0, fmt.Errorf("回复的评论不存在")
+	}
+	if parent.IsTopLevel() {
+		return parent.Id, parent.Id, nil
+	}
+	return parent.Id, parent.TopPid, nil
+}
+
+// commentDescendantIds 获取评论及其所有后代回复的ID
+func commentDescendantIds(comment models.Comment) ([]int, error) {
+	ids := []int{comment.Id}
+	topPid := comment.TopPid
+	if comment.IsTopLevel() {
+		topPid = comment.Id
+	}
+	var candidates []models.Comment
+	if err := database.DB.Select("id", "pid").Where("top_pid = ?", topPid).Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]int)
+	for _, candidate := range candidates {
+		children[candidate.Pid] = append(children[candidate.Pid], candidate.Id)
+	}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids, nil
+}
@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"crypto/md5"
+	"fmt"
+	"matuto-blog/config"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+	"matuto-blog/pkg/feed"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// FeedController 订阅源控制器
+type FeedController struct{}
+
+// 订阅源格式
+const (
+	feedFormatRSS  = "rss"
+	feedFormatAtom = "atom"
+)
+
+// feedScope 订阅源范围
+type feedScope struct {
+	key         string                     // 范围标识，参与ETag计算
+	title       string                     // 订阅源标题
+	description string                     // 订阅源描述
+	link        string                     // 对应的HTML页面路径
+	filter      func(db *gorm.DB) *gorm.DB // 文章筛选条件
+}
+
+// RSS 全站RSS订阅
+func (f *FeedController) RSS(c *gin.Context) {
+	f.serveFeed(c, feedFormatRSS, f.siteScope())
+}
+
+// Atom 全站Atom订阅
+func (f *FeedController) Atom(c *gin.Context) {
+	f.serveFeed(c, feedFormatAtom, f.siteScope())
+}
+
+// CategoryRSS 分类RSS订阅
+func (f *FeedController) CategoryRSS(c *gin.Context) {
+	if scope, ok := f.categoryScope(c); ok {
+		f.serveFeed(c, feedFormatRSS, scope)
+	}
+}
+
+// CategoryAtom 分类Atom订阅
+func (f *FeedController) CategoryAtom(c *gin.Context) {
+	if scope, ok := f.categoryScope(c); ok {
+		f.serveFeed(c, feedFormatAtom, scope)
+	}
+}
+
+// TagRSS 标签RSS订阅
+func (f *FeedController) TagRSS(c *gin.Context) {
+	if scope, ok := f.tagScope(c); ok {
+		f.serveFeed(c, feedFormatRSS, scope)
+	}
+}
+
+// TagAtom 标签Atom订阅
+func (f *FeedController) TagAtom(c *gin.Context) {
+	if scope, ok := f.tagScope(c); ok {
+		f.serveFeed(c, feedFormatAtom, scope)
+	}
+}
+
+// siteScope 全站范围
+func (f *FeedController) siteScope() *feedScope {
+	return &feedScope{
+		key:         "site",
+		title:       config.GetString("site.title"),
+		description: config.GetString("site.description"),
+		link:        "/",
+	}
+}
+
+// categoryScope 分类范围
+func (f *FeedController) categoryScope(c *gin.Context) (*feedScope, bool) {
+	var category models.Category
+	if err := resolveSlug(database.DB.Model(&models.Category{}), models.SlugTypeCategory, c.Param("id"), &category); err != nil {
+		c.String(http.StatusNotFound, "分类不存在")
+		return nil, false
+	}
+
+	return &feedScope{
+		key:         "category:" + strconv.Itoa(category.Id),
+		title:       config.GetString("site.title") + " - " + category.Name,
+		description: category.Desc,
+		link:        category.Permalink(),
+		filter: func(db *gorm.DB) *gorm.DB {
+			return db.Joins("JOIN m_article_category ac ON m_article.id = ac.article_id").
+				Where("ac.category_id = ?", category.Id)
+		},
+	}, true
+}
+
+// tagScope 标签范围
+func (f *FeedController) tagScope(c *gin.Context) (*feedScope, bool) {
+	var tag models.Tag
+	if err := resolveSlug(database.DB.Model(&models.Tag{}), models.SlugTypeTag, c.Param("id"), &tag); err != nil {
+		c.String(http.StatusNotFound, "标签不存在")
+		return nil, false
+	}
+
+	return &feedScope{
+		key:   "tag:" + strconv.Itoa(tag.Id),
+		title: config.GetString("site.title") + " - #" + tag.Name,
+		link:  tag.Permalink(),
+		filter: func(db *gorm.DB) *gorm.DB {
+			return db.Joins("JOIN m_article_tag at ON m_article.id = at.article_id").
+				Where("at.tag_id = ?", tag.Id)
+		},
+	}, true
+}
+
+// serveFeed 输出订阅源，先根据文章更新时间计算ETag，客户端缓存有效时直接返回304
+func (f *FeedController) serveFeed(c *gin.Context, format string, scope *feedScope) {
+	limit := config.GetInt("feed.limit")
+	if limit <= 0 {
+		limit = 20
+	}
+
+	query := database.DB.Model(&models.Article{}).
+		Scopes(models.ScopePublished).
+		Where("m_article.type <> ? AND m_article.visibility = ?", models.ArticleTypePage, 0)
+	if scope.filter != nil {
+		query = scope.filter(query)
+	}
+
+	// 仅查询版本信息用于协商缓存
+	var stamps []models.Article
+	query.Select("m_article.id, m_article.updated_at, m_article.publish_at, m_article.created_at").
+		Order("COALESCE(m_article.publish_at, m_article.created_at) DESC").
+		Limit(limit).
+		Find(&stamps)
+
+	hash := md5.New()
+	fmt.Fprintf(hash, "%s|%s", format, scope.key)
+	var lastModified time.Time
+	ids := make([]int, 0, len(stamps))
+	for _, stamp := range stamps {
+		ids = append(ids, stamp.Id)
+		fmt.Fprintf(hash, "|%d:%d", stamp.Id, stamp.UpdatedAt.Unix())
+		if stamp.UpdatedAt.After(lastModified) {
+			lastModified = stamp.UpdatedAt
+		}
+		if stamp.PublishAt != nil && stamp.PublishAt.After(lastModified) {
+			lastModified = *stamp.PublishAt
+		}
+	}
+	if lastModified.IsZero() {
+		lastModified = time.Now()
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	if common.CheckNotModified(c, fmt.Sprintf("%x", hash.Sum(nil)), lastModified) {
+		return
+	}
+
+	var articles []models.Article
+	if len(ids) > 0 {
+		database.DB.Where("id IN ?", ids).
+			Order("COALESCE(publish_at, created_at) DESC").
+			Find(&articles)
+	}
+
+	site := &feed.Feed{
+		Title:       scope.title,
+		Link:        absoluteURL(scope.link),
+		Description: scope.description,
+		Language:    config.GetString("site.language"),
+		Author:      config.GetString("site.title"),
+		Updated:     lastModified,
+		Items:       f.buildItems(articles),
+	}
+
+	var body []byte
+	var err error
+	contentType := feed.ContentTypeRSS
+	site.FeedURL = absoluteURL(c.Request.URL.Path)
+	if format == feedFormatAtom {
+		body, err = site.ToAtom()
+		contentType = feed.ContentTypeAtom
+	} else {
+		body, err = site.ToRSS()
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "生成订阅源失败")
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// buildItems 将文章转换为订阅条目
+func (f *FeedController) buildItems(articles []models.Article) []*feed.Item {
+	ids := make([]int, 0, len(articles))
+	for _, article := range articles {
+		ids = append(ids, article.Id)
+	}
+
+	// 一次性查询所有文章的分类名称
+	var relations []struct {
+		ArticleId int
+		Name      string
+	}
+	if len(ids) > 0 {
+		database.DB.Table("m_article_category ac").
+			Select("ac.article_id, c.name").
+			Joins("JOIN m_category c ON c.id = ac.category_id").
+			Where("ac.article_id IN ?", ids).
+			Scan(&relations)
+	}
+	categories := make(map[int][]string)
+	for _, relation := range relations {
+		categories[relation.ArticleId] = append(categories[relation.ArticleId], relation.Name)
+	}
+
+	fullContent := config.GetBool("feed.full_content")
+	items := make([]*feed.Item, 0, len(articles))
+	for i := range articles {
+		article := &articles[i]
+		link := absoluteURL(article.Permalink())
+		content := article.RenderedContent()
+
+		item := &feed.Item{
+			Id:          link,
+			Title:       article.Title,
+			Link:        link,
+			Description: article.Summary,
+			Categories:  categories[article.Id],
+			Published:   article.CreatedAt,
+			Updated:     article.UpdatedAt,
+		}
+		if article.PublishAt != nil {
+			item.Published = *article.PublishAt
+		}
+		if item.Description == "" {
+			item.Description = content
+		}
+		if fullContent {
+			item.Content = content
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// absoluteURL 根据站点地址生成绝对链接
+func absoluteURL(path string) string {
+	return strings.TrimRight(config.GetString("site.url"), "/") + "/" + strings.TrimLeft(path, "/")
+}
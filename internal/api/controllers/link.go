@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"matuto-blog/config"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/jobs"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/cache"
+	"matuto-blog/pkg/common"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cacheTagLinks 友情链接的缓存标签
+const cacheTagLinks = "links"
+
+// linkChecker 友情链接检测任务，由main注入，用于后台手动检测
+var linkChecker *jobs.LinkChecker
+
+func init() {
+	RegisterSiteData("links", func(ctx context.Context) (interface{}, error) {
+		return cachedLinkGroups(ctx)
+	})
+}
+
+// SetLinkChecker 设置友情链接检测任务
+func SetLinkChecker(checker *jobs.LinkChecker) {
+	linkChecker = checker
+}
+
+// OnLinksChanged 友情链接状态变化后刷新缓存
+func OnLinksChanged() {
+	invalidateCache(cacheTagLinks, cacheTagSite)
+}
+
+// LinkController 友情链接控制器
+type LinkController struct{}
+
+// LinkRequest 友情链接请求结构
+type LinkRequest struct {
+	Name      string `json:"name" binding:"required"`
+	Logo      string `json:"logo"`
+	Desc      string `json:"desc"`
+	Address   string `json:"address" binding:"required"`
+	Group     string `json:"group"`
+	Sort      int    `json:"sort"`
+	IsVisible *int8  `json:"isVisible"`
+}
+
+// LinkPageRequest 友情链接分页请求结构
+type LinkPageRequest struct {
+	common.PageRequest
+	Name         string  `json:"name" form:"name"`
+	Group        *string `json:"group" form:"group"`
+	IsVisible    *int8   `json:"isVisible" form:"isVisible"`
+	HealthStatus *int8   `json:"healthStatus" form:"healthStatus"`
+}
+
+// LinkGroup 按分组整理的友情链接
+type LinkGroup struct {
+	Name  string        `json:"name"`
+	Links []models.Link `json:"links"`
+}
+
+// LinkPage 友情链接分页
+func (l *LinkController) LinkPage(ctx *gin.Context) {
+	var req LinkPageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	var links []models.Link
+	var total int64
+	query := database.DB.Model(&models.Link{})
+	if req.Name != "" {
+		query = query.Where("name LIKE ? OR address LIKE ?", "%"+req.Name+"%", "%"+req.Name+"%")
+	}
+	if req.Group != nil {
+		query = query.Where("group_name = ?", *req.Group)
+	}
+	if req.IsVisible != nil {
+		query = query.Where("is_visible = ?", *req.IsVisible)
+	}
+	if req.HealthStatus != nil {
+		query = query.Where("health_status = ?", *req.HealthStatus)
+	}
+	query.Count(&total)
+
+	offset := (req.Page - 1) * req.PageSize
+	query.Order("group_name ASC, sort ASC, id ASC").
+		Limit(req.PageSize).
+		Offset(offset).
+		Find(&links)
+	common.SuccessPage(ctx, links, total, req.Page, req.PageSize)
+}
+
+// LinkGroups 友情链接分组列表
+func (l *LinkController) LinkGroups(ctx *gin.Context) {
+	var groups []string
+	database.DB.Model(&models.Link{}).Distinct("group_name").Order("group_name ASC").Pluck("group_name", &groups)
+	common.Success(ctx, groups)
+}
+
+// CreateLink 创建友情链接
+func (l *LinkController) CreateLink(ctx *gin.Context) {
+	var req LinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+	if err := validateLinkAddress(req.Address); err != nil {
+		common.BadRequest(ctx, err.Error())
+		return
+	}
+
+	link := models.Link{IsVisible: 1}
+	applyLinkRequest(&link, &req)
+	link.SetCreator(ctx.GetInt("user_id"))
+	if err := database.DB.Create(&link).Error; err != nil {
+		common.ServerError(ctx, "创建友情链接失败: "+err.Error())
+		return
+	}
+
+	OnLinksChanged()
+	common.SuccessWithMessage(ctx, "友情链接创建成功", link)
+}
+
+// UpdateLink 更新友情链接
+func (l *LinkController) UpdateLink(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		common.BadRequest(ctx, "无效的友情链接ID")
+		return
+	}
+
+	var req LinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+	if err := validateLinkAddress(req.Address); err != nil {
+		common.BadRequest(ctx, err.Error())
+		return
+	}
+
+	var link models.Link
+	if err := database.DB.First(&link, id).Error; err != nil {
+		common.NotFound(ctx, "友情链接不存在")
+		return
+	}
+
+	// 地址变化后重新检测
+	if link.Address != strings.TrimSpace(req.Address) {
+		link.HealthStatus = models.LinkHealthUnknown
+		link.HealthCode = 0
+		link.HealthError = ""
+		link.FailCount = 0
+		link.CheckedAt = nil
+	}
+	applyLinkRequest(&link, &req)
+	link.SetUpdater(ctx.GetInt("user_id"))
+	if err := database.DB.Save(&link).Error; err != nil {
+		common.ServerError(ctx, "更新友情链接失败: "+err.Error())
+		return
+	}
+
+	OnLinksChanged()
+	common.SuccessWithMessage(ctx, "友情链接更新成功", link)
+}
+
+// DeleteLink 删除友情链接
+func (l *LinkController) DeleteLink(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		common.BadRequest(ctx, "无效的友情链接ID")
+		return
+	}
+	if err := database.DB.Delete(&models.Link{}, id).Error; err != nil {
+		common.ServerError(ctx, "删除友情链接失败: "+err.Error())
+		return
+	}
+
+	OnLinksChanged()
+	common.SuccessWithMessage(ctx, "友情链接删除成功", nil)
+}
+
+// SortLinks 批量调整友情链接排序
+func (l *LinkController) SortLinks(ctx *gin.Context) {
+	var req []struct {
+		Id   int `json:"id" binding:"required"`
+		Sort int `json:"sort"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	tx := database.DB.Begin()
+	for _, item := range req {
+		if err := tx.Model(&models.Link{}).Where("id = ?", item.Id).
+			Updates(map[string]interface{}{"sort": item.Sort, "updated_by": ctx.GetInt("user_id")}).Error; err != nil {
+			tx.Rollback()
+			common.ServerError(ctx, "更新排序失败: "+err.Error())
+			return
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		common.ServerError(ctx, "更新排序失败: "+err.Error())
+		return
+	}
+
+	OnLinksChanged()
+	common.SuccessWithMessage(ctx, "排序已更新", nil)
+}
+
+// CheckLinks 立即检测友情链接，指定ids时只检测这些链接
+func (l *LinkController) CheckLinks(ctx *gin.Context) {
+	if linkChecker == nil {
+		common.ServerError(ctx, "友情链接检测未启用")
+		return
+	}
+
+	var req struct {
+		Ids []int `json:"ids"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil && ctx.Request.ContentLength > 0 {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	var links []models.Link
+	query := database.DB.Model(&models.Link{})
+	if len(req.Ids) > 0 {
+		query = query.Where("id IN ?", req.Ids)
+	}
+	query.Find(&links)
+	linkChecker.Check(links)
+	common.Success(ctx, links)
+}
+
+// applyLinkRequest 将请求内容写入友情链接
+func applyLinkRequest(link *models.Link, req *LinkRequest) {
+	link.Name = strings.TrimSpace(req.Name)
+	link.Logo = req.Logo
+	link.Desc = req.Desc
+	link.Address = strings.TrimSpace(req.Address)
+	link.Group = strings.TrimSpace(req.Group)
+	link.Sort = req.Sort
+	if req.IsVisible != nil {
+		link.IsVisible = *req.IsVisible
+	}
+}
+
+// validateLinkAddress 校验友情链接地址
+func validateLinkAddress(address string) error {
+	u, err := url.Parse(strings.TrimSpace(address))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("友情链接地址必须是完整的http或https网址")
+	}
+	return nil
+}
+
+// cachedLinkGroups 前台显示的友情链接，按分组整理，配置隐藏失效链接时不包含已失效的链接
+func cachedLinkGroups(ctx context.Context) ([]LinkGroup, error) {
+	return cache.Remember(ctx, cache.Default(), "links:visible", cache.DefaultTTL(), []string{cacheTagLinks}, func() ([]LinkGroup, error) {
+		var links []models.Link
+		query := database.DB.Scopes(models.ScopeVisibleLinks)
+		if config.GetBool("links.hide_dead") {
+			query = query.Where("health_status <> ?", models.LinkHealthDead)
+		}
+		if err := query.Find(&links).Error; err != nil {
+			return nil, err
+		}
+
+		groups := []LinkGroup{}
+		for _, link := range links {
+			if len(groups) == 0 || groups[len(groups)-1].Name != link.Group {
+				groups = append(groups, LinkGroup{Name: link.Group})
+			}
+			last := &groups[len(groups)-1]
+			last.Links = append(last.Links, link)
+		}
+		return groups, nil
+	})
+}
@@ -0,0 +1,283 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"matuto-blog/config"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+	"matuto-blog/pkg/logger"
+	"matuto-blog/pkg/loginguard"
+	"matuto-blog/pkg/utils"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// loginGuard 登录防暴力破解，未初始化时不限制登录尝试
+var loginGuard *loginguard.Guard
+
+// dummyPasswordHash 账号不存在时用于校验密码的哈希，使响应时间与账号存在时一致
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := utils.HashDefaultConfigPassword("matuto-blog-dummy-password")
+	return hash
+})
+
+// InitLoginGuard 初始化登录防护，并清理超过保留天数的登录日志
+func InitLoginGuard() error {
+	window := time.Duration(config.GetInt("login.window_minutes")) * time.Minute
+	base := time.Duration(config.GetInt("login.lockout_seconds")) * time.Second
+	maxLockout := time.Duration(config.GetInt("login.max_lockout_minutes")) * time.Minute
+
+	var backend loginguard.Store
+	switch store := config.GetString("login.store"); store {
+	case "", "memory":
+	case "database":
+		backend = &dbLoginThrottleStore{}
+	default:
+		return fmt.Errorf("不支持的登录状态存储: %s", store)
+	}
+	loginGuard = loginguard.New(
+		loginguard.Policy{MaxAttempts: config.GetInt("login.max_attempts"), BaseLockout: base, MaxLockout: maxLockout, Window: window},
+		loginguard.Policy{MaxAttempts: config.GetInt("login.ip_max_attempts"), BaseLockout: base, MaxLockout: maxLockout, Window: window},
+		backend,
+	)
+
+	if days := config.GetInt("login.log_retention_days"); days > 0 {
+		result := database.DB.Where("created_at < ?", time.Now().AddDate(0, 0, -days)).Delete(&models.LoginLog{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			logger.Info("Removed expired login logs:", result.RowsAffected)
+		}
+	}
+	return nil
+}
+
+// checkLoginAllowed 检查账号和IP是否被锁定，被锁定时记录日志并直接返回错误响应
+func checkLoginAllowed(c *gin.Context, account string) bool {
+	if loginGuard == nil {
+		return true
+	}
+	wait, err := loginGuard.Check(account, c.ClientIP())
+	if err != nil {
+		logger.Error("Failed to check login throttle:", err)
+	}
+	if wait <= 0 {
+		return true
+	}
+	recordLogin(c, 0, account, models.LoginResultLocked)
+	respondLoginLocked(c, wait)
+	return false
+}
+
+// loginFailed 记录登录失败，达到锁定条件时返回锁定提示，否则返回message
+func loginFailed(c *gin.Context, userId int, account, result, message string) {
+	recordLogin(c, userId, account, result)
+	if loginGuard != nil {
+		wait, err := loginGuard.Fail(account, c.ClientIP())
+		if err != nil {
+			logger.Error("Failed to record login failure:", err)
+		}
+		if wait > 0 {
+			logger.Warn("Login locked for", account, "from", c.ClientIP(), "for", wait)
+			respondLoginLocked(c, wait)
+			return
+		}
+	}
+	common.Unauthorized(c, message)
+}
+
+// loginSucceeded 记录登录成功并清除账号的失败次数
+func loginSucceeded(c *gin.Context, user *models.User, account string) {
+	recordLogin(c, user.Id, account, models.LoginResultSuccess)
+	if loginGuard != nil {
+		if err := loginGuard.Succeed(account); err != nil {
+			logger.Error("Failed to reset login throttle:", err)
+		}
+	}
+}
+
+// respondLoginLocked 返回锁定提示和剩余秒数
+func respondLoginLocked(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	message := fmt.Sprintf("登录失败次数过多，请%d秒后再试", seconds)
+	if seconds > 60 {
+		message = fmt.Sprintf("登录失败次数过多，请%d分钟后再试", (seconds+59)/60)
+	}
+	common.TooManyRequests(c, message, seconds)
+}
+
+// recordLogin 写入登录审计日志
+func recordLogin(c *gin.Context, userId int, account, result string) {
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > 256 {
+		userAgent = userAgent[:256]
+	}
+	if len(account) > 100 {
+		account = account[:100]
+	}
+	log := models.LoginLog{
+		UserId:    userId,
+		Account:   account,
+		IP:        c.ClientIP(),
+		UserAgent: userAgent,
+		Success:   result == models.LoginResultSuccess,
+		Result:    result,
+	}
+	if err := database.DB.Create(&log).Error; err != nil {
+		logger.Error("Failed to record login log:", err)
+	}
+}
+
+// LoginLogPageRequest 登录日志分页请求
+type LoginLogPageRequest struct {
+	common.PageRequest
+	UserId  int    `json:"userId" form:"userId"`
+	Account string `json:"account" form:"account"`
+	IP      string `json:"ip" form:"ip"`
+	Success *bool  `json:"success" form:"success"`
+}
+
+// LoginLogPage 登录日志分页
+func (u *UserController) LoginLogPage(ctx *gin.Context) {
+	var req LoginLogPageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	var logs []models.LoginLog
+	var total int64
+	query := database.DB.Model(&models.LoginLog{})
+	if req.UserId > 0 {
+		query = query.Where("user_id = ?", req.UserId)
+	}
+	if req.Account != "" {
+		query = query.Where("account = ?", req.Account)
+	}
+	if req.IP != "" {
+		query = query.Where("ip = ?", req.IP)
+	}
+	if req.Success != nil {
+		query = query.Where("success = ?", *req.Success)
+	}
+	query.Count(&total)
+
+	offset := (req.Page - 1) * req.PageSize
+	query.Order("id DESC").Limit(req.PageSize).Offset(offset).Find(&logs)
+	common.SuccessPage(ctx, logs, total, req.Page, req.PageSize)
+}
+
+// UnlockUser 解除用户因登录失败过多导致的锁定
+func (u *UserController) UnlockUser(ctx *gin.Context) {
+	user, ok := findUser(ctx)
+	if !ok {
+		return
+	}
+	if loginGuard == nil {
+		common.SuccessWithMessage(ctx, "账户未被锁定", nil)
+		return
+	}
+	if err := loginGuard.Unlock(loginguard.AccountKey(user.Account)); err != nil {
+		common.ServerError(ctx, "解除锁定失败: "+err.Error())
+		return
+	}
+	logger.Info("Login lock removed for", user.Account, "by user", ctx.GetInt("user_id"))
+	common.SuccessWithMessage(ctx, "已解除锁定", nil)
+}
+
+// UnlockIP 解除IP因登录失败过多导致的锁定
+func (u *UserController) UnlockIP(ctx *gin.Context) {
+	var req struct {
+		IP string `json:"ip" binding:"required,ip"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+	if loginGuard != nil {
+		if err := loginGuard.Unlock(loginguard.IPKey(req.IP)); err != nil {
+			common.ServerError(ctx, "解除锁定失败: "+err.Error())
+			return
+		}
+	}
+	common.SuccessWithMessage(ctx, "已解除锁定", nil)
+}
+
+// loginLockResponse 用户账号当前的登录失败状态，没有失败记录时为nil
+func loginLockResponse(user *models.User) gin.H {
+	if loginGuard == nil {
+		return nil
+	}
+	state, err := loginGuard.State(loginguard.AccountKey(user.Account))
+	if err != nil {
+		logger.Error("Failed to read login throttle:", err)
+	}
+	if state == nil {
+		return nil
+	}
+	_, locked := state.Locked(time.Now())
+	return gin.H{
+		"failures":    state.Failures,
+		"lastFailure": state.LastFailure,
+		"locked":      locked,
+		"lockedUntil": state.LockedUntil,
+	}
+}
+
+// dbLoginThrottleStore 基于数据库的登录失败状态存储
+type dbLoginThrottleStore struct{}
+
+// Get 读取状态
+func (s *dbLoginThrottleStore) Get(key string) (*loginguard.State, error) {
+	var record models.LoginThrottle
+	err := database.DB.Where("throttle_key = ?", key).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := &loginguard.State{
+		Failures:    record.Failures,
+		LastFailure: record.LastFailure,
+		ExpiresAt:   record.ExpiresAt,
+	}
+	if record.LockedUntil != nil {
+		state.LockedUntil = *record.LockedUntil
+	}
+	return state, nil
+}
+
+// Set 写入状态，顺便清理已过期的记录
+func (s *dbLoginThrottleStore) Set(key string, state *loginguard.State) error {
+	now := time.Now()
+	if err := database.DB.Where("expires_at < ?", now).Delete(&models.LoginThrottle{}).Error; err != nil {
+		return err
+	}
+	record := models.LoginThrottle{
+		Key:         key,
+		Failures:    state.Failures,
+		LastFailure: state.LastFailure,
+		ExpiresAt:   state.ExpiresAt,
+	}
+	if !state.LockedUntil.IsZero() {
+		record.LockedUntil = &state.LockedUntil
+	}
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "throttle_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"failures", "last_failure", "locked_until", "expires_at"}),
+	}).Create(&record).Error
+}
+
+// Delete 删除状态
+func (s *dbLoginThrottleStore) Delete(key string) error {
+	return database.DB.Where("throttle_key = ?", key).Delete(&models.LoginThrottle{}).Error
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CategoryIndex 分类文章列表页面，支持ID、slug及历史slug访问
+func (a *ArticleController) CategoryIndex(c *gin.Context) {
+	a.showCategory(c, c.Param("id"))
+}
+
+// TagIndex 标签文章列表页面，支持ID、slug及历史slug访问
+func (a *ArticleController) TagIndex(c *gin.Context) {
+	a.showTag(c, c.Param("id"))
+}
+
+// Permalink 按配置的固定链接规则解析未注册的路由
+func (a *ArticleController) Permalink(c *gin.Context) {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		pageNotFound(c, "页面不存在")
+		return
+	}
+
+	kind, values, ok := models.MatchPermalink(c.Request.URL.Path)
+	if !ok {
+		pageNotFound(c, "页面不存在")
+		return
+	}
+	value := values["slug"]
+	if value == "" {
+		value = values["id"]
+	}
+
+	switch kind {
+	case models.PermalinkArticle, models.PermalinkPage:
+		a.showArticle(c, value)
+	case models.PermalinkCategory:
+		a.showCategory(c, value)
+	case models.PermalinkTag:
+		a.showTag(c, value)
+	default:
+		pageNotFound(c, "页面不存在")
+	}
+}
+
+// showCategory 根据ID或slug渲染分类文章列表
+func (a *ArticleController) showCategory(c *gin.Context, value string) {
+	var category models.Category
+	if err := resolveSlug(database.DB.Model(&models.Category{}), models.SlugTypeCategory, value, &category); err != nil {
+		pageNotFound(c, "分类不存在")
+		return
+	}
+	if redirectCanonical(c, category.Permalink()) {
+		return
+	}
+	a.renderIndex(c, category.Id, 0)
+}
+
+// showTag 根据ID或slug渲染标签文章列表
+func (a *ArticleController) showTag(c *gin.Context, value string) {
+	var tag models.Tag
+	if err := resolveSlug(database.DB.Model(&models.Tag{}), models.SlugTypeTag, value, &tag); err != nil {
+		pageNotFound(c, "标签不存在")
+		return
+	}
+	if redirectCanonical(c, tag.Permalink()) {
+		return
+	}
+	a.renderIndex(c, 0, tag.Id)
+}
+
+// redirectCanonical 请求路径与固定链接不一致时301跳转，保留查询参数
+func redirectCanonical(c *gin.Context, canonical string) bool {
+	if c.Request.URL.Path == canonical {
+		return false
+	}
+	target := canonical
+	if c.Request.URL.RawQuery != "" {
+		target += "?" + c.Request.URL.RawQuery
+	}
+	c.Redirect(http.StatusMovedPermanently, target)
+	return true
+}
+
+// pageNotFound 前台页面不存在
+func pageNotFound(c *gin.Context, message string) {
+	c.String(http.StatusNotFound, message)
+}
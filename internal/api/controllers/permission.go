@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"matuto-blog/internal/api/middlewares"
+	"matuto-blog/pkg/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// can 当前用户是否拥有指定权限
+func can(c *gin.Context, permission string) bool {
+	user := middlewares.CurrentUser(c)
+	return user != nil && user.Can(permission)
+}
+
+// canAccessOwned 当前用户是否可以操作创建人为ownerId的记录：自己创建的，或拥有管理他人记录的权限
+func canAccessOwned(c *gin.Context, ownerId int, othersPermission string) bool {
+	if user := middlewares.CurrentUser(c); user != nil && ownerId > 0 && ownerId == user.Id {
+		return true
+	}
+	return can(c, othersPermission)
+}
+
+// requireOwned 检查当前用户是否可以操作该记录，没有权限时直接返回错误响应
+func requireOwned(c *gin.Context, ownerId int, othersPermission string) bool {
+	if canAccessOwned(c, ownerId, othersPermission) {
+		return true
+	}
+	common.Forbidden(c, "只能操作自己创建的内容")
+	return false
+}
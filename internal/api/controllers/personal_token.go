@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"matuto-blog/config"
+	"matuto-blog/internal/api/middlewares"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+	"matuto-blog/pkg/logger"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PersonalTokenRequest 创建个人访问令牌请求
+type PersonalTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required"`
+	ExpiresInDays int      `json:"expiresInDays"` // 为0时使用默认有效期
+}
+
+// PersonalTokenList 当前用户的个人访问令牌，不包含已吊销的令牌
+func (a *AuthController) PersonalTokenList(c *gin.Context) {
+	var tokens []models.PersonalToken
+	database.DB.Where("user_id = ? AND revoked_at IS NULL", c.GetInt("user_id")).Order("id DESC").Find(&tokens)
+
+	list := make([]gin.H, len(tokens))
+	for i := range tokens {
+		list[i] = personalTokenResponse(&tokens[i])
+	}
+	common.Success(c, list)
+}
+
+// CreatePersonalToken 创建个人访问令牌，权限范围不能超出当前角色的权限，令牌原文只返回这一次
+func (a *AuthController) CreatePersonalToken(c *gin.Context) {
+	user := middlewares.CurrentUser(c)
+	if user == nil {
+		common.ServerError(c, "未找到用户信息")
+		return
+	}
+	var req PersonalTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		common.BadRequest(c, "令牌名称不能为空")
+		return
+	}
+
+	scopes := []string{}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(models.AllPermissions, scope) {
+			common.BadRequest(c, "无效的权限: "+scope)
+			return
+		}
+		// 用户管理只能通过登录会话进行
+		if scope == models.PermUserManage {
+			common.BadRequest(c, "用户管理权限不能授予个人访问令牌")
+			return
+		}
+		if !user.Can(scope) {
+			common.Forbidden(c, "不能授予自己没有的权限: "+scope)
+			return
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		common.BadRequest(c, "至少选择一个权限")
+		return
+	}
+
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = config.GetInt("tokens.default_expire_days")
+	}
+	maxDays := config.GetInt("tokens.max_expire_days")
+	if days <= 0 {
+		common.BadRequest(c, "无效的有效期")
+		return
+	}
+	if maxDays > 0 && days > maxDays {
+		common.BadRequest(c, "有效期应在1到"+strconv.Itoa(maxDays)+"天之间")
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.PersonalToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", user.Id, time.Now()).
+		Count(&count)
+	if limit := config.GetInt("tokens.max_per_user"); limit > 0 && count >= int64(limit) {
+		common.BadRequest(c, "令牌数量已达上限，请先吊销不用的令牌")
+		return
+	}
+
+	token, raw, err := middlewares.CreatePersonalToken(user.Id, req.Name, scopes, time.Now().AddDate(0, 0, days))
+	if err != nil {
+		common.ServerError(c, "创建令牌失败: "+err.Error())
+		return
+	}
+	logger.Info("Personal token created:", token.Id, "for", user.Account)
+
+	result := personalTokenResponse(token)
+	result["token"] = raw
+	common.SuccessWithMessage(c, "令牌创建成功，请立即复制保存，之后将无法再次查看", result)
+}
+
+// RevokePersonalToken 吊销当前用户的个人访问令牌
+func (a *AuthController) RevokePersonalToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.BadRequest(c, "无效的令牌ID")
+		return
+	}
+	result := database.DB.Model(&models.PersonalToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, c.GetInt("user_id")).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		common.ServerError(c, "吊销令牌失败: "+result.Error.Error())
+		return
+	}
+	if result.RowsAffected == 0 {
+		common.NotFound(c, "令牌不存在")
+		return
+	}
+	common.SuccessWithMessage(c, "令牌已吊销", nil)
+}
+
+// UserPersonalTokens 管理员查看用户的个人访问令牌
+func (u *UserController) UserPersonalTokens(ctx *gin.Context) {
+	user, ok := findUser(ctx)
+	if !ok {
+		return
+	}
+	var tokens []models.PersonalToken
+	database.DB.Where("user_id = ? AND revoked_at IS NULL", user.Id).Order("id DESC").Find(&tokens)
+
+	list := make([]gin.H, len(tokens))
+	for i := range tokens {
+		list[i] = personalTokenResponse(&tokens[i])
+	}
+	common.Success(ctx, list)
+}
+
+// RevokeUserPersonalTokens 管理员吊销用户的全部个人访问令牌
+func (u *UserController) RevokeUserPersonalTokens(ctx *gin.Context) {
+	user, ok := findUser(ctx)
+	if !ok {
+		return
+	}
+	result := database.DB.Model(&models.PersonalToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", user.Id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		common.ServerError(ctx, "吊销令牌失败: "+result.Error.Error())
+		return
+	}
+	logger.Info("Personal tokens revoked for", user.Account, "by user", ctx.GetInt("user_id"))
+	common.SuccessWithMessage(ctx, "已吊销"+strconv.FormatInt(result.RowsAffected, 10)+"个令牌", nil)
+}
+
+// personalTokenResponse 令牌信息，不包含令牌原文
+func personalTokenResponse(token *models.PersonalToken) gin.H {
+	return gin.H{
+		"id":         token.Id,
+		"name":       token.Name,
+		"tokenHint":  token.TokenHint,
+		"scopes":     token.ScopeList(),
+		"expiresAt":  token.ExpiresAt,
+		"expired":    !time.Now().Before(token.ExpiresAt),
+		"lastUsedAt": token.LastUsedAt,
+		"lastUsedIp": token.LastUsedIP,
+		"createdAt":  token.CreatedAt,
+	}
+}
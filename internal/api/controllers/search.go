@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+	"matuto-blog/pkg/fulltext"
+	"matuto-blog/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SearchController 全文搜索控制器
+type SearchController struct{}
+
+// searchIndex 文章全文索引，未初始化时搜索退化为LIKE查询
+var searchIndex *fulltext.Index
+
+// InitSearchIndex 打开全文索引，索引为空时从数据库重建
+func InitSearchIndex(path string) error {
+	idx, err := fulltext.Open(path)
+	if err != nil {
+		return err
+	}
+	searchIndex = idx
+	if idx.Len() == 0 {
+		return RebuildSearchIndex()
+	}
+	return nil
+}
+
+// RebuildSearchIndex 使用数据库中已发布的文章重建全文索引
+func RebuildSearchIndex() error {
+	if searchIndex == nil {
+		return nil
+	}
+	var ids []int
+	database.DB.Model(&models.Article{}).Scopes(models.ScopePublished).Pluck("id", &ids)
+	return searchIndex.Reset(loadSearchDocuments(ids))
+}
+
+// IndexArticles 更新文章的索引，未发布或已删除的文章从索引中移除
+func IndexArticles(ids ...int) {
+	if searchIndex == nil || len(ids) == 0 {
+		return
+	}
+
+	docs := loadSearchDocuments(ids)
+	indexed := make(map[int]bool, len(docs))
+	for _, doc := range docs {
+		indexed[doc.Id] = true
+	}
+	var removed []int
+	for _, id := range ids {
+		if !indexed[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	if err := searchIndex.Put(docs...); err != nil {
+		logger.Error("Failed to update search index:", err)
+	}
+	if len(removed) > 0 {
+		if err := searchIndex.Delete(removed...); err != nil {
+			logger.Error("Failed to update search index:", err)
+		}
+	}
+}
+
+// reindexCategoryArticles 分类名称变更后更新其下文章的索引
+func reindexCategoryArticles(categoryId int) {
+	var ids []int
+	database.DB.Model(&models.ArticleCategory{}).Where("category_id = ?", categoryId).Pluck("article_id", &ids)
+	IndexArticles(ids...)
+}
+
+// reindexTagArticles 标签名称变更后更新其下文章的索引
+func reindexTagArticles(tagId int) {
+	var ids []int
+	database.DB.Model(&models.ArticleTag{}).Where("tag_id = ?", tagId).Pluck("article_id", &ids)
+	IndexArticles(ids...)
+}
+
+// Rebuild 重建全文索引
+func (s *SearchController) Rebuild(c *gin.Context) {
+	if searchIndex == nil {
+		common.ServerError(c, "全文索引未启用")
+		return
+	}
+	if err := RebuildSearchIndex(); err != nil {
+		common.ServerError(c, "重建索引失败: "+err.Error())
+		return
+	}
+	common.SuccessWithMessage(c, "索引重建成功", gin.H{
+		"count": searchIndex.Len(),
+	})
+}
+
+// searchArticles 全文搜索文章，query为已发布文章及分类、标签的筛选条件
+// 返回当前页按相关度排序的文章、命中总数和高亮结果
+func searchArticles(query *gorm.DB, keyword string, offset, limit int) ([]models.Article, int64, map[int]fulltext.Hit) {
+	var allowedIds []int
+	query.Session(&gorm.Session{}).Pluck("m_article.id", &allowedIds)
+	allowed := make(map[int]bool, len(allowedIds))
+	for _, id := range allowedIds {
+		allowed[id] = true
+	}
+
+	result := searchIndex.Search(keyword, offset, limit, func(id int) bool {
+		return allowed[id]
+	})
+
+	hits := make(map[int]fulltext.Hit, len(result.Hits))
+	ids := make([]int, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		hits[hit.Id] = hit
+		ids = append(ids, hit.Id)
+	}
+
+	var found []models.Article
+	if len(ids) > 0 {
+		database.DB.Where("id IN ?", ids).Find(&found)
+	}
+	byId := make(map[int]models.Article, len(found))
+	for _, article := range found {
+		byId[article.Id] = article
+	}
+	articles := make([]models.Article, 0, len(ids))
+	for _, id := range ids {
+		if article, ok := byId[id]; ok {
+			articles = append(articles, article)
+		}
+	}
+	return articles, int64(result.Total), hits
+}
+
+// loadSearchDocuments 查询已发布的文章及其标签、分类，构建索引文档
+func loadSearchDocuments(ids []int) []*fulltext.Document {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var articles []models.Article
+	database.DB.Model(&models.Article{}).
+		Scopes(models.ScopePublished).
+		Where("id IN ?", ids).
+		Find(&articles)
+
+	type relation struct {
+		ArticleId int
+		Name      string
+	}
+	var tagRelations, categoryRelations []relation
+	database.DB.Table("m_article_tag at").
+		Select("at.article_id, t.name").
+		Joins("JOIN m_tag t ON t.id = at.tag_id").
+		Where("at.article_id IN ?", ids).
+		Scan(&tagRelations)
+	database.DB.Table("m_article_category ac").
+		Select("ac.article_id, c.name").
+		Joins("JOIN m_category c ON c.id = ac.category_id").
+		Where("ac.article_id IN ?", ids).
+		Scan(&categoryRelations)
+
+	tags := make(map[int][]string)
+	for _, r := range tagRelations {
+		tags[r.ArticleId] = append(tags[r.ArticleId], r.Name)
+	}
+	categories := make(map[int][]string)
+	for _, r := range categoryRelations {
+		categories[r.ArticleId] = append(categories[r.ArticleId], r.Name)
+	}
+
+	docs := make([]*fulltext.Document, 0, len(articles))
+	for i := range articles {
+		article := &articles[i]
+		doc := &fulltext.Document{
+			Id:         article.Id,
+			Title:      article.Title,
+			Summary:    article.Summary,
+			Content:    fulltext.PlainText(article.RenderedContent()),
+			Tags:       tags[article.Id],
+			Categories: categories[article.Id],
+			Published:  article.CreatedAt,
+		}
+		if article.PublishAt != nil {
+			doc.Published = *article.PublishAt
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
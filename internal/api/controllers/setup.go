@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"matuto-blog/internal/api/middlewares"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+	"matuto-blog/pkg/logger"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupState 首次安装状态，setupToken只在启动时用户表为空的情况下生成并输出到日志，
+// 防止部署后他人抢先创建管理员
+var setupState struct {
+	mu    sync.Mutex
+	token string
+}
+
+// InitSetup 检查是否需要首次安装，用户表为空时生成安装令牌
+func InitSetup() error {
+	var count int64
+	if err := database.DB.Model(&models.User{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	setupState.mu.Lock()
+	setupState.token = hex.EncodeToString(b)
+	setupState.mu.Unlock()
+	logger.Warn("No users found, create the administrator via POST /api/setup with setup token:", setupState.token)
+	return nil
+}
+
+// SetupController 首次安装控制器
+type SetupController struct{}
+
+// SetupRequest 创建初始管理员请求
+type SetupRequest struct {
+	SetupToken string `json:"setupToken" binding:"required"`
+	Account    string `json:"account" binding:"required"`
+	Username   string `json:"username" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+}
+
+// SetupStatus 是否需要首次安装
+func (s *SetupController) SetupStatus(ctx *gin.Context) {
+	setupState.mu.Lock()
+	required := setupState.token != ""
+	setupState.mu.Unlock()
+	common.Success(ctx, gin.H{"required": required})
+}
+
+// Setup 用户表为空时创建初始管理员并直接登录，安装令牌见启动日志
+func (s *SetupController) Setup(ctx *gin.Context) {
+	var req SetupRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	setupState.mu.Lock()
+	defer setupState.mu.Unlock()
+	if setupState.token == "" {
+		common.Forbidden(ctx, "系统已完成安装")
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(setupState.token), []byte(strings.TrimSpace(req.SetupToken))) != 1 {
+		common.Forbidden(ctx, "安装令牌错误")
+		return
+	}
+	var count int64
+	if err := database.DB.Model(&models.User{}).Count(&count).Error; err != nil {
+		common.ServerError(ctx, "读取用户失败")
+		return
+	}
+	if count > 0 {
+		setupState.token = ""
+		common.Forbidden(ctx, "系统已完成安装")
+		return
+	}
+
+	req.Account = strings.TrimSpace(req.Account)
+	if !accountPattern.MatchString(req.Account) {
+		common.BadRequest(ctx, "账号只能包含字母、数字、下划线、点和短横线，长度3到32位")
+		return
+	}
+	if _, err := checkNewPassword(req.Password); err != nil {
+		common.BadRequest(ctx, err.Error())
+		return
+	}
+	user := models.User{
+		Account:  req.Account,
+		Username: strings.TrimSpace(req.Username),
+		Email:    strings.TrimSpace(req.Email),
+		Role:     models.RoleAdmin,
+		Status:   models.UserStatusActive,
+	}
+	if err := createUser(&user, req.Password); err != nil {
+		common.ServerError(ctx, "创建管理员失败: "+err.Error())
+		return
+	}
+	setupState.token = ""
+	logger.Info("Administrator created:", user.Account)
+
+	tokens, err := middlewares.IssueTokens(&user, ctx.Request.UserAgent(), ctx.ClientIP())
+	if err != nil {
+		common.SuccessWithMessage(ctx, "管理员创建成功，请登录", nil)
+		return
+	}
+	common.SuccessWithMessage(ctx, "安装完成", loginResponse(&user, tokens))
+}
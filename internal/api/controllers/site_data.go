@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"matuto-blog/config"
+	"matuto-blog/pkg/logger"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cacheTagSite 站点公共数据的缓存标签，所有前台页面缓存都带有该标签
+const cacheTagSite = "site"
+
+// SiteDataProvider 站点公共数据提供者
+type SiteDataProvider func(ctx context.Context) (interface{}, error)
+
+// siteDataProviders 已注册的站点公共数据提供者
+var siteDataProviders = map[string]SiteDataProvider{}
+
+// RegisterSiteData 注册站点公共数据，前台模板通过 .site.<key> 访问
+func RegisterSiteData(key string, provider SiteDataProvider) {
+	siteDataProviders[key] = provider
+}
+
+// siteData 汇总站点配置和所有提供者的数据
+func siteData(ctx context.Context) gin.H {
+	data := gin.H{
+		"title":       config.GetString("site.title"),
+		"description": config.GetString("site.description"),
+		"url":         config.GetString("site.url"),
+		"language":    config.GetString("site.language"),
+	}
+
+	keys := make([]string, 0, len(siteDataProviders))
+	for key := range siteDataProviders {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, err := siteDataProviders[key](ctx)
+		if err != nil {
+			logger.Error("Failed to load site data", key+":", err)
+			continue
+		}
+		data[key] = value
+	}
+	return data
+}
+
+// renderHTML 使用当前主题渲染前台页面，name为主题内的页面名，如index.html
+// 模板数据中附带站点公共数据和当前主题的设置
+func renderHTML(c *gin.Context, name string, data gin.H) {
+	data["site"] = siteData(c.Request.Context())
+	data["theme"] = themeData()
+	c.HTML(http.StatusOK, name, data)
+}
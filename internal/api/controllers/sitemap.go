@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"crypto/md5"
+	"fmt"
+	"matuto-blog/config"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+	"matuto-blog/pkg/logger"
+	"matuto-blog/pkg/sitemap"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SitemapController 站点地图控制器
+type SitemapController struct{}
+
+// seoDocument 缓存的SEO文档
+type seoDocument struct {
+	body         []byte
+	contentType  string
+	etag         string
+	lastModified time.Time
+}
+
+// seoCache 站点地图和robots.txt缓存，文章、分类、标签写入时失效
+var seoCache = struct {
+	sync.RWMutex
+	documents map[string]*seoDocument
+}{}
+
+// InvalidateSEOCache 清空站点地图和robots.txt缓存
+func InvalidateSEOCache() {
+	seoCache.Lock()
+	seoCache.documents = nil
+	seoCache.Unlock()
+}
+
+// Sitemap 站点地图，URL数量超过单文件上限时返回sitemap索引
+func (s *SitemapController) Sitemap(c *gin.Context) {
+	s.serveDocument(c, "sitemap.xml")
+}
+
+// SitemapPart 分片站点地图
+func (s *SitemapController) SitemapPart(c *gin.Context) {
+	s.serveDocument(c, "sitemaps/"+c.Param("file"))
+}
+
+// Robots robots.txt
+func (s *SitemapController) Robots(c *gin.Context) {
+	s.serveDocument(c, "robots.txt")
+}
+
+// serveDocument 从缓存输出文档，缓存为空时重新生成
+func (s *SitemapController) serveDocument(c *gin.Context, name string) {
+	documents, err := s.loadDocuments()
+	if err != nil {
+		logger.Error("Failed to build sitemap:", err)
+		c.String(http.StatusInternalServerError, "生成站点地图失败")
+		return
+	}
+
+	doc, ok := documents[name]
+	if !ok {
+		c.String(http.StatusNotFound, "页面不存在")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	if common.CheckNotModified(c, doc.etag, doc.lastModified) {
+		return
+	}
+	c.Data(http.StatusOK, doc.contentType, doc.body)
+}
+
+// loadDocuments 获取缓存的文档集合
+func (s *SitemapController) loadDocuments() (map[string]*seoDocument, error) {
+	seoCache.RLock()
+	documents := seoCache.documents
+	seoCache.RUnlock()
+	if documents != nil {
+		return documents, nil
+	}
+
+	seoCache.Lock()
+	defer seoCache.Unlock()
+	if seoCache.documents != nil {
+		return seoCache.documents, nil
+	}
+
+	documents, err := s.buildDocuments()
+	if err != nil {
+		return nil, err
+	}
+	seoCache.documents = documents
+	return documents, nil
+}
+
+// buildDocuments 生成站点地图和robots.txt
+func (s *SitemapController) buildDocuments() (map[string]*seoDocument, error) {
+	urls := s.collectURLs()
+	documents := make(map[string]*seoDocument)
+
+	if len(urls) <= sitemap.MaxURLs {
+		body, err := sitemap.BuildURLSet(urls)
+		if err != nil {
+			return nil, err
+		}
+		documents["sitemap.xml"] = newSEODocument(body, sitemap.ContentType, sitemap.LastModOf(urls))
+	} else {
+		var entries []sitemap.IndexEntry
+		for i, chunk := range sitemap.Split(urls, sitemap.MaxURLs) {
+			name := "sitemaps/sitemap-" + strconv.Itoa(i+1) + ".xml"
+			body, err := sitemap.BuildURLSet(chunk)
+			if err != nil {
+				return nil, err
+			}
+			lastMod := sitemap.LastModOf(chunk)
+			documents[name] = newSEODocument(body, sitemap.ContentType, lastMod)
+			entries = append(entries, sitemap.IndexEntry{Loc: absoluteURL(name), LastMod: lastMod})
+		}
+		body, err := sitemap.BuildIndex(entries)
+		if err != nil {
+			return nil, err
+		}
+		documents["sitemap.xml"] = newSEODocument(body, sitemap.ContentType, sitemap.LastModOf(urls))
+	}
+
+	documents["robots.txt"] = newSEODocument([]byte(buildRobots()), "text/plain; charset=utf-8", time.Now())
+	return documents, nil
+}
+
+// collectURLs 收集首页、文章、页面、分类和标签的URL
+func (s *SitemapController) collectURLs() []sitemap.URL {
+	urls := []sitemap.URL{{
+		Loc:        absoluteURL("/"),
+		LastMod:    time.Now(),
+		ChangeFreq: sitemap.ChangeFreqDaily,
+		Priority:   1.0,
+	}}
+
+	var articles []models.Article
+	database.DB.Model(&models.Article{}).
+		Select("id, type, slug, updated_at, publish_at, created_at").
+		Scopes(models.ScopePublished).
+		Where("visibility = ?", 0).
+		Order("id ASC").
+		Find(&articles)
+	for _, article := range articles {
+		u := sitemap.URL{
+			Loc:        absoluteURL(article.Permalink()),
+			LastMod:    article.UpdatedAt,
+			ChangeFreq: sitemap.ChangeFreqWeekly,
+			Priority:   0.8,
+		}
+		if article.Type == models.ArticleTypePage {
+			u.ChangeFreq = sitemap.ChangeFreqMonthly
+			u.Priority = 0.6
+		}
+		urls = append(urls, u)
+	}
+
+	var categories []models.Category
+	database.DB.Select("id, slug, updated_at").Order("id ASC").Find(&categories)
+	for _, category := range categories {
+		urls = append(urls, sitemap.URL{
+			Loc:        absoluteURL(category.Permalink()),
+			LastMod:    category.UpdatedAt,
+			ChangeFreq: sitemap.ChangeFreqWeekly,
+			Priority:   0.5,
+		})
+	}
+
+	var tags []models.Tag
+	database.DB.Select("id, slug, updated_at").Order("id ASC").Find(&tags)
+	for _, tag := range tags {
+		urls = append(urls, sitemap.URL{
+			Loc:        absoluteURL(tag.Permalink()),
+			LastMod:    tag.UpdatedAt,
+			ChangeFreq: sitemap.ChangeFreqWeekly,
+			Priority:   0.4,
+		})
+	}
+
+	return urls
+}
+
+// buildRobots 根据配置生成robots.txt
+func buildRobots() string {
+	var b strings.Builder
+	b.WriteString("User-agent: " + config.GetString("robots.user_agent") + "\n")
+	for _, path := range config.GetStringSlice("robots.allow") {
+		b.WriteString("Allow: " + path + "\n")
+	}
+	for _, path := range config.GetStringSlice("robots.disallow") {
+		b.WriteString("Disallow: " + path + "\n")
+	}
+	if delay := config.GetInt("robots.crawl_delay"); delay > 0 {
+		b.WriteString("Crawl-delay: " + strconv.Itoa(delay) + "\n")
+	}
+	if extra := strings.TrimSpace(config.GetString("robots.extra")); extra != "" {
+		b.WriteString("\n" + extra + "\n")
+	}
+	b.WriteString("\nSitemap: " + absoluteURL("/sitemap.xml") + "\n")
+	return b.String()
+}
+
+// newSEODocument 创建缓存文档并计算ETag
+func newSEODocument(body []byte, contentType string, lastModified time.Time) *seoDocument {
+	return &seoDocument{
+		body:         body,
+		contentType:  contentType,
+		etag:         fmt.Sprintf("%x", md5.Sum(body)),
+		lastModified: lastModified,
+	}
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"fmt"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/utils"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// slugModels slug历史类型对应的数据模型
+var slugModels = map[string]func() interface{}{
+	models.SlugTypeArticle:  func() interface{} { return &models.Article{} },
+	models.SlugTypeCategory: func() interface{} { return &models.Category{} },
+	models.SlugTypeTag:      func() interface{} { return &models.Tag{} },
+}
+
+// uniqueSlug 规范化slug并保证唯一，冲突时追加 -2、-3 等后缀
+// 纯数字的slug会与ID混淆，自动添加类型前缀
+func uniqueSlug(db *gorm.DB, slugType, slug, fallback string, excludeId int) string {
+	base := utils.GenerateSlug(slug)
+	if base == "" {
+		base = utils.GenerateSlug(fallback)
+	}
+	if base == "" {
+		base = slugType
+	}
+	if _, err := strconv.Atoi(base); err == nil {
+		base = slugType + "-" + base
+	}
+
+	candidate := base
+	for i := 2; slugTaken(db, slugType, candidate, excludeId); i++ {
+		candidate = base + "-" + strconv.Itoa(i)
+	}
+	return candidate
+}
+
+// slugTaken 检查slug是否已被其他记录或其他记录的历史slug占用
+func slugTaken(db *gorm.DB, slugType, slug string, excludeId int) bool {
+	var count int64
+	db.Model(slugModels[slugType]()).
+		Where("slug = ? AND id <> ?", slug, excludeId).
+		Count(&count)
+	if count > 0 {
+		return true
+	}
+	db.Model(&models.SlugHistory{}).
+		Where("type = ? AND slug = ? AND target_id <> ?", slugType, slug, excludeId).
+		Count(&count)
+	return count > 0
+}
+
+// recordSlugChange slug变更时记录旧slug，并移除与新slug相同的历史记录
+func recordSlugChange(db *gorm.DB, slugType string, targetId int, oldSlug, newSlug string) error {
+	if oldSlug == newSlug {
+		return nil
+	}
+	if err := db.Where("type = ? AND target_id = ? AND slug = ?", slugType, targetId, newSlug).
+		Delete(&models.SlugHistory{}).Error; err != nil {
+		return fmt.Errorf("更新slug历史失败: %w", err)
+	}
+	if oldSlug == "" {
+		return nil
+	}
+	history := models.SlugHistory{Type: slugType, Slug: oldSlug, TargetId: targetId}
+	if err := db.Where(&history).FirstOrCreate(&history).Error; err != nil {
+		return fmt.Errorf("记录slug历史失败: %w", err)
+	}
+	return nil
+}
+
+// deleteSlugHistory 删除记录的全部slug历史
+func deleteSlugHistory(db *gorm.DB, slugType string, targetId int) error {
+	return db.Where("type = ? AND target_id = ?", slugType, targetId).Delete(&models.SlugHistory{}).Error
+}
+
+// resolveSlug 根据slug或ID查找记录，依次匹配当前slug、ID和历史slug
+func resolveSlug(query *gorm.DB, slugType, value string, dest interface{}) error {
+	err := query.Session(&gorm.Session{}).Where("slug = ?", value).First(dest).Error
+	if err == nil {
+		return nil
+	}
+	if id, convErr := strconv.Atoi(value); convErr == nil {
+		if err = query.Session(&gorm.Session{}).Where("id = ?", id).First(dest).Error; err == nil {
+			return nil
+		}
+	}
+
+	var history models.SlugHistory
+	if err := database.DB.Where("type = ? AND slug = ?", slugType, value).
+		Order("id DESC").
+		First(&history).Error; err != nil {
+		return err
+	}
+	return query.Session(&gorm.Session{}).Where("id = ?", history.TargetId).First(dest).Error
+}
@@ -67,7 +67,13 @@ func (t *TagController) DeleteTag(ctx *gin.Context) {
 		common.ServerError(ctx, "删除标签失败: "+err.Error())
 		return
 	}
+	if err := deleteSlugHistory(database.DB, models.SlugTypeTag, int(id)); err != nil {
+		common.ServerError(ctx, "删除标签slug历史失败: "+err.Error())
+		return
+	}
 
+	InvalidateSEOCache()
+	invalidateCache(cacheTagTags)
 	common.SuccessWithMessage(ctx, "标签删除成功", nil)
 }
 
@@ -95,12 +101,16 @@ func (t *TagController) CreateTag(ctx *gin.Context) {
 	tag := models.Tag{
 		Name:  req.Name,
 		Color: req.Color,
+		Slug:  uniqueSlug(database.DB, models.SlugTypeTag, req.Slug, req.Name, 0),
 	}
+	tag.SetCreator(ctx.GetInt("user_id"))
 
 	if err := database.DB.Create(&tag).Error; err != nil {
 		common.ServerError(ctx, "创建标签失败: "+err.Error())
 		return
 	}
+	InvalidateSEOCache()
+	invalidateCache(cacheTagTags)
 	common.SuccessWithMessage(ctx, "标签创建成功", tag)
 }
 
@@ -129,15 +139,33 @@ func (t *TagController) UpdateTag(ctx *gin.Context) {
 		req.Color = "#007bff"
 	}
 
+	// 未指定slug时沿用原slug，避免修改名称导致链接变化
+	if req.Slug == "" {
+		req.Slug = tag.Slug
+	}
+	oldSlug := tag.Slug
+	oldName := tag.Name
+
 	// 更新标签
 	tag.Name = req.Name
 	tag.Color = req.Color
+	tag.Slug = uniqueSlug(database.DB, models.SlugTypeTag, req.Slug, req.Name, tag.Id)
+	tag.SetUpdater(ctx.GetInt("user_id"))
 
 	if err := database.DB.Save(&tag).Error; err != nil {
 
 		common.ServerError(ctx, "更新标签失败: "+err.Error())
 		return
 	}
+	if err := recordSlugChange(database.DB, models.SlugTypeTag, tag.Id, oldSlug, tag.Slug); err != nil {
+		common.ServerError(ctx, err.Error())
+		return
+	}
 
+	InvalidateSEOCache()
+	invalidateCache(cacheTagTags)
+	if oldName != tag.Name {
+		reindexTagArticles(tag.Id)
+	}
 	common.SuccessWithMessage(ctx, "标签更新成功", tag)
 }
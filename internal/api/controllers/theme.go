@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"matuto-blog/config"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+	"matuto-blog/pkg/logger"
+	"matuto-blog/pkg/theme"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// fallbackTheme 默认主题，当前主题缺少的页面模板使用默认主题的模板
+const fallbackTheme = "default"
+
+// 文章页面模板，主题根目录下以article开头的模板都可以作为文章或分类的自定义模板，如article-wide.html
+const (
+	articleTemplatePrefix  = "article"
+	defaultArticleTemplate = "article.html"
+)
+
+// themes 主题管理器
+var themes *theme.Manager
+
+// ThemeController 主题管理
+type ThemeController struct{}
+
+// ActivateThemeRequest 切换主题请求
+type ActivateThemeRequest struct {
+	Theme string `json:"theme" binding:"required"`
+}
+
+// ThemeSettingsRequest 保存主题设置请求
+type ThemeSettingsRequest struct {
+	Settings map[string]interface{} `json:"settings" binding:"required"`
+}
+
+// InitThemes 发现主题并加载模板，前台页面通过当前主题渲染
+// 当前主题优先使用后台切换后保存的主题，其次是theme.current配置，调试模式下模板文件变化时自动重新加载
+func InitThemes(r *gin.Engine, funcs template.FuncMap) error {
+	manager := theme.NewManager(config.GetString("theme.path"), funcs, fallbackTheme)
+	if err := manager.Load(); err != nil {
+		return err
+	}
+
+	current := config.GetString("theme.current")
+	var records []models.ThemeSetting
+	if database.DB != nil {
+		database.DB.Find(&records)
+	}
+	for _, record := range records {
+		if record.Active {
+			current = record.Theme
+		}
+		if record.Settings == "" {
+			continue
+		}
+		var values map[string]interface{}
+		if err := json.Unmarshal([]byte(record.Settings), &values); err != nil {
+			logger.Warn("Invalid settings for theme", record.Theme+":", err)
+			continue
+		}
+		manager.SetSettings(record.Theme, values)
+	}
+	if err := manager.Activate(current); err != nil {
+		logger.Warn("Theme", current, "not found, using", fallbackTheme)
+	}
+
+	themes = manager
+	r.HTMLRender = manager
+	logger.Info("Theme loaded:", manager.Current().ID)
+
+	if gin.Mode() == gin.DebugMode {
+		manager.Watch(time.Duration(config.GetInt("theme.watch_interval_seconds"))*time.Second, func() {
+			invalidateCache(cacheTagSite)
+		})
+	}
+	return nil
+}
+
+// themeData 当前主题的信息和设置，前台模板通过 .theme 访问
+func themeData() gin.H {
+	if themes == nil {
+		return gin.H{"settings": gin.H{}}
+	}
+	current := themes.Current()
+	return gin.H{
+		"id":       current.ID,
+		"name":     current.Manifest.Name,
+		"version":  current.Manifest.Version,
+		"settings": themes.Settings(current.ID),
+	}
+}
+
+// ArticleTemplates 当前主题可用的文章模板，供编辑文章和分类时选择
+func (t *ThemeController) ArticleTemplates(c *gin.Context) {
+	if themes == nil {
+		common.ServerError(c, "主题未初始化")
+		return
+	}
+	common.Success(c, gin.H{
+		"theme":     themes.Current().ID,
+		"default":   defaultArticleTemplate,
+		"templates": themes.Pages(articleTemplatePrefix),
+	})
+}
+
+// ThemeList 所有主题
+func (t *ThemeController) ThemeList(c *gin.Context) {
+	if themes == nil {
+		common.ServerError(c, "主题未初始化")
+		return
+	}
+	current := themes.Current().ID
+	list := make([]gin.H, 0)
+	for _, item := range themes.Themes() {
+		list = append(list, themeResponse(item, item.ID == current))
+	}
+	common.Success(c, list)
+}
+
+// ActivateTheme 切换当前主题，无需重启立即生效
+func (t *ThemeController) ActivateTheme(c *gin.Context) {
+	var req ActivateThemeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+	item, ok := findTheme(c, req.Theme)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ThemeSetting{}).Where("active = ?", true).Update("active", false).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "theme"}},
+			DoUpdates: clause.AssignmentColumns([]string{"active", "updated_at"}),
+		}).Create(&models.ThemeSetting{Theme: item.ID, Active: true}).Error
+	})
+	if err != nil {
+		common.ServerError(c, "切换主题失败: "+err.Error())
+		return
+	}
+	if err := themes.Activate(item.ID); err != nil {
+		common.ServerError(c, "切换主题失败: "+err.Error())
+		return
+	}
+	invalidateCache(cacheTagSite)
+	logger.Info("Theme switched to", item.ID, "by user", c.GetInt("user_id"))
+
+	common.SuccessWithMessage(c, "主题已切换", themeResponse(item, true))
+}
+
+// ThemeSettings 主题的设置项和当前设置值
+func (t *ThemeController) ThemeSettings(c *gin.Context) {
+	item, ok := findTheme(c, c.Param("name"))
+	if !ok {
+		return
+	}
+	common.Success(c, gin.H{
+		"theme":    item.ID,
+		"schema":   item.Manifest.Settings,
+		"settings": themes.Settings(item.ID),
+	})
+}
+
+// UpdateThemeSettings 保存主题设置，设置值按主题的设置项校验
+func (t *ThemeController) UpdateThemeSettings(c *gin.Context) {
+	item, ok := findTheme(c, c.Param("name"))
+	if !ok {
+		return
+	}
+	var req ThemeSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+	values, err := item.Manifest.Validate(req.Settings)
+	if err != nil {
+		common.BadRequest(c, err.Error())
+		return
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		common.ServerError(c, "保存主题设置失败: "+err.Error())
+		return
+	}
+
+	err = database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "theme"}},
+		DoUpdates: clause.AssignmentColumns([]string{"settings", "updated_at"}),
+	}).Create(&models.ThemeSetting{Theme: item.ID, Settings: string(data)}).Error
+	if err != nil {
+		common.ServerError(c, "保存主题设置失败: "+err.Error())
+		return
+	}
+	themes.SetSettings(item.ID, values)
+	invalidateCache(cacheTagSite)
+
+	common.SuccessWithMessage(c, "主题设置已保存", gin.H{
+		"theme":    item.ID,
+		"settings": themes.Settings(item.ID),
+	})
+}
+
+// ReloadThemes 重新发现主题并加载模板，用于新增或更新主题后无需重启
+func (t *ThemeController) ReloadThemes(c *gin.Context) {
+	if themes == nil {
+		common.ServerError(c, "主题未初始化")
+		return
+	}
+	if err := themes.Load(); err != nil {
+		common.ServerError(c, "重新加载主题失败: "+err.Error())
+		return
+	}
+	invalidateCache(cacheTagSite)
+	t.ThemeList(c)
+}
+
+// checkArticleTemplate 校验文章或分类的自定义模板，为空表示使用默认模板，
+// 只能选择当前主题中的文章模板
+func checkArticleTemplate(name string) error {
+	if name == "" || themes == nil {
+		return nil
+	}
+	if !strings.HasPrefix(name, articleTemplatePrefix) || strings.Contains(name, "/") || !themes.Has(name) {
+		return fmt.Errorf("模板不存在: %s", name)
+	}
+	return nil
+}
+
+// articleTemplate 文章页面使用的模板，依次查找文章的模板、分类的模板和主题默认的文章模板，
+// 模板在当前主题中不存在时继续查找下一个，都不存在时返回空
+func articleTemplate(article *models.Article, categories []models.Category) string {
+	candidates := []string{article.Template}
+	// 文章属于多个分类时按分类ID顺序取第一个设置了模板的分类
+	sorted := slices.Clone(categories)
+	slices.SortFunc(sorted, func(a, b models.Category) int { return a.Id - b.Id })
+	for _, category := range sorted {
+		candidates = append(candidates, category.Template)
+	}
+	candidates = append(candidates, defaultArticleTemplate)
+
+	for _, name := range candidates {
+		if name == "" {
+			continue
+		}
+		if themes == nil || themes.Has(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// findTheme 按名称查找主题，不存在时输出错误响应
+func findTheme(c *gin.Context, name string) (*theme.Theme, bool) {
+	if themes == nil {
+		common.ServerError(c, "主题未初始化")
+		return nil, false
+	}
+	item, ok := themes.Get(name)
+	if !ok {
+		common.NotFound(c, theme.ErrThemeNotFound.Error())
+		return nil, false
+	}
+	return item, true
+}
+
+// themeResponse 主题信息
+func themeResponse(item *theme.Theme, active bool) gin.H {
+	return gin.H{
+		"id":          item.ID,
+		"name":        item.Manifest.Name,
+		"version":     item.Manifest.Version,
+		"author":      item.Manifest.Author,
+		"description": item.Manifest.Description,
+		"settings":    item.Manifest.Settings,
+		"active":      active,
+	}
+}
@@ -0,0 +1,387 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"matuto-blog/config"
+	"matuto-blog/internal/api/middlewares"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+	"matuto-blog/pkg/logger"
+	"matuto-blog/pkg/totp"
+	"matuto-blog/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recoveryCodeAlphabet 恢复码字符集，去掉了容易混淆的0、1、i、l、o
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// recoveryCodeLength 恢复码长度，显示时每5位用短横线分隔
+const recoveryCodeLength = 10
+
+// totpSkew 允许前后各一个时间步的时钟误差
+const totpSkew = 1
+
+// TwoFactorPasswordRequest 需要再次验证密码的两步验证操作
+type TwoFactorPasswordRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// TwoFactorCodeRequest 提交验证码的请求，Code可以是验证器中的6位验证码或恢复码
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTwoFactorRequest 关闭两步验证请求
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// TwoFactorLoginRequest 两步验证登录请求
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// VerifyTwoFactor 登录第二步，使用挑战令牌和验证码换取访问令牌和刷新令牌
+func (a *AuthController) VerifyTwoFactor(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+	claims, err := middlewares.ParseChallengeToken(req.ChallengeToken)
+	if err != nil {
+		common.Unauthorized(c, err.Error())
+		return
+	}
+	var user models.User
+	if err := database.DB.First(&user, claims.UserID).Error; err != nil || !user.TotpEnabled {
+		common.Unauthorized(c, middlewares.ErrChallengeInvalid.Error())
+		return
+	}
+	if !checkLoginAllowed(c, user.Account) {
+		return
+	}
+	if !user.IsActive() {
+		recordLogin(c, user.Id, user.Account, models.LoginResultDisabled)
+		common.Forbidden(c, "账户已被禁用")
+		return
+	}
+
+	ok, err := verifyTwoFactorCode(&user, req.Code)
+	if err != nil {
+		common.ServerError(c, "验证失败")
+		return
+	}
+	if !ok {
+		loginFailed(c, user.Id, user.Account, models.LoginResultBadTwoFactor, "验证码错误")
+		return
+	}
+	if err := middlewares.ConsumeChallengeToken(claims); err != nil {
+		common.ServerError(c, "验证失败")
+		return
+	}
+
+	tokens, err := middlewares.IssueTokens(&user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		common.ServerError(c, "生成令牌失败")
+		return
+	}
+	loginSucceeded(c, &user, user.Account)
+	common.SuccessWithMessage(c, "登录成功", loginResponse(&user, tokens))
+}
+
+// TwoFactorStatus 当前用户的两步验证状态
+func (a *AuthController) TwoFactorStatus(c *gin.Context) {
+	user := middlewares.CurrentUser(c)
+	if user == nil {
+		common.ServerError(c, "未找到用户信息")
+		return
+	}
+	var remaining int64
+	if user.TotpEnabled {
+		database.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", user.Id).Count(&remaining)
+	}
+	common.Success(c, gin.H{
+		"enabled":                user.TotpEnabled,
+		"recoveryCodesRemaining": remaining,
+	})
+}
+
+// SetupTwoFactor 生成新的TOTP密钥，返回密钥和用于生成二维码的otpauth地址，提交验证码后才会启用
+func (a *AuthController) SetupTwoFactor(c *gin.Context) {
+	user := middlewares.CurrentUser(c)
+	if user == nil {
+		common.ServerError(c, "未找到用户信息")
+		return
+	}
+	var req TwoFactorPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+	if !user.CheckPassword(req.Password) {
+		common.BadRequest(c, "密码错误")
+		return
+	}
+	if user.TotpEnabled {
+		common.BadRequest(c, "已启用两步验证，请先关闭后再重新绑定")
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		common.ServerError(c, "生成密钥失败")
+		return
+	}
+	if err := database.DB.Model(user).Update("totp_secret", secret).Error; err != nil {
+		common.ServerError(c, "保存密钥失败: "+err.Error())
+		return
+	}
+	issuer := config.GetString("login.totp_issuer")
+	if issuer == "" {
+		issuer = config.GetString("site.title")
+	}
+	common.Success(c, gin.H{
+		"secret": secret,
+		"uri":    totp.ProvisioningURI(secret, issuer, user.Account),
+	})
+}
+
+// EnableTwoFactor 校验验证器中的验证码后启用两步验证，返回只显示一次的恢复码
+func (a *AuthController) EnableTwoFactor(c *gin.Context) {
+	user := middlewares.CurrentUser(c)
+	if user == nil {
+		common.ServerError(c, "未找到用户信息")
+		return
+	}
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+	if user.TotpEnabled {
+		common.BadRequest(c, "已启用两步验证")
+		return
+	}
+	if user.TotpSecret == "" {
+		common.BadRequest(c, "请先生成两步验证密钥")
+		return
+	}
+	step, ok := totp.Validate(user.TotpSecret, req.Code, time.Now(), totpSkew)
+	if !ok {
+		common.BadRequest(c, "验证码错误")
+		return
+	}
+
+	var codes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{"totp_enabled": true, "totp_last_step": step}).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.Id)
+		return err
+	})
+	if err != nil {
+		common.ServerError(c, "启用两步验证失败: "+err.Error())
+		return
+	}
+	common.SuccessWithMessage(c, "两步验证已启用，请妥善保存恢复码", gin.H{"recoveryCodes": codes})
+}
+
+// DisableTwoFactor 关闭两步验证，需要密码和验证码或恢复码
+func (a *AuthController) DisableTwoFactor(c *gin.Context) {
+	user := middlewares.CurrentUser(c)
+	if user == nil {
+		common.ServerError(c, "未找到用户信息")
+		return
+	}
+	var req DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+	if !user.TotpEnabled {
+		common.BadRequest(c, "未启用两步验证")
+		return
+	}
+	if !user.CheckPassword(req.Password) {
+		common.BadRequest(c, "密码错误")
+		return
+	}
+	ok, err := verifyTwoFactorCode(user, req.Code)
+	if err != nil {
+		common.ServerError(c, "验证失败")
+		return
+	}
+	if !ok {
+		common.BadRequest(c, "验证码错误")
+		return
+	}
+
+	if err := resetTwoFactor(user.Id); err != nil {
+		common.ServerError(c, "关闭两步验证失败: "+err.Error())
+		return
+	}
+	common.SuccessWithMessage(c, "两步验证已关闭", nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，之前的恢复码全部作废
+func (a *AuthController) RegenerateRecoveryCodes(c *gin.Context) {
+	user := middlewares.CurrentUser(c)
+	if user == nil {
+		common.ServerError(c, "未找到用户信息")
+		return
+	}
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+	if !user.TotpEnabled {
+		common.BadRequest(c, "未启用两步验证")
+		return
+	}
+	ok, err := verifyTwoFactorCode(user, req.Code)
+	if err != nil {
+		common.ServerError(c, "验证失败")
+		return
+	}
+	if !ok {
+		common.BadRequest(c, "验证码错误")
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(database.DB, user.Id)
+	if err != nil {
+		common.ServerError(c, "生成恢复码失败: "+err.Error())
+		return
+	}
+	common.SuccessWithMessage(c, "恢复码已重新生成，请妥善保存", gin.H{"recoveryCodes": codes})
+}
+
+// ResetUserTwoFactor 管理员关闭用户的两步验证，用于用户丢失验证器和恢复码的情况
+func (u *UserController) ResetUserTwoFactor(ctx *gin.Context) {
+	user, ok := findUser(ctx)
+	if !ok {
+		return
+	}
+	if err := resetTwoFactor(user.Id); err != nil {
+		common.ServerError(ctx, "重置两步验证失败: "+err.Error())
+		return
+	}
+	logger.Info("Two-factor authentication reset for", user.Account, "by user", ctx.GetInt("user_id"))
+	common.SuccessWithMessage(ctx, "已关闭该用户的两步验证", nil)
+}
+
+// verifyTwoFactorCode 校验验证码或恢复码，验证码只能使用一次，恢复码使用后作废
+func verifyTwoFactorCode(user *models.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if step, ok := totp.Validate(user.TotpSecret, code, time.Now(), totpSkew); ok {
+		// 条件更新防止同一个验证码被并发或重复使用
+		result := database.DB.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.Id, step).
+			Update("totp_last_step", step)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		return result.RowsAffected > 0, nil
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != recoveryCodeLength {
+		return false, nil
+	}
+	var codes []models.RecoveryCode
+	if err := database.DB.Where("user_id = ? AND used_at IS NULL", user.Id).Find(&codes).Error; err != nil {
+		return false, err
+	}
+	for _, recovery := range codes {
+		if !utils.CheckPassword(normalized, recovery.CodeHash) {
+			continue
+		}
+		result := database.DB.Model(&models.RecoveryCode{}).
+			Where("id = ? AND used_at IS NULL", recovery.Id).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return false, result.Error
+		}
+		if result.RowsAffected > 0 {
+			logger.Info("Recovery code used by", user.Account)
+		}
+		return result.RowsAffected > 0, nil
+	}
+	return false, nil
+}
+
+// replaceRecoveryCodes 删除用户已有的恢复码并生成新的一组，返回恢复码原文
+func replaceRecoveryCodes(tx *gorm.DB, userId int) ([]string, error) {
+	if err := tx.Where("user_id = ?", userId).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	count := config.GetInt("login.recovery_codes")
+	if count <= 0 {
+		count = 10
+	}
+	codes := make([]string, count)
+	records := make([]models.RecoveryCode, count)
+	for i := range codes {
+		raw, err := randomRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := utils.HashPassword(raw, utils.DefaultPasswordConfig)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = raw[:recoveryCodeLength/2] + "-" + raw[recoveryCodeLength/2:]
+		records[i] = models.RecoveryCode{UserId: userId, CodeHash: hash}
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// resetTwoFactor 关闭两步验证并删除密钥和恢复码
+func resetTwoFactor(userId int) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"totp_enabled": false, "totp_secret": "", "totp_last_step": 0}
+		if err := tx.Model(&models.User{}).Where("id = ?", userId).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userId).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+// randomRecoveryCode 生成随机恢复码
+func randomRecoveryCode() (string, error) {
+	b := make([]byte, 1)
+	code := make([]byte, recoveryCodeLength)
+	for i := range code {
+		// 拒绝采样避免取模偏差
+		for {
+			if _, err := rand.Read(b); err != nil {
+				return "", err
+			}
+			if int(b[0]) < 256-256%len(recoveryCodeAlphabet) {
+				code[i] = recoveryCodeAlphabet[int(b[0])%len(recoveryCodeAlphabet)]
+				break
+			}
+		}
+	}
+	return string(code), nil
+}
+
+// normalizeRecoveryCode 忽略大小写、空格和短横线
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
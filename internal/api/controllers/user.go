@@ -0,0 +1,374 @@
+package controllers
+
+import (
+	"errors"
+	"matuto-blog/internal/api/middlewares"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+	"matuto-blog/pkg/logger"
+	"matuto-blog/pkg/utils"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// accountPattern 登录账号只能包含字母、数字、下划线、点和短横线
+var accountPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,32}$`)
+
+// generatedPasswordLength 未指定密码时随机生成的密码长度
+const generatedPasswordLength = 12
+
+// UserController 用户管理控制器
+type UserController struct{}
+
+// UserPageRequest 用户分页请求
+type UserPageRequest struct {
+	common.PageRequest
+	Keyword string `json:"keyword" form:"keyword"`
+	Role    string `json:"role" form:"role"`
+	Status  *int   `json:"status" form:"status"`
+}
+
+// CreateUserRequest 创建用户请求，密码为空时随机生成
+type CreateUserRequest struct {
+	Account  string `json:"account" binding:"required"`
+	Username string `json:"username" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password"`
+	Avatar   string `json:"avatar"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// UpdateUserRequest 更新用户请求
+type UpdateUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Avatar   string `json:"avatar"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// UserStatusRequest 启用或禁用用户请求
+type UserStatusRequest struct {
+	Status *int `json:"status" binding:"required"`
+}
+
+// ResetPasswordRequest 重置密码请求，密码为空时随机生成
+type ResetPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+// UserPage 用户分页
+func (u *UserController) UserPage(ctx *gin.Context) {
+	var req UserPageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	var users []models.User
+	var total int64
+	query := database.DB.Model(&models.User{})
+	if req.Keyword != "" {
+		keyword := "%" + req.Keyword + "%"
+		query = query.Where("account LIKE ? OR username LIKE ? OR email LIKE ?", keyword, keyword, keyword)
+	}
+	if req.Role != "" {
+		query = query.Where("role = ?", req.Role)
+	}
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+	query.Count(&total)
+
+	offset := (req.Page - 1) * req.PageSize
+	query.Order("id ASC").Limit(req.PageSize).Offset(offset).Find(&users)
+
+	list := make([]gin.H, len(users))
+	for i := range users {
+		list[i] = userResponse(&users[i])
+	}
+	common.SuccessPage(ctx, list, total, req.Page, req.PageSize)
+}
+
+// GetUser 获取用户详情
+func (u *UserController) GetUser(ctx *gin.Context) {
+	user, ok := findUser(ctx)
+	if !ok {
+		return
+	}
+	result := userResponse(user)
+	result["loginLock"] = loginLockResponse(user)
+	common.Success(ctx, result)
+}
+
+// CreateUser 创建用户，未指定密码时返回随机生成的初始密码
+func (u *UserController) CreateUser(ctx *gin.Context) {
+	var req CreateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+	req.Account = strings.TrimSpace(req.Account)
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.TrimSpace(req.Email)
+	if !accountPattern.MatchString(req.Account) {
+		common.BadRequest(ctx, "账号只能包含字母、数字、下划线、点和短横线，长度3到32位")
+		return
+	}
+	if !models.ValidRole(req.Role) {
+		common.BadRequest(ctx, "无效的角色")
+		return
+	}
+
+	password, generated, err := resolvePassword(req.Password)
+	if err != nil {
+		common.BadRequest(ctx, err.Error())
+		return
+	}
+	user := models.User{
+		Account:  req.Account,
+		Username: req.Username,
+		Email:    req.Email,
+		Avatar:   req.Avatar,
+		Role:     req.Role,
+		Status:   models.UserStatusActive,
+	}
+	if err := createUser(&user, password); err != nil {
+		var conflict userConflictError
+		if errors.As(err, &conflict) {
+			common.Conflict(ctx, err.Error())
+			return
+		}
+		common.ServerError(ctx, "创建用户失败: "+err.Error())
+		return
+	}
+
+	result := userResponse(&user)
+	if generated {
+		result["password"] = password
+	}
+	common.SuccessWithMessage(ctx, "用户创建成功", result)
+}
+
+// UpdateUser 更新用户资料和角色，不能降级自己或最后一个管理员
+func (u *UserController) UpdateUser(ctx *gin.Context) {
+	user, ok := findUser(ctx)
+	if !ok {
+		return
+	}
+	var req UpdateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+	if !models.ValidRole(req.Role) {
+		common.BadRequest(ctx, "无效的角色")
+		return
+	}
+	if user.Role == models.RoleAdmin && req.Role != models.RoleAdmin {
+		if user.Id == ctx.GetInt("user_id") {
+			common.Forbidden(ctx, "不能修改自己的管理员角色")
+			return
+		}
+		if isLastAdmin(user) {
+			common.Forbidden(ctx, "至少需要保留一个管理员")
+			return
+		}
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.TrimSpace(req.Email)
+	if err := checkUserUnique("", req.Username, req.Email, user.Id); err != nil {
+		common.Conflict(ctx, err.Error())
+		return
+	}
+	updates := map[string]interface{}{
+		"username": req.Username,
+		"email":    req.Email,
+		"avatar":   req.Avatar,
+		"role":     req.Role,
+	}
+	if err := database.DB.Model(user).Updates(updates).Error; err != nil {
+		common.ServerError(ctx, "更新用户失败: "+err.Error())
+		return
+	}
+	common.SuccessWithMessage(ctx, "用户更新成功", userResponse(user))
+}
+
+// UpdateUserStatus 启用或禁用用户，禁用后立即吊销该用户的所有登录会话
+func (u *UserController) UpdateUserStatus(ctx *gin.Context) {
+	user, ok := findUser(ctx)
+	if !ok {
+		return
+	}
+	var req UserStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+	status := *req.Status
+	if status != models.UserStatusActive && status != models.UserStatusDisabled {
+		common.BadRequest(ctx, "无效的状态")
+		return
+	}
+	if status == models.UserStatusDisabled {
+		if user.Id == ctx.GetInt("user_id") {
+			common.Forbidden(ctx, "不能禁用自己的账户")
+			return
+		}
+		if user.Role == models.RoleAdmin && isLastAdmin(user) {
+			common.Forbidden(ctx, "至少需要保留一个管理员")
+			return
+		}
+	}
+
+	if err := database.DB.Model(user).Update("status", status).Error; err != nil {
+		common.ServerError(ctx, "更新用户状态失败: "+err.Error())
+		return
+	}
+	if status == models.UserStatusDisabled {
+		if err := middlewares.RevokeUserSessions(user.Id); err != nil {
+			logger.Error("Failed to revoke sessions of disabled user", user.Id, ":", err)
+		}
+	}
+	common.SuccessWithMessage(ctx, "用户状态更新成功", userResponse(user))
+}
+
+// ResetUserPassword 重置用户密码并吊销该用户的所有登录会话，未指定密码时返回随机生成的密码
+func (u *UserController) ResetUserPassword(ctx *gin.Context) {
+	user, ok := findUser(ctx)
+	if !ok {
+		return
+	}
+	var req ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && ctx.Request.ContentLength > 0 {
+		common.BadRequest(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	password, generated, err := resolvePassword(req.Password)
+	if err != nil {
+		common.BadRequest(ctx, err.Error())
+		return
+	}
+	if err := user.HashPassword(password); err != nil {
+		common.ServerError(ctx, "密码加密失败")
+		return
+	}
+	if err := database.DB.Model(user).Update("password", user.Password).Error; err != nil {
+		common.ServerError(ctx, "重置密码失败: "+err.Error())
+		return
+	}
+	if err := middlewares.RevokeUserSessions(user.Id); err != nil {
+		logger.Error("Failed to revoke sessions after password reset", user.Id, ":", err)
+	}
+
+	result := gin.H{}
+	if generated {
+		result["password"] = password
+	}
+	common.SuccessWithMessage(ctx, "密码重置成功", result)
+}
+
+// userConflictError 账号、用户名或邮箱已被其他用户使用
+type userConflictError string
+
+func (e userConflictError) Error() string {
+	return string(e)
+}
+
+// createUser 校验唯一性后加密密码并保存用户
+func createUser(user *models.User, password string) error {
+	if err := checkUserUnique(user.Account, user.Username, user.Email, 0); err != nil {
+		return err
+	}
+	if err := user.HashPassword(password); err != nil {
+		return err
+	}
+	return database.DB.Create(user).Error
+}
+
+// checkUserUnique 检查账号、用户名和邮箱是否已被其他用户使用，account为空时不检查账号
+func checkUserUnique(account, username, email string, excludeId int) error {
+	exists := func(column, value string) bool {
+		var count int64
+		database.DB.Model(&models.User{}).Where(column+" = ? AND id <> ?", value, excludeId).Count(&count)
+		return count > 0
+	}
+	switch {
+	case account != "" && exists("account", account):
+		return userConflictError("账号已被使用")
+	case exists("username", username):
+		return userConflictError("用户名已被使用")
+	case email != "" && exists("email", email):
+		return userConflictError("邮箱已被使用")
+	}
+	return nil
+}
+
+// resolvePassword 校验指定的密码，为空时随机生成，返回密码和是否为生成的密码
+func resolvePassword(password string) (string, bool, error) {
+	if password == "" {
+		generated, err := utils.GenerateRandomPassword(generatedPasswordLength)
+		return generated, true, err
+	}
+	if _, err := checkNewPassword(password); err != nil {
+		return "", false, err
+	}
+	return password, false, nil
+}
+
+// checkNewPassword 校验新密码的格式和强度，返回强度等级
+func checkNewPassword(password string) (string, error) {
+	if err := utils.ValidatePassword(password); err != nil {
+		return "", err
+	}
+	strength := utils.GetPasswordStrength(password)
+	if strength == "weak" {
+		return strength, errors.New("密码强度太弱，请使用至少8位并包含大小写字母、数字或符号中的两种")
+	}
+	return strength, nil
+}
+
+// isLastAdmin 用户是否为唯一一个启用的管理员
+func isLastAdmin(user *models.User) bool {
+	var count int64
+	database.DB.Model(&models.User{}).
+		Where("role = ? AND status = ? AND id <> ?", models.RoleAdmin, models.UserStatusActive, user.Id).
+		Count(&count)
+	return count == 0
+}
+
+// findUser 读取路径参数中的用户，失败时直接返回错误响应
+func findUser(ctx *gin.Context) (*models.User, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		common.BadRequest(ctx, "无效的用户ID")
+		return nil, false
+	}
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		common.NotFound(ctx, "用户不存在")
+		return nil, false
+	}
+	return &user, true
+}
+
+// userResponse 用户信息，不包含密码
+func userResponse(user *models.User) gin.H {
+	return gin.H{
+		"id":          user.Id,
+		"account":     user.Account,
+		"username":    user.Username,
+		"email":       user.Email,
+		"avatar":      user.Avatar,
+		"role":        user.Role,
+		"status":      user.Status,
+		"totpEnabled": user.TotpEnabled,
+		"createdAt":   user.CreatedAt,
+		"updatedAt":   user.UpdatedAt,
+	}
+}
@@ -12,31 +12,41 @@ import (
 	"github.com/spf13/viper"
 )
 
-// Claims JWT claims
+// Claims JWT claims，ID为令牌ID(jti)，用于吊销单个访问令牌
 type Claims struct {
-	UserID   int    `json:"user_id"`
-	Account  string `json:"account"`
-	Username string `json:"username"`
+	UserID    int    `json:"user_id"`
+	Account   string `json:"account"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 生成JWT token
-func GenerateToken(user *models.User) (string, error) {
+// generateAccessToken 为登录会话生成访问令牌，返回令牌、令牌ID和过期时间
+func generateAccessToken(user *models.User, sessionId string) (string, string, time.Time, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(time.Hour * time.Duration(viper.GetInt("jwt.access_token_ttl")))
 	claims := &Claims{
-		UserID:   user.Id,
-		Username: user.Username,
-		Account:  user.Account,
+		UserID:    user.Id,
+		Username:  user.Username,
+		Account:   user.Account,
+		SessionID: sessionId,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(viper.GetInt("jwt.access_token_ttl")))),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    viper.GetString("jwt.issuer"),
 			Subject:   user.Username,
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(viper.GetString("jwt.secret")))
+	signed, err := token.SignedString([]byte(viper.GetString("jwt.secret")))
+	return signed, jti, expiresAt, err
 }
 
 // ParseToken 解析JWT token
@@ -56,7 +66,7 @@ func ParseToken(tokenString string) (*Claims, error) {
 	return nil, jwt.ErrInvalidKey
 }
 
-// JWTAuth JWT认证中间件
+// JWTAuth JWT认证中间件，同时接受个人访问令牌
 func JWTAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从请求头获取token
@@ -75,14 +85,27 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
-		// 解析token
+		// 个人访问令牌
+		if IsPersonalToken(parts[1]) {
+			personalTokenAuth(c, parts[1])
+			return
+		}
+
+		// 解析token，没有令牌ID的旧令牌无法吊销，同样视为无效
 		claims, err := ParseToken(parts[1])
-		if err != nil {
+		if err != nil || claims.ID == "" {
 			common.Unauthorized(c, "认证令牌无效")
 			c.Abort()
 			return
 		}
 
+		// 检查令牌是否已吊销
+		if IsTokenRevoked(claims.ID) {
+			common.Unauthorized(c, "认证令牌已失效")
+			c.Abort()
+			return
+		}
+
 		// 验证用户是否存在
 		var user models.User
 		if err := database.DB.First(&user, claims.UserID).Error; err != nil {
@@ -92,18 +115,23 @@ func JWTAuth() gin.HandlerFunc {
 		}
 
 		// 检查用户状态
-		if user.Status != 1 {
+		if !user.IsActive() {
 			common.Unauthorized(c, "用户已被禁用")
 			c.Abort()
 			return
 		}
 
-		// 将用户信息存储到上下文中
-		c.Set("user", &user)
-		c.Set("user_id", user.Id)
-		c.Set("username", user.Username)
-		c.Set("account", user.Account)
+		setAuthUser(c, &user)
+		c.Set("claims", claims)
 
 		c.Next()
 	}
 }
+
+// setAuthUser 将用户信息存储到上下文中
+func setAuthUser(c *gin.Context, user *models.User) {
+	c.Set("user", user)
+	c.Set("user_id", user.Id)
+	c.Set("username", user.Username)
+	c.Set("account", user.Account)
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CurrentUser 获取JWTAuth保存到上下文中的当前用户，未认证时返回nil
+func CurrentUser(c *gin.Context) *models.User {
+	value, exists := c.Get("user")
+	if !exists {
+		return nil
+	}
+	user, _ := value.(*models.User)
+	return user
+}
+
+// RequirePermission 权限中间件，当前用户需要拥有全部指定权限，需在JWTAuth之后使用
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := CurrentUser(c)
+		if user == nil {
+			common.Unauthorized(c, "未登录")
+			c.Abort()
+			return
+		}
+		for _, permission := range permissions {
+			if !user.Can(permission) {
+				common.Forbidden(c, "没有权限执行此操作")
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,93 @@
+package middlewares
+
+import (
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/common"
+	"matuto-blog/pkg/logger"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// personalTokenTouchInterval 最近使用时间的更新间隔，避免每个请求都写数据库
+const personalTokenTouchInterval = time.Minute
+
+// CreatePersonalToken 创建个人访问令牌，令牌原文只在创建时返回
+func CreatePersonalToken(userId int, name string, scopes []string, expiresAt time.Time) (*models.PersonalToken, string, error) {
+	random, err := randomToken(20)
+	if err != nil {
+		return nil, "", err
+	}
+	raw := models.PersonalTokenPrefix + random
+	token := &models.PersonalToken{
+		UserId:    userId,
+		Name:      name,
+		TokenHint: raw[:len(models.PersonalTokenPrefix)+4],
+		TokenHash: hashToken(raw),
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: expiresAt,
+	}
+	if err := database.DB.Create(token).Error; err != nil {
+		return nil, "", err
+	}
+	return token, raw, nil
+}
+
+// IsPersonalToken 是否为个人访问令牌
+func IsPersonalToken(token string) bool {
+	return strings.HasPrefix(token, models.PersonalTokenPrefix)
+}
+
+// RequireSession 只允许通过登录会话访问，拒绝个人访问令牌，用于令牌管理、修改密码等敏感操作，需在JWTAuth之后使用
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("personal_token"); ok {
+			common.Forbidden(c, "个人访问令牌不能执行此操作")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// personalTokenAuth 使用个人访问令牌认证，用户的权限限制在令牌的权限范围内
+func personalTokenAuth(c *gin.Context, raw string) {
+	var token models.PersonalToken
+	if err := database.DB.Where("token_hash = ?", hashToken(raw)).First(&token).Error; err != nil {
+		common.Unauthorized(c, "认证令牌无效")
+		c.Abort()
+		return
+	}
+	now := time.Now()
+	if !token.Active(now) {
+		common.Unauthorized(c, "认证令牌已失效")
+		c.Abort()
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, token.UserId).Error; err != nil {
+		common.Unauthorized(c, "用户不存在")
+		c.Abort()
+		return
+	}
+	if !user.IsActive() {
+		common.Unauthorized(c, "用户已被禁用")
+		c.Abort()
+		return
+	}
+	user.Scopes = token.ScopeList()
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= personalTokenTouchInterval || token.LastUsedIP != c.ClientIP() {
+		err := database.DB.Model(&token).Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": c.ClientIP()}).Error
+		if err != nil {
+			logger.Warn("Failed to update personal token usage:", err)
+		}
+	}
+
+	setAuthUser(c, &user)
+	c.Set("personal_token", &token)
+	c.Next()
+}
@@ -0,0 +1,218 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/logger"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRefreshTokenInvalid 刷新令牌不存在、已过期或已吊销
+	ErrRefreshTokenInvalid = errors.New("刷新令牌无效或已过期")
+	// ErrRefreshTokenReused 已轮换的刷新令牌被再次使用，可能已泄露，所在会话已吊销
+	ErrRefreshTokenReused = errors.New("刷新令牌已被使用，请重新登录")
+	// ErrUserDisabled 用户不存在或已被禁用
+	ErrUserDisabled = errors.New("账户已被禁用")
+)
+
+// TokenPair 登录或刷新后签发的令牌
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	RefreshToken     string    `json:"refreshToken"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+}
+
+// IssueTokens 为新的登录会话签发访问令牌和刷新令牌
+func IssueTokens(user *models.User, userAgent, ip string) (*TokenPair, error) {
+	sessionId, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	// 顺便清理该用户已过期的刷新令牌
+	database.DB.Where("user_id = ? AND expires_at < ?", user.Id, time.Now()).Delete(&models.RefreshToken{})
+	return issueSessionTokens(database.DB, user, sessionId, userAgent, ip)
+}
+
+// RefreshTokens 使用刷新令牌换取新的令牌，旧的刷新令牌和访问令牌随即失效
+// 已轮换的刷新令牌被再次使用时吊销整个会话，防止泄露的令牌继续使用
+func RefreshTokens(refreshToken, userAgent, ip string) (*TokenPair, *models.User, error) {
+	var record models.RefreshToken
+	if err := database.DB.Where("token_hash = ?", hashToken(refreshToken)).First(&record).Error; err != nil {
+		return nil, nil, ErrRefreshTokenInvalid
+	}
+	if record.RevokedAt != nil {
+		var rotated int64
+		database.DB.Model(&models.RefreshToken{}).Where("session_id = ? AND id > ?", record.SessionId, record.Id).Count(&rotated)
+		if rotated > 0 {
+			logger.Warn("Refresh token reused, revoking session:", record.SessionId)
+			RevokeSession(record.UserId, record.SessionId)
+			return nil, nil, ErrRefreshTokenReused
+		}
+		return nil, nil, ErrRefreshTokenInvalid
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return nil, nil, ErrRefreshTokenInvalid
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, record.UserId).Error; err != nil || !user.IsActive() {
+		return nil, nil, ErrUserDisabled
+	}
+
+	var pair *TokenPair
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// 条件更新保证并发刷新时只有一个请求成功
+		result := tx.Model(&models.RefreshToken{}).Where("id = ? AND revoked_at IS NULL", record.Id).Update("revoked_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenInvalid
+		}
+		if err := revokeAccessToken(tx, record.UserId, record.AccessJti, record.AccessExpiresAt); err != nil {
+			return err
+		}
+		var err error
+		pair, err = issueSessionTokens(tx, &user, record.SessionId, userAgent, ip)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return pair, &user, nil
+}
+
+// RevokeSession 吊销登录会话的所有刷新令牌和当前的访问令牌
+func RevokeSession(userId int, sessionId string) error {
+	return revokeSessions(database.DB.Where("user_id = ? AND session_id = ?", userId, sessionId))
+}
+
+// RevokeUserSessions 吊销用户所有登录会话，用于退出所有设备或修改密码后
+func RevokeUserSessions(userId int) error {
+	return revokeSessions(database.DB.Where("user_id = ?", userId))
+}
+
+// RevokeOtherSessions 吊销用户除当前会话外的所有登录会话，用于修改密码后让其他设备下线
+func RevokeOtherSessions(userId int, sessionId string) error {
+	return revokeSessions(database.DB.Where("user_id = ? AND session_id <> ?", userId, sessionId))
+}
+
+// RevokeRefreshToken 吊销刷新令牌所在的会话，令牌无效时忽略
+func RevokeRefreshToken(refreshToken string) error {
+	var record models.RefreshToken
+	if err := database.DB.Where("token_hash = ?", hashToken(refreshToken)).First(&record).Error; err != nil {
+		return nil
+	}
+	return RevokeSession(record.UserId, record.SessionId)
+}
+
+// IsTokenRevoked 访问令牌是否已吊销
+func IsTokenRevoked(jti string) bool {
+	var count int64
+	if err := database.DB.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		logger.Error("Failed to check revoked token:", err)
+		return true
+	}
+	return count > 0
+}
+
+// revokeSessions 吊销查询到的会话中仍然有效的令牌
+func revokeSessions(query *gorm.DB) error {
+	var records []models.RefreshToken
+	if err := query.Where("revoked_at IS NULL").Find(&records).Error; err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	ids := make([]int, len(records))
+	for i, record := range records {
+		ids[i] = record.Id
+	}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RefreshToken{}).Where("id IN ?", ids).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := revokeAccessToken(tx, record.UserId, record.AccessJti, record.AccessExpiresAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// revokeAccessToken 将访问令牌加入吊销列表，已过期的令牌无需记录；同时清理过期的吊销记录
+func revokeAccessToken(tx *gorm.DB, userId int, jti string, expiresAt time.Time) error {
+	now := time.Now()
+	if jti == "" || expiresAt.Before(now) {
+		return nil
+	}
+	if err := tx.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	var count int64
+	tx.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count)
+	if count > 0 {
+		return nil
+	}
+	return tx.Create(&models.RevokedToken{Jti: jti, UserId: userId, ExpiresAt: expiresAt}).Error
+}
+
+// issueSessionTokens 为会话签发一对新令牌并保存刷新令牌
+func issueSessionTokens(tx *gorm.DB, user *models.User, sessionId, userAgent, ip string) (*TokenPair, error) {
+	accessToken, jti, accessExpiresAt, err := generateAccessToken(user, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if len(userAgent) > 256 {
+		userAgent = userAgent[:256]
+	}
+	record := models.RefreshToken{
+		UserId:          user.Id,
+		SessionId:       sessionId,
+		TokenHash:       hashToken(refreshToken),
+		AccessJti:       jti,
+		AccessExpiresAt: accessExpiresAt,
+		ExpiresAt:       time.Now().Add(time.Hour * time.Duration(viper.GetInt("jwt.refresh_token_ttl"))),
+		UserAgent:       userAgent,
+		IP:              ip,
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        accessExpiresAt,
+		RefreshExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// randomToken 生成n字节随机数的十六进制字符串
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken 刷新令牌的哈希，数据库中不保存令牌原文
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
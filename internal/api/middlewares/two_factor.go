@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"errors"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/viper"
+)
+
+// ErrChallengeInvalid 两步验证挑战令牌无效、已过期或已使用
+var ErrChallengeInvalid = errors.New("验证已过期，请重新登录")
+
+// ChallengeClaims 两步验证挑战令牌，密码验证通过后签发，只能用于换取访问令牌
+type ChallengeClaims struct {
+	UserID int `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+// GenerateChallengeToken 为通过密码验证的用户签发挑战令牌，返回令牌和过期时间
+func GenerateChallengeToken(user *models.User) (string, time.Time, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(time.Minute * time.Duration(viper.GetInt("login.challenge_ttl_minutes")))
+	claims := &ChallengeClaims{
+		UserID: user.Id,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    viper.GetString("jwt.issuer"),
+			Subject:   user.Account,
+		},
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(challengeKey())
+	return signed, expiresAt, err
+}
+
+// ParseChallengeToken 解析挑战令牌，已使用过的令牌视为无效
+func ParseChallengeToken(tokenString string) (*ChallengeClaims, error) {
+	claims := &ChallengeClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return challengeKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid || claims.ID == "" || IsTokenRevoked(claims.ID) {
+		return nil, ErrChallengeInvalid
+	}
+	return claims, nil
+}
+
+// ConsumeChallengeToken 两步验证通过后作废挑战令牌，防止重复使用
+func ConsumeChallengeToken(claims *ChallengeClaims) error {
+	return revokeAccessToken(database.DB, claims.UserID, claims.ID, claims.ExpiresAt.Time)
+}
+
+// challengeKey 挑战令牌的签名密钥，与访问令牌使用不同的密钥，挑战令牌无法通过JWTAuth
+func challengeKey() []byte {
+	return []byte("2fa-challenge:" + viper.GetString("jwt.secret"))
+}
@@ -1,9 +1,11 @@
 package router
 
 import (
+	"fmt"
 	"matuto-blog/config"
 	"matuto-blog/internal/api/controllers"
 	"matuto-blog/internal/api/middlewares"
+	"matuto-blog/internal/models"
 	"matuto-blog/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -17,17 +19,25 @@ func InitRoutes() *gin.Engine {
 	r.Use(middlewares.Logger())
 	r.Use(middlewares.CORS())
 
-	// 设置模板路径 - 根据主题配置加载模板
-	themePath := config.GetString("theme.path")
-	templateNames := []string{
-		"default",
-		"theme2",
-	}
 	// 设置模板方法
 	customFuncs := utils.GenTemplateFuncMap()
-	// 加载模板
-	tplManager := utils.NewTemplateManager(themePath, templateNames)
-	tplManager.LoadTemplates(r, customFuncs)
+	for name, fn := range controllers.ImageTemplateFuncs() {
+		customFuncs[name] = fn
+	}
+	// 加载主题模板，按当前主题渲染前台页面
+	if err := controllers.InitThemes(r, customFuncs); err != nil {
+		panic(fmt.Sprintf("主题加载失败：%v", err))
+	}
+
+	// 固定链接规则
+	if err := models.SetPermalinkPatterns(models.PermalinkConfig{
+		Article:  config.GetString("permalink.article"),
+		Page:     config.GetString("permalink.page"),
+		Category: config.GetString("permalink.category"),
+		Tag:      config.GetString("permalink.tag"),
+	}); err != nil {
+		panic(fmt.Sprintf("固定链接规则配置错误：%v", err))
+	}
 
 	// 静态文件
 	r.Static("/static", "./web/static")
@@ -39,7 +49,14 @@ func InitRoutes() *gin.Engine {
 	categoryController := &controllers.CategoryController{}
 	tagController := &controllers.TagController{}
 	commentController := &controllers.CommentController{}
+	linkController := &controllers.LinkController{}
 	attachmentController := &controllers.AttachmentController{}
+	feedController := &controllers.FeedController{}
+	sitemapController := &controllers.SitemapController{}
+	searchController := &controllers.SearchController{}
+	userController := &controllers.UserController{}
+	setupController := &controllers.SetupController{}
+	themeController := &controllers.ThemeController{}
 
 	// 前台路由
 	frontend := r.Group("/")
@@ -47,78 +64,186 @@ func InitRoutes() *gin.Engine {
 		// 首页
 		frontend.GET("", articleController.Index)
 
-		// 文章详情
+		// 文章详情，支持ID和slug，非固定链接时301跳转
 		frontend.GET("/article/:id", articleController.Show)
 
 		// 分类页面
-		frontend.GET("/category/:id", articleController.Index)
+		frontend.GET("/category/:id", articleController.CategoryIndex)
 
 		// 分类列表页面
 		frontend.GET("/categories", categoryController.CategoryListPage)
 
 		// 标签页面
-		frontend.GET("/tag/:id", articleController.Index)
+		frontend.GET("/tag/:id", articleController.TagIndex)
 
 		// 搜索页面
 		frontend.GET("/search", articleController.Index)
 
 		// 评论提交
 		frontend.POST("/comment/submit", commentController.Submit)
+
+		// 评论邮件通知退订
+		frontend.GET("/comment/unsubscribe", commentController.Unsubscribe)
+		frontend.POST("/comment/unsubscribe", commentController.Unsubscribe)
+
+		// 订阅源
+		frontend.GET("/feed.xml", feedController.RSS)
+		frontend.GET("/atom.xml", feedController.Atom)
+		frontend.GET("/category/:id/feed.xml", feedController.CategoryRSS)
+		frontend.GET("/category/:id/atom.xml", feedController.CategoryAtom)
+		frontend.GET("/tag/:id/feed.xml", feedController.TagRSS)
+		frontend.GET("/tag/:id/atom.xml", feedController.TagAtom)
+
+		// 站点地图和robots.txt
+		frontend.GET("/sitemap.xml", sitemapController.Sitemap)
+		frontend.GET("/sitemaps/:file", sitemapController.SitemapPart)
+		frontend.GET("/robots.txt", sitemapController.Robots)
 	}
 
+	// 按固定链接规则解析其余前台页面
+	r.NoRoute(articleController.Permalink)
+
 	// API路由 (用于AJAX请求)
 	api := r.Group("/api")
 	{
 		// 认证接口
 		api.POST("/login", authController.Login)
+		api.POST("/login/2fa", authController.VerifyTwoFactor)
 		api.POST("/logout", authController.Logout)
+		api.POST("/token/refresh", authController.RefreshToken)
+		// 首次安装
+		api.GET("/setup", setupController.SetupStatus)
+		api.POST("/setup", setupController.Setup)
+		// 文章评论树
+		api.GET("/articles/:id/comments", commentController.ArticleComments)
+		// 评论表单令牌，不随页面缓存
+		api.GET("/articles/:id/comment-token", commentController.CommentToken)
 		// 需要认证的API
 		apiAuth := api.Group("", middlewares.JWTAuth())
 		{
 			apiAuth.GET("/profile", authController.GetProfile)
+			// 账户安全相关操作只能通过登录会话进行，个人访问令牌不能使用
+			session := apiAuth.Group("", middlewares.RequireSession())
+			{
+				session.PUT("/profile", authController.UpdateProfile)
+				session.PUT("/profile/password", authController.ChangePassword)
+				// 两步验证
+				session.GET("/profile/2fa", authController.TwoFactorStatus)
+				session.POST("/profile/2fa/setup", authController.SetupTwoFactor)
+				session.POST("/profile/2fa/enable", authController.EnableTwoFactor)
+				session.POST("/profile/2fa/disable", authController.DisableTwoFactor)
+				session.POST("/profile/2fa/recovery-codes", authController.RegenerateRecoveryCodes)
+				// 个人访问令牌
+				session.GET("/tokens", authController.PersonalTokenList)
+				session.POST("/tokens", authController.CreatePersonalToken)
+				session.DELETE("/tokens/:id", authController.RevokePersonalToken)
+				session.POST("/logout/all", authController.LogoutAll)
+			}
 			// 文件管理
-			attr := apiAuth.Group("/attach")
+			attr := apiAuth.Group("/attach", middlewares.RequirePermission(models.PermAttachUpload))
 			{
 				attr.POST("/upload", attachmentController.Upload)
 				attr.GET("/page", attachmentController.AttachPage)
 				attr.DELETE("/:id", attachmentController.DeleteAttach)
+				attr.POST("/batch-delete", attachmentController.BatchDeleteAttach)
+				// 分片上传
+				attr.POST("/chunks", attachmentController.CreateChunkUpload)
+				attr.GET("/chunks/:uploadId", attachmentController.ChunkUploadStatus)
+				attr.PUT("/chunks/:uploadId/:index", attachmentController.UploadChunk)
+				attr.POST("/chunks/:uploadId/complete", attachmentController.CompleteChunkUpload)
+				attr.DELETE("/chunks/:uploadId", attachmentController.CancelChunkUpload)
+				// 附件引用与闲置附件回收
+				attr.GET("/:id/usages", attachmentController.AttachUsages)
+				attrManage := attr.Group("", middlewares.RequirePermission(models.PermAttachManage))
+				attrManage.POST("/scan", attachmentController.ScanAttachUsages)
+				attrManage.GET("/unused", attachmentController.UnusedAttachList)
+				attrManage.POST("/unused/collect", attachmentController.CollectUnusedAttach)
 			}
 			// 文章管理
-			articles := apiAuth.Group("/articles")
+			articles := apiAuth.Group("/articles", middlewares.RequirePermission(models.PermArticleWrite))
 			{
 				articles.GET("/page", articleController.ArticlePage)
 				articles.GET("/:id", articleController.GetArticle)
 				articles.DELETE("/:id", articleController.DeleteArticle)
 				articles.POST("/publish", articleController.PublishArticle)
 				articles.PUT("/update", articleController.UpdateArticle)
+				// 文章历史版本
+				articles.GET("/:id/revisions", articleController.RevisionList)
+				articles.GET("/:id/revisions/diff", articleController.RevisionDiff)
+				articles.GET("/:id/revisions/:revisionId", articleController.RevisionDetail)
+				articles.POST("/:id/revisions/:revisionId/restore", articleController.RevisionRestore)
 			}
 			// 分类管理
 			categories := apiAuth.Group("/categories")
 			{
-				categories.GET("/page", categoryController.CategoryPage)
-				categories.POST("", categoryController.CreateCategory)
-				categories.PUT("/:id", categoryController.UpdateCategory)
-				categories.DELETE("/:id", categoryController.DeleteCategory)
-				categories.GET("/enable-list", categoryController.CategoryEnableList)
+				// 撰写文章时选择分类
+				categories.GET("/enable-list", middlewares.RequirePermission(models.PermArticleWrite), categoryController.CategoryEnableList)
+				categoryManage := categories.Group("", middlewares.RequirePermission(models.PermTaxonomyManage))
+				categoryManage.GET("/page", categoryController.CategoryPage)
+				categoryManage.POST("", categoryController.CreateCategory)
+				categoryManage.PUT("/:id", categoryController.UpdateCategory)
+				categoryManage.DELETE("/:id", categoryController.DeleteCategory)
 			}
 			// 标签管理
 			tags := apiAuth.Group("/tags")
 			{
-				tags.GET("/page", tagController.TagPage)
-				tags.POST("/create", tagController.CreateTag)
-				tags.PUT("/:id", tagController.UpdateTag)
-				tags.POST("/:id", tagController.UpdateTag)
-				tags.DELETE("/:id", tagController.DeleteTag)
-				tags.GET("/enable-list", tagController.TagEnableList)
+				// 撰写文章时选择标签
+				tags.GET("/enable-list", middlewares.RequirePermission(models.PermArticleWrite), tagController.TagEnableList)
+				tagManage := tags.Group("", middlewares.RequirePermission(models.PermTaxonomyManage))
+				tagManage.GET("/page", tagController.TagPage)
+				tagManage.POST("/create", tagController.CreateTag)
+				tagManage.PUT("/:id", tagController.UpdateTag)
+				tagManage.POST("/:id", tagController.UpdateTag)
+				tagManage.DELETE("/:id", tagController.DeleteTag)
 			}
 			// 评论管理
-			comments := apiAuth.Group("/comments")
+			comments := apiAuth.Group("/comments", middlewares.RequirePermission(models.PermCommentManage))
 			{
 				comments.GET("/page", commentController.CommentPage)
 				comments.PUT("/:id/status", commentController.ReviewComment)
 				comments.DELETE("/:id", commentController.DestroyComment)
 				comments.POST("/batch-review", commentController.BatchReviewComment)
 			}
+			// 友情链接管理
+			links := apiAuth.Group("/links", middlewares.RequirePermission(models.PermLinkManage))
+			{
+				links.GET("/page", linkController.LinkPage)
+				links.GET("/groups", linkController.LinkGroups)
+				links.POST("", linkController.CreateLink)
+				links.PUT("/sort", linkController.SortLinks)
+				links.POST("/check", linkController.CheckLinks)
+				links.PUT("/:id", linkController.UpdateLink)
+				links.DELETE("/:id", linkController.DeleteLink)
+			}
+			// 用户管理
+			users := apiAuth.Group("/users", middlewares.RequireSession(), middlewares.RequirePermission(models.PermUserManage))
+			{
+				users.GET("/page", userController.UserPage)
+				users.GET("/login-logs", userController.LoginLogPage)
+				users.POST("/unlock-ip", userController.UnlockIP)
+				users.GET("/:id", userController.GetUser)
+				users.POST("", userController.CreateUser)
+				users.PUT("/:id", userController.UpdateUser)
+				users.PUT("/:id/status", userController.UpdateUserStatus)
+				users.PUT("/:id/password", userController.ResetUserPassword)
+				users.POST("/:id/unlock", userController.UnlockUser)
+				users.DELETE("/:id/2fa", userController.ResetUserTwoFactor)
+				users.GET("/:id/tokens", userController.UserPersonalTokens)
+				users.DELETE("/:id/tokens", userController.RevokeUserPersonalTokens)
+			}
+			// 全文搜索
+			apiAuth.POST("/search/rebuild", middlewares.RequirePermission(models.PermSiteManage), searchController.Rebuild)
+			// 编辑文章和分类时选择文章模板
+			apiAuth.GET("/themes/templates", middlewares.RequirePermission(models.PermArticleWrite), themeController.ArticleTemplates)
+			// 主题管理
+			themes := apiAuth.Group("/themes", middlewares.RequirePermission(models.PermSiteManage))
+			{
+				themes.GET("", themeController.ThemeList)
+				themes.PUT("/active", themeController.ActivateTheme)
+				themes.POST("/reload", themeController.ReloadThemes)
+				themes.GET("/:name/settings", themeController.ThemeSettings)
+				themes.PUT("/:name/settings", themeController.UpdateThemeSettings)
+			}
 		}
 
 	}
@@ -1,8 +1,10 @@
 package database
 
 import (
+	"fmt"
 	"matuto-blog/internal/models"
 	"matuto-blog/pkg/logger"
+	"slices"
 
 	"gorm.io/gorm"
 )
@@ -19,9 +21,23 @@ func InitTables(db *gorm.DB) error {
 		&models.Tag{},
 		&models.Comment{},
 		&models.Attach{},
+		&models.AttachBlob{},
+		&models.AttachVariant{},
+		&models.AttachUsage{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.LoginLog{},
+		&models.LoginThrottle{},
+		&models.RecoveryCode{},
+		&models.PersonalToken{},
+		&models.ThemeSetting{},
 		&models.Link{},
 		&models.ArticleCategory{},
 		&models.ArticleTag{},
+		&models.ArticleRevision{},
+		&models.SlugHistory{},
+		&models.MailUnsubscribe{},
+		&models.SchemaMigration{},
 	)
 
 	if err != nil {
@@ -29,10 +45,60 @@ func InitTables(db *gorm.DB) error {
 		return err
 	}
 
+	if err := runMigrations(db); err != nil {
+		logger.Error("Failed to migrate data:", err)
+		return err
+	}
+
 	logger.Info("Database tables initialized successfully")
 	return nil
 }
 
+// migration 数据迁移，按版本顺序执行，执行成功后记录版本，之后启动时不再执行
+type migration struct {
+	version string
+	migrate func(tx *gorm.DB) error
+}
+
+// migrations 所有数据迁移，新的迁移追加到末尾，已发布的迁移不能修改版本号
+var migrations = []migration{
+	{version: "001_ownership", migrate: migrateOwnership},
+}
+
+// runMigrations 执行尚未执行过的数据迁移，每个迁移与其版本记录在同一事务中提交
+func runMigrations(db *gorm.DB) error {
+	var applied []string
+	if err := db.Model(&models.SchemaMigration{}).Pluck("version", &applied).Error; err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if slices.Contains(applied, m.version) {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.migrate(tx); err != nil {
+				return err
+			}
+			return tx.Create(&models.SchemaMigration{Version: m.version}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("数据迁移%s失败: %w", m.version, err)
+		}
+		logger.Info("Data migration applied:", m.version)
+	}
+	return nil
+}
+
+// migrateOwnership 补全引入角色权限之前的数据：已有用户均为管理员，文章创建人沿用文章作者
+// 只在升级时执行一次，之后角色为空的用户没有任何权限
+func migrateOwnership(db *gorm.DB) error {
+	if err := db.Model(&models.User{}).Where("role = ''").Update("role", models.RoleAdmin).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.Article{}).Where("created_by = 0 AND user_id IS NOT NULL").
+		Update("created_by", gorm.Expr("user_id")).Error
+}
+
 // CreateIndexes 创建必要的索引
 func CreateIndexes(db *gorm.DB) error {
 	logger.Info("Creating database indexes...")
@@ -68,6 +134,9 @@ func CreateIndexes(db *gorm.DB) error {
 		// 文章标签关联表索引
 		"CREATE INDEX IF NOT EXISTS idx_article_tag_article_id ON p_article_tag(article_id)",
 		"CREATE INDEX IF NOT EXISTS idx_article_tag_tag_id ON p_article_tag(tag_id)",
+
+		// 文章历史版本表索引
+		"CREATE INDEX IF NOT EXISTS idx_article_revision_version ON m_article_revision(article_id, version)",
 	}
 
 	for _, indexSQL := range indexes {
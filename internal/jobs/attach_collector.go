@@ -0,0 +1,340 @@
+package jobs
+
+import (
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/logger"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// attachURLPattern 文本中可能指向附件的地址，包括完整URL、协议相对URL和绝对路径
+var attachURLPattern = regexp.MustCompile(`(?:https?:)?//[^\s"'<>()\[\]\\]+|/[^\s"'<>()\[\]\\]+`)
+
+// AttachCollector 附件回收任务，扫描文章及其历史版本、分类、标签、用户和友情链接中引用的附件并记录使用位置，
+// 回收超过指定天数未被引用的附件
+type AttachCollector struct {
+	interval   time.Duration
+	unusedDays int
+	autoDelete bool
+	mu         sync.Mutex
+	stop       chan struct{}
+	hooks      []func(attachments []models.Attach)
+}
+
+// NewAttachCollector 创建附件回收任务，autoDelete为false时定时任务只扫描引用，不删除附件
+func NewAttachCollector(interval time.Duration, unusedDays int, autoDelete bool) *AttachCollector {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	if unusedDays < 0 {
+		unusedDays = 30
+	}
+	return &AttachCollector{
+		interval:   interval,
+		unusedDays: unusedDays,
+		autoDelete: autoDelete,
+		stop:       make(chan struct{}),
+	}
+}
+
+// OnCollect 注册附件记录删除后的回调，用于释放存储中的文件
+func (c *AttachCollector) OnCollect(hook func(attachments []models.Attach)) {
+	c.hooks = append(c.hooks, hook)
+}
+
+// UnusedDays 配置的未引用天数
+func (c *AttachCollector) UnusedDays() int {
+	return c.unusedDays
+}
+
+// Start 在后台启动回收任务
+func (c *AttachCollector) Start() {
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		c.run()
+		for {
+			select {
+			case <-ticker.C:
+				c.run()
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+	logger.Info("Attachment collector started, interval:", c.interval)
+}
+
+// Stop 停止回收任务
+func (c *AttachCollector) Stop() {
+	close(c.stop)
+}
+
+// run 执行一次定时回收
+func (c *AttachCollector) run() {
+	if database.DB == nil {
+		return
+	}
+	if c.autoDelete {
+		if _, err := c.Collect(c.unusedDays); err != nil {
+			logger.Error("Failed to collect attachments:", err)
+		}
+		return
+	}
+	if _, err := c.Scan(); err != nil {
+		logger.Error("Failed to scan attachment usages:", err)
+		return
+	}
+	orphans, err := c.Orphans(c.unusedDays)
+	if err != nil {
+		logger.Error("Failed to list unused attachments:", err)
+		return
+	}
+	if len(orphans) > 0 {
+		logger.Info("Unused attachments:", len(orphans))
+	}
+}
+
+// Scan 扫描所有引用并重建附件使用记录，返回被引用的附件数量
+// 不再被引用的附件记录开始闲置的时间，重新被引用的附件清除该时间
+func (c *AttachCollector) Scan() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.scan()
+}
+
+// scan 扫描引用，调用方需持有锁
+func (c *AttachCollector) scan() (int, error) {
+	index, err := buildAttachIndex()
+	if err != nil {
+		return 0, err
+	}
+	// 任何来源读取失败时中止扫描，避免把仍在使用的附件标记为闲置
+	usages, err := collectAttachUsages(index)
+	if err != nil {
+		return 0, err
+	}
+
+	var previous []int
+	if err := database.DB.Model(&models.AttachUsage{}).Distinct("attach_id").Pluck("attach_id", &previous).Error; err != nil {
+		return 0, err
+	}
+	used := make(map[int]bool)
+	for _, usage := range usages {
+		used[usage.AttachId] = true
+	}
+	var released, usedIds []int
+	for _, id := range previous {
+		if !used[id] {
+			released = append(released, id)
+		}
+	}
+	for id := range used {
+		usedIds = append(usedIds, id)
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Where("1 = 1").Delete(&models.AttachUsage{}).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if len(usages) > 0 {
+		if err := tx.CreateInBatches(usages, 200).Error; err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	if len(released) > 0 {
+		if err := tx.Model(&models.Attach{}).Where("id IN ?", released).Update("unused_since", time.Now()).Error; err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	if len(usedIds) > 0 {
+		if err := tx.Model(&models.Attach{}).Where("id IN ? AND unused_since IS NOT NULL", usedIds).
+			Update("unused_since", nil).Error; err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return 0, err
+	}
+	return len(used), nil
+}
+
+// Orphans 列出没有被引用且闲置超过days天的附件，闲置时间从不再被引用或上传时开始计算
+// 结果基于最近一次扫描，需要最新结果时先调用Scan
+func (c *AttachCollector) Orphans(days int) ([]models.Attach, error) {
+	cutoff := time.Now().AddDate(0, 0, -days)
+	var attachments []models.Attach
+	err := database.DB.
+		Where("id NOT IN (?)", database.DB.Model(&models.AttachUsage{}).Select("attach_id")).
+		Where("COALESCE(unused_since, created_at) <= ?", cutoff).
+		Order("created_at ASC").
+		Find(&attachments).Error
+	return attachments, err
+}
+
+// Collect 重新扫描引用后删除闲置超过days天的附件记录，并通过回调释放文件，返回删除的附件
+func (c *AttachCollector) Collect(days int) ([]models.Attach, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := c.scan(); err != nil {
+		return nil, err
+	}
+	attachments, err := c.Orphans(days)
+	if err != nil || len(attachments) == 0 {
+		return nil, err
+	}
+	ids := make([]int, len(attachments))
+	for i, attachment := range attachments {
+		ids[i] = attachment.Id
+	}
+	if err := database.DB.Where("id IN ?", ids).Delete(&models.Attach{}).Error; err != nil {
+		return nil, err
+	}
+
+	logger.Info("Collected unused attachments:", len(attachments))
+	for _, hook := range c.hooks {
+		hook(attachments)
+	}
+	return attachments, nil
+}
+
+// buildAttachIndex 建立附件地址到附件ID的索引，包括图片派生尺寸的地址
+// 内容相同的附件共用一个文件和地址，引用该地址时这些附件都视为被使用
+func buildAttachIndex() (map[string][]int, error) {
+	var attachments []models.Attach
+	if err := database.DB.Select("id", "url", "blob_id").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	index := make(map[string][]int)
+	byBlob := make(map[int][]int)
+	for _, attachment := range attachments {
+		if key := attachURLKey(attachment.URL); key != "" {
+			index[key] = append(index[key], attachment.Id)
+		}
+		if attachment.BlobId != nil {
+			byBlob[*attachment.BlobId] = append(byBlob[*attachment.BlobId], attachment.Id)
+		}
+	}
+
+	var variants []models.AttachVariant
+	if err := database.DB.Select("blob_id", "url").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	for _, variant := range variants {
+		if key := attachURLKey(variant.URL); key != "" {
+			index[key] = append(index[key], byBlob[variant.BlobId]...)
+		}
+	}
+	return index, nil
+}
+
+// collectAttachUsages 扫描文章内容和缩略图、文章历史版本、分类和标签封面、用户头像以及友情链接logo中引用的附件
+// 历史版本恢复后会重新引用其中的附件，因此同样视为使用中
+func collectAttachUsages(index map[string][]int) ([]models.AttachUsage, error) {
+	var usages []models.AttachUsage
+	seen := make(map[models.AttachUsage]bool)
+	add := func(sourceType string, sourceId int, field, title, text string) {
+		for _, attachId := range matchAttachURLs(index, text) {
+			usage := models.AttachUsage{AttachId: attachId, SourceType: sourceType, SourceId: sourceId, Field: field}
+			if seen[usage] {
+				continue
+			}
+			seen[usage] = true
+			usage.Title = title
+			usages = append(usages, usage)
+		}
+	}
+
+	var articles []models.Article
+	if err := database.DB.Select("id", "title", "content", "thumbnail").Find(&articles).Error; err != nil {
+		return nil, err
+	}
+	for _, article := range articles {
+		add(models.AttachSourceArticle, article.Id, "content", article.Title, article.Content)
+		add(models.AttachSourceArticle, article.Id, "thumbnail", article.Title, article.Thumbnail)
+	}
+
+	// 历史版本数量较多，分批读取
+	var revisions []models.ArticleRevision
+	err := database.DB.Select("id", "title", "content", "thumbnail").
+		FindInBatches(&revisions, 100, func(tx *gorm.DB, batch int) error {
+			for _, revision := range revisions {
+				add(models.AttachSourceRevision, revision.Id, "content", revision.Title, revision.Content)
+				add(models.AttachSourceRevision, revision.Id, "thumbnail", revision.Title, revision.Thumbnail)
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var categories []models.Category
+	if err := database.DB.Select("id", "name", "thumbnail").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		add(models.AttachSourceCategory, category.Id, "thumbnail", category.Name, category.Thumbnail)
+	}
+
+	var tags []models.Tag
+	if err := database.DB.Select("id", "name", "thumbnail").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		add(models.AttachSourceTag, tag.Id, "thumbnail", tag.Name, tag.Thumbnail)
+	}
+
+	var users []models.User
+	if err := database.DB.Select("id", "username", "avatar").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		add(models.AttachSourceUser, user.Id, "avatar", user.Username, user.Avatar)
+	}
+
+	var links []models.Link
+	if err := database.DB.Select("id", "name", "logo").Find(&links).Error; err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		add(models.AttachSourceLink, link.Id, "logo", link.Name, link.Logo)
+	}
+	return usages, nil
+}
+
+// matchAttachURLs 找出文本中引用的附件ID
+func matchAttachURLs(index map[string][]int, text string) []int {
+	if text == "" {
+		return nil
+	}
+	candidates := attachURLPattern.FindAllString(text, -1)
+	candidates = append(candidates, text)
+
+	var ids []int
+	for _, candidate := range candidates {
+		key := attachURLKey(strings.TrimRight(candidate, ".,;:!?"))
+		ids = append(ids, index[key]...)
+	}
+	return ids
+}
+
+// attachURLKey 地址的匹配键，只比较路径部分，忽略域名、查询参数和转义的差异
+func attachURLKey(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
@@ -0,0 +1,178 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/logger"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// linkCheckWorkers 并发检测的链接数量
+const linkCheckWorkers = 5
+
+// LinkChecker 友情链接检测任务，周期性地检测链接是否可访问，连续失败达到阈值后标记为失效
+type LinkChecker struct {
+	interval  time.Duration
+	threshold int
+	client    *http.Client
+	stop      chan struct{}
+	hooks     []func()
+}
+
+// NewLinkChecker 创建友情链接检测任务，threshold为标记失效所需的连续失败次数
+func NewLinkChecker(interval, timeout time.Duration, threshold int) *LinkChecker {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	if threshold <= 0 {
+		threshold = 3
+	}
+	return &LinkChecker{
+		interval:  interval,
+		threshold: threshold,
+		client:    &http.Client{Timeout: timeout},
+		stop:      make(chan struct{}),
+	}
+}
+
+// OnChange 注册链接状态变化后的回调，用于刷新缓存等
+func (l *LinkChecker) OnChange(hook func()) {
+	l.hooks = append(l.hooks, hook)
+}
+
+// Start 在后台启动检测任务
+func (l *LinkChecker) Start() {
+	go func() {
+		ticker := time.NewTicker(l.interval)
+		defer ticker.Stop()
+
+		l.CheckAll()
+		for {
+			select {
+			case <-ticker.C:
+				l.CheckAll()
+			case <-l.stop:
+				return
+			}
+		}
+	}()
+	logger.Info("Link checker started, interval:", l.interval)
+}
+
+// Stop 停止检测任务
+func (l *LinkChecker) Stop() {
+	close(l.stop)
+}
+
+// CheckAll 检测所有友情链接
+func (l *LinkChecker) CheckAll() {
+	if database.DB == nil {
+		return
+	}
+	var links []models.Link
+	database.DB.Find(&links)
+	l.Check(links)
+}
+
+// Check 检测给定的友情链接并保存结果，返回状态发生变化的链接数量
+func (l *LinkChecker) Check(links []models.Link) int {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	changed := 0
+	queue := make(chan *models.Link)
+	for i := 0; i < linkCheckWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range queue {
+				if l.checkLink(link) {
+					mu.Lock()
+					changed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range links {
+		queue <- &links[i]
+	}
+	close(queue)
+	wg.Wait()
+
+	if changed > 0 {
+		logger.Info("Link health changed:", changed)
+		for _, hook := range l.hooks {
+			hook()
+		}
+	}
+	return changed
+}
+
+// checkLink 检测单个链接并保存结果，返回可访问状态是否变化
+func (l *LinkChecker) checkLink(link *models.Link) bool {
+	code, err := l.probe(link.Address)
+	now := time.Now()
+	oldStatus := link.HealthStatus
+
+	link.HealthCode = code
+	link.CheckedAt = &now
+	if err == nil {
+		link.HealthStatus = models.LinkHealthOK
+		link.HealthError = ""
+		link.FailCount = 0
+	} else {
+		link.HealthError = err.Error()
+		if len(link.HealthError) > 512 {
+			link.HealthError = link.HealthError[:512]
+		}
+		link.FailCount++
+		if link.FailCount >= l.threshold {
+			link.HealthStatus = models.LinkHealthDead
+		}
+	}
+
+	if err := database.DB.Model(link).Select("health_status", "health_code", "health_error", "fail_count", "checked_at").
+		Updates(link).Error; err != nil {
+		logger.Error("Failed to save link health:", err)
+	}
+	return link.HealthStatus != oldStatus
+}
+
+// probe 请求链接地址，状态码小于400视为可访问；不支持HEAD请求的站点改用GET请求
+func (l *LinkChecker) probe(address string) (int, error) {
+	code, err := l.request(http.MethodHead, address)
+	if err == nil && (code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented || code == http.StatusForbidden) {
+		code, err = l.request(http.MethodGet, address)
+	}
+	if err != nil {
+		return code, err
+	}
+	if code >= http.StatusBadRequest {
+		return code, fmt.Errorf("HTTP %d", code)
+	}
+	return code, nil
+}
+
+// request 发送请求并返回状态码
+func (l *LinkChecker) request(method, address string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.client.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, address, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; MatutoBlog LinkChecker)")
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
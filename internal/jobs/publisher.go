@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"matuto-blog/internal/database"
+	"matuto-blog/internal/models"
+	"matuto-blog/pkg/logger"
+	"time"
+)
+
+// ArticlePublisher 定时发布任务，周期性地将到期的定时文章切换为已发布
+type ArticlePublisher struct {
+	interval time.Duration
+	stop     chan struct{}
+	hooks    []func(ids []int)
+}
+
+// NewArticlePublisher 创建定时发布任务
+func NewArticlePublisher(interval time.Duration) *ArticlePublisher {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &ArticlePublisher{
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// OnPublish 注册文章发布后的回调，用于刷新缓存等
+func (p *ArticlePublisher) OnPublish(hook func(ids []int)) {
+	p.hooks = append(p.hooks, hook)
+}
+
+// Start 在后台启动定时发布任务
+func (p *ArticlePublisher) Start() {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		// 启动时先处理一次，避免停机期间到期的文章延迟发布
+		p.PublishDue()
+		for {
+			select {
+			case <-ticker.C:
+				p.PublishDue()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+	logger.Info("Article publisher started, interval:", p.interval)
+}
+
+// Stop 停止定时发布任务
+func (p *ArticlePublisher) Stop() {
+	close(p.stop)
+}
+
+// PublishDue 发布所有已到发布时间的定时文章，返回本次发布的文章ID
+func (p *ArticlePublisher) PublishDue() []int {
+	if database.DB == nil {
+		return nil
+	}
+
+	var ids []int
+	database.DB.Model(&models.Article{}).
+		Where("status = ? AND publish_at <= ?", models.ArticleStatusScheduled, time.Now()).
+		Pluck("id", &ids)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// 更新时再次校验状态，避免与后台编辑并发时覆盖新状态
+	if err := database.DB.Model(&models.Article{}).
+		Where("id IN ? AND status = ?", ids, models.ArticleStatusScheduled).
+		Update("status", models.ArticleStatusPublished).Error; err != nil {
+		logger.Error("Failed to publish scheduled articles:", err)
+		return nil
+	}
+
+	logger.Info("Published scheduled articles:", ids)
+	for _, hook := range p.hooks {
+		hook(ids)
+	}
+	return ids
+}
@@ -1,26 +1,35 @@
 package models
 
+import (
+	"matuto-blog/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
 // Article 文章模型
 type Article struct {
 	BaseModel
-	Title           string `json:"title" gorm:"size:256;comment:文章标题"`
-	Content         string `json:"content" gorm:"type:longtext;not null;comment:文章内容"`
-	ParseContent    string `json:"parseContent" gorm:"type:longtext;not null;comment:解析后的文章内容"`
-	ContentModel    string `json:"contentModel" gorm:"size:32;comment:文章内容类型:html/markdown"`
-	Type            string `json:"type" gorm:"size:32;comment:文章类型:article文章,page页面"`
-	Summary         string `json:"summary" gorm:"size:1024;comment:文章摘要"`
-	MetaKeywords    string `json:"metaKeywords" gorm:"size:512;comment:SEO关键字"`
-	MetaDescription string `json:"metaDescription" gorm:"size:512;comment:SEO描述"`
-	Thumbnail       string `json:"thumbnail" gorm:"size:256;comment:缩略图"`
-	Slug            string `json:"slug" gorm:"size:128;index;comment:slug"`
-	IsTop           int8   `json:"isTop" gorm:"default:0;comment:是否置顶0:否,1:是"`
-	Status          int8   `json:"status" gorm:"default:0;comment:状态0:已发布,1:草稿"`
-	ViewCount       int    `json:"viewCount" gorm:"default:0;comment:访问量"`
-	GreatCount      int    `json:"greatCount" gorm:"default:0;comment:点赞量"`
-	IsComment       int8   `json:"isComment" gorm:"default:1;comment:是否允许评论0:否,1是"`
-	Flag            string `json:"flag" gorm:"size:256;comment:标识"`
-	Template        string `json:"template" gorm:"size:256;comment:模板"`
-	Visibility      int8   `json:"visibility" gorm:"default:0;comment:是否可见, 0是, 1否"`
+	Title           string     `json:"title" gorm:"size:256;comment:文章标题"`
+	Content         string     `json:"content" gorm:"type:longtext;not null;comment:文章内容"`
+	ParseContent    string     `json:"parseContent" gorm:"type:longtext;not null;comment:解析后的文章内容"`
+	ContentModel    string     `json:"contentModel" gorm:"size:32;comment:文章内容类型:html/markdown"`
+	Type            string     `json:"type" gorm:"size:32;comment:文章类型:article文章,page页面"`
+	Summary         string     `json:"summary" gorm:"size:1024;comment:文章摘要"`
+	MetaKeywords    string     `json:"metaKeywords" gorm:"size:512;comment:SEO关键字"`
+	MetaDescription string     `json:"metaDescription" gorm:"size:512;comment:SEO描述"`
+	Thumbnail       string     `json:"thumbnail" gorm:"size:256;comment:缩略图"`
+	Slug            string     `json:"slug" gorm:"size:128;index;comment:slug"`
+	IsTop           int8       `json:"isTop" gorm:"default:0;comment:是否置顶0:否,1:是"`
+	Status          int8       `json:"status" gorm:"default:0;comment:状态0:已发布,1:草稿,2:定时发布"`
+	ViewCount       int        `json:"viewCount" gorm:"default:0;comment:访问量"`
+	GreatCount      int        `json:"greatCount" gorm:"default:0;comment:点赞量"`
+	IsComment       int8       `json:"isComment" gorm:"default:1;comment:是否允许评论0:否,1是"`
+	Flag            string     `json:"flag" gorm:"size:256;comment:标识"`
+	Template        string     `json:"template" gorm:"size:256;comment:模板"`
+	Visibility      int8       `json:"visibility" gorm:"default:0;comment:是否可见, 0是, 1否"`
+	PublishAt       *time.Time `json:"publishAt" gorm:"index;comment:发布时间"`
+	UserId          *int       `json:"userId" gorm:"index;comment:作者ID"`
 }
 
 // TableName 指定表名
@@ -38,6 +47,7 @@ const (
 const (
 	ArticleStatusPublished = 0 // 已发布
 	ArticleStatusDraft     = 1 // 草稿
+	ArticleStatusScheduled = 2 // 定时发布
 )
 
 // ContentModel 内容模型常量
@@ -56,6 +66,16 @@ func (a *Article) IsDraft() bool {
 	return a.Status == ArticleStatusDraft
 }
 
+// IsScheduled 检查文章是否为定时发布
+func (a *Article) IsScheduled() bool {
+	return a.Status == ArticleStatusScheduled
+}
+
+// IsDue 检查定时发布的文章是否已到发布时间
+func (a *Article) IsDue(now time.Time) bool {
+	return a.IsScheduled() && a.PublishAt != nil && !a.PublishAt.After(now)
+}
+
 // AllowComment 检查文章是否允许评论
 func (a *Article) AllowComment() bool {
 	return a.IsComment == 1
@@ -70,3 +90,24 @@ func (a *Article) IsVisible() bool {
 func (a *Article) IsTopArticle() bool {
 	return a.IsTop == 1
 }
+
+// RenderedContent 获取渲染后的HTML内容，优先使用已解析的内容
+func (a *Article) RenderedContent() string {
+	if a.ParseContent != "" {
+		return a.ParseContent
+	}
+	if a.ContentModel == ContentModelHTML {
+		return a.Content
+	}
+	rendered, err := utils.RenderMarkdown(a.Content)
+	if err != nil {
+		return a.Content
+	}
+	return rendered
+}
+
+// ScopePublished 查询前台可见的已发布文章，包含已到发布时间但尚未被后台任务处理的定时文章
+func ScopePublished(db *gorm.DB) *gorm.DB {
+	return db.Where("m_article.status = ? OR (m_article.status = ? AND m_article.publish_at <= ?)",
+		ArticleStatusPublished, ArticleStatusScheduled, time.Now())
+}
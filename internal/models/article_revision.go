@@ -0,0 +1,93 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ArticleRevision 文章历史版本模型
+type ArticleRevision struct {
+	BaseModel
+	ArticleId       int    `json:"articleId" gorm:"not null;index;comment:文章id"`
+	Version         int    `json:"version" gorm:"not null;comment:版本号"`
+	Title           string `json:"title" gorm:"size:256;comment:文章标题"`
+	Content         string `json:"content" gorm:"type:longtext;not null;comment:文章内容"`
+	ParseContent    string `json:"parseContent" gorm:"type:longtext;not null;comment:解析后的文章内容"`
+	ContentModel    string `json:"contentModel" gorm:"size:32;comment:文章内容类型:html/markdown"`
+	Summary         string `json:"summary" gorm:"size:1024;comment:文章摘要"`
+	MetaKeywords    string `json:"metaKeywords" gorm:"size:512;comment:SEO关键字"`
+	MetaDescription string `json:"metaDescription" gorm:"size:512;comment:SEO描述"`
+	Thumbnail       string `json:"thumbnail" gorm:"size:256;comment:缩略图"`
+	Slug            string `json:"slug" gorm:"size:128;comment:slug"`
+	Status          int8   `json:"status" gorm:"default:0;comment:保存时的文章状态"`
+	CategoryIds     string `json:"categoryIds" gorm:"size:512;comment:分类id列表,逗号分隔"`
+	TagIds          string `json:"tagIds" gorm:"size:512;comment:标签id列表,逗号分隔"`
+	Remark          string `json:"remark" gorm:"size:256;comment:版本说明"`
+}
+
+// TableName 指定表名
+func (ArticleRevision) TableName() string {
+	return "m_article_revision"
+}
+
+// NewArticleRevision 根据文章当前内容创建版本快照
+func NewArticleRevision(article *Article, categoryIds, tagIds []int) *ArticleRevision {
+	return &ArticleRevision{
+		ArticleId:       article.Id,
+		Title:           article.Title,
+		Content:         article.Content,
+		ParseContent:    article.ParseContent,
+		ContentModel:    article.ContentModel,
+		Summary:         article.Summary,
+		MetaKeywords:    article.MetaKeywords,
+		MetaDescription: article.MetaDescription,
+		Thumbnail:       article.Thumbnail,
+		Slug:            article.Slug,
+		Status:          article.Status,
+		CategoryIds:     joinIds(categoryIds),
+		TagIds:          joinIds(tagIds),
+	}
+}
+
+// GetCategoryIds 获取版本保存时的分类id列表
+func (r *ArticleRevision) GetCategoryIds() []int {
+	return splitIds(r.CategoryIds)
+}
+
+// GetTagIds 获取版本保存时的标签id列表
+func (r *ArticleRevision) GetTagIds() []int {
+	return splitIds(r.TagIds)
+}
+
+// ApplyTo 将版本内容回写到文章
+func (r *ArticleRevision) ApplyTo(article *Article) {
+	article.Title = r.Title
+	article.Content = r.Content
+	article.ParseContent = r.ParseContent
+	article.ContentModel = r.ContentModel
+	article.Summary = r.Summary
+	article.MetaKeywords = r.MetaKeywords
+	article.MetaDescription = r.MetaDescription
+	article.Thumbnail = r.Thumbnail
+	article.Slug = r.Slug
+}
+
+// joinIds 将id列表拼接为逗号分隔字符串
+func joinIds(ids []int) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.Itoa(id))
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitIds 将逗号分隔字符串解析为id列表
+func splitIds(value string) []int {
+	ids := make([]int, 0)
+	for _, part := range strings.Split(value, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
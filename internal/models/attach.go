@@ -10,12 +10,24 @@ import (
 // Attach 附件模型
 type Attach struct {
 	BaseModel
-	Name   string `json:"name" gorm:"size:256;not null;comment:附件名"`
-	Remark string `json:"remark" gorm:"size:512;comment:附件描述"`
-	Path   string `json:"path" gorm:"size:512;not null;comment:附件路径"`
-	Flag   string `json:"flag" gorm:"size:256;comment:标识"`
-	Type   string `json:"type" gorm:"size:32;index;comment:文件类型"`
-	URL    string `json:"url" gorm:"size:512;not null;comment:访问路径"`
+	Name     string `json:"name" gorm:"size:256;not null;comment:附件名"`
+	Remark   string `json:"remark" gorm:"size:512;comment:附件描述"`
+	Path     string `json:"path" gorm:"size:512;not null;comment:附件路径"`
+	Flag     string `json:"flag" gorm:"size:256;comment:标识"`
+	Type     string `json:"type" gorm:"size:32;index;comment:文件类型"`
+	URL      string `json:"url" gorm:"size:512;not null;comment:访问路径"`
+	Storage  string `json:"storage" gorm:"size:32;index;default:local;comment:存储类型:local/aliyun/tencent"`
+	Size     int64  `json:"size" gorm:"default:0;comment:文件大小(字节)"`
+	MimeType string `json:"mimeType" gorm:"size:128;comment:MIME类型"`
+	Hash     string `json:"hash" gorm:"size:64;index;comment:文件SHA-256哈希"`
+	BlobId   *int   `json:"blobId" gorm:"index;comment:文件ID，内容相同的附件共用同一个文件"`
+	Width    int    `json:"width" gorm:"default:0;comment:图片宽度"`
+	Height   int    `json:"height" gorm:"default:0;comment:图片高度"`
+
+	UnusedSince *time.Time `json:"unusedSince" gorm:"comment:不再被引用的时间，为空时从上传时间算起"`
+
+	Variants []AttachVariant `json:"variants,omitempty" gorm:"-"` // 图片的派生尺寸
+	Usages   []AttachUsage   `json:"usages,omitempty" gorm:"-"`   // 附件的使用位置
 }
 
 // TableName 指定表名
@@ -31,11 +43,11 @@ const (
 	AttachTypeAudio = "audio" // 音频
 )
 
-// StorageType 存储类型常量
+// StorageType 存储类型常量，与存储适配器的GetStorageType一致
 const (
-	StorageTypeLocal = 0 // 本地存储
-	StorageTypeOSS   = 1 // OSS存储
-	StorageTypeCOS   = 2 // COS存储
+	StorageTypeLocal = "local"   // 本地存储
+	StorageTypeOSS   = "aliyun"  // OSS存储
+	StorageTypeCOS   = "tencent" // COS存储
 )
 
 // 支持的图片格式
@@ -64,8 +76,32 @@ const (
 
 // GetSizeString 获取文件大小的可读字符串
 func (a *Attach) GetSizeString() string {
-	// Note: 由于数据库中没有存储文件大小，这里无法实现真实的大小显示
-	return "未知大小"
+	if a.Size <= 0 {
+		return "未知大小"
+	}
+	return getSizeString(a.Size)
+}
+
+// GetStorage 获取存储类型，兼容未记录存储类型的旧附件
+func (a *Attach) GetStorage() string {
+	if a.Storage == "" {
+		return StorageTypeLocal
+	}
+	return a.Storage
+}
+
+// AttachTypeFromMime 根据MIME类型判断附件类型
+func AttachTypeFromMime(mimeType string) string {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return AttachTypeImage
+	case strings.HasPrefix(mimeType, "video/"):
+		return AttachTypeVideo
+	case strings.HasPrefix(mimeType, "audio/"):
+		return AttachTypeAudio
+	default:
+		return AttachTypeFile
+	}
 }
 
 // ValidateImageFile 验证图片文件
@@ -0,0 +1,38 @@
+package models
+
+// AttachBlob 附件文件，同一存储中内容相同的附件共用一个文件，RefCount为引用该文件的附件数量
+type AttachBlob struct {
+	BaseModel
+	Storage  string `json:"storage" gorm:"size:32;not null;uniqueIndex:idx_attach_blob_storage_hash;comment:存储类型"`
+	Hash     string `json:"hash" gorm:"size:64;not null;uniqueIndex:idx_attach_blob_storage_hash;comment:文件SHA-256哈希"`
+	Path     string `json:"path" gorm:"size:512;not null;comment:文件路径"`
+	URL      string `json:"url" gorm:"size:512;not null;comment:访问路径"`
+	Size     int64  `json:"size" gorm:"default:0;comment:文件大小(字节)"`
+	MimeType string `json:"mimeType" gorm:"size:128;comment:MIME类型"`
+	RefCount int    `json:"refCount" gorm:"default:0;comment:引用数量"`
+	Width    int    `json:"width" gorm:"default:0;comment:图片宽度，未处理或不是图片时为0"`
+	Height   int    `json:"height" gorm:"default:0;comment:图片高度"`
+}
+
+// TableName 指定表名
+func (AttachBlob) TableName() string {
+	return "m_attach_blob"
+}
+
+// AttachVariant 图片文件的派生尺寸，如缩略图和WebP版本，随文件一起删除
+type AttachVariant struct {
+	BaseModel
+	BlobId int    `json:"blobId" gorm:"not null;uniqueIndex:idx_attach_variant_blob_name_format;comment:文件ID"`
+	Name   string `json:"name" gorm:"size:32;not null;uniqueIndex:idx_attach_variant_blob_name_format;comment:尺寸名称"`
+	Format string `json:"format" gorm:"size:16;not null;uniqueIndex:idx_attach_variant_blob_name_format;comment:图片格式"`
+	Width  int    `json:"width" gorm:"comment:宽度"`
+	Height int    `json:"height" gorm:"comment:高度"`
+	Path   string `json:"path" gorm:"size:512;not null;comment:文件路径"`
+	URL    string `json:"url" gorm:"size:512;not null;comment:访问路径"`
+	Size   int64  `json:"size" gorm:"default:0;comment:文件大小(字节)"`
+}
+
+// TableName 指定表名
+func (AttachVariant) TableName() string {
+	return "m_attach_variant"
+}
@@ -0,0 +1,26 @@
+package models
+
+// AttachUsage 附件的使用位置，由附件引用扫描生成，每次扫描时整体重建
+type AttachUsage struct {
+	BaseModel
+	AttachId   int    `json:"attachId" gorm:"not null;uniqueIndex:idx_attach_usage_source;comment:附件ID"`
+	SourceType string `json:"sourceType" gorm:"size:32;not null;uniqueIndex:idx_attach_usage_source;index:idx_attach_usage_owner;comment:引用来源类型"`
+	SourceId   int    `json:"sourceId" gorm:"not null;uniqueIndex:idx_attach_usage_source;index:idx_attach_usage_owner;comment:引用来源ID"`
+	Field      string `json:"field" gorm:"size:32;not null;uniqueIndex:idx_attach_usage_source;comment:引用字段"`
+	Title      string `json:"title" gorm:"size:256;comment:引用来源标题"`
+}
+
+// TableName 指定表名
+func (AttachUsage) TableName() string {
+	return "m_attach_usage"
+}
+
+// 附件引用来源类型
+const (
+	AttachSourceArticle  = "article"  // 文章
+	AttachSourceCategory = "category" // 分类
+	AttachSourceTag      = "tag"      // 标签
+	AttachSourceUser     = "user"     // 用户
+	AttachSourceRevision = "revision" // 文章历史版本
+	AttachSourceLink     = "link"     // 友情链接
+)
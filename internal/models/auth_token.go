@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// RefreshToken 刷新令牌，只保存令牌的哈希；每次刷新都会轮换，同一次登录产生的令牌属于同一个会话
+type RefreshToken struct {
+	BaseModel
+	UserId          int        `json:"userId" gorm:"not null;index;comment:用户ID"`
+	SessionId       string     `json:"sessionId" gorm:"size:32;not null;index;comment:登录会话ID"`
+	TokenHash       string     `json:"-" gorm:"size:64;not null;uniqueIndex;comment:令牌SHA-256哈希"`
+	AccessJti       string     `json:"-" gorm:"size:32;comment:与该令牌一起签发的访问令牌ID"`
+	AccessExpiresAt time.Time  `json:"-" gorm:"comment:访问令牌过期时间"`
+	ExpiresAt       time.Time  `json:"expiresAt" gorm:"not null;comment:过期时间"`
+	RevokedAt       *time.Time `json:"revokedAt" gorm:"comment:吊销或被轮换的时间"`
+	UserAgent       string     `json:"userAgent" gorm:"size:256;comment:客户端"`
+	IP              string     `json:"ip" gorm:"size:64;comment:IP地址"`
+}
+
+// TableName 指定表名
+func (RefreshToken) TableName() string {
+	return "m_refresh_token"
+}
+
+// RevokedToken 已吊销的访问令牌，访问令牌过期后记录即可删除
+type RevokedToken struct {
+	BaseModel
+	Jti       string    `json:"jti" gorm:"size:32;not null;uniqueIndex;comment:访问令牌ID"`
+	UserId    int       `json:"userId" gorm:"index;comment:用户ID"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"not null;index;comment:访问令牌过期时间"`
+}
+
+// TableName 指定表名
+func (RevokedToken) TableName() string {
+	return "m_revoked_token"
+}
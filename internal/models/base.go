@@ -13,6 +13,17 @@ type BaseModel struct {
 	UpdatedBy int       `json:"updatedBy" gorm:"column:updated_by;comment:更新人"`
 }
 
+// SetCreator 记录创建人，新建的记录同时记为最后修改人
+func (m *BaseModel) SetCreator(userId int) {
+	m.CreatedBy = userId
+	m.UpdatedBy = userId
+}
+
+// SetUpdater 记录最后修改人
+func (m *BaseModel) SetUpdater(userId int) {
+	m.UpdatedBy = userId
+}
+
 // 状态常量定义
 const (
 	StatusDisabled = 0 // 禁用
@@ -11,6 +11,7 @@ type Category struct {
 	Slug            string `json:"slug" gorm:"size:128;index;comment:slug"`
 	MetaDescription string `json:"meta_description" gorm:"size:256;comment:SEO描述内容"`
 	Status          int    `json:"status" gorm:"default:0;comment:状态0:正常,1禁用"`
+	Template        string `json:"template" gorm:"size:256;comment:分类下文章的默认模板"`
 }
 
 // TableName 指定表名
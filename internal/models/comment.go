@@ -8,7 +8,7 @@ type Comment struct {
 	TopPid    int    `json:"topPId" gorm:"default:-1;comment:顶层父级id"`
 	UserId    *int   `json:"userId" gorm:"comment:用户ID"`
 	Content   string `json:"content" gorm:"size:2048;comment:评论内容"`
-	Status    int    `json:"status" gorm:"default:0;comment:状态:0正常,1:待审核"`
+	Status    int    `json:"status" gorm:"default:0;comment:状态:0待审核,1已通过,2已拒绝"`
 	Avatar    string `json:"avatar" gorm:"size:256;comment:头像"`
 	Website   string `json:"website" gorm:"size:256;comment:网站地址"`
 	Email     string `json:"email" gorm:"size:256;comment:邮箱"`
@@ -24,13 +24,14 @@ func (Comment) TableName() string {
 
 // CommentStatus 评论状态常量
 const (
-	CommentStatusActive  = 0 // 正常
-	CommentStatusPending = 1 // 待审核
+	CommentStatusPending  = 0 // 待审核
+	CommentStatusApproved = 1 // 已通过
+	CommentStatusRejected = 2 // 已拒绝
 )
 
-// IsActive 检查评论是否激活
-func (c *Comment) IsActive() bool {
-	return c.Status == CommentStatusActive
+// IsApproved 检查评论是否已通过审核
+func (c *Comment) IsApproved() bool {
+	return c.Status == CommentStatusApproved
 }
 
 // IsRoot 检查是否为根评论
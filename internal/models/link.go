@@ -1,15 +1,46 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 // Link 友情链接模型
 type Link struct {
 	BaseModel
-	Name    string `json:"name" gorm:"size:256;not null;comment:网站名"`
-	Logo    string `json:"logo" gorm:"size:256;comment:网站logo"`
-	Desc    string `json:"desc" gorm:"size:512;comment:网站描述"`
-	Address string `json:"address" gorm:"size:256;not null;comment:网站地址"`
+	Name         string     `json:"name" gorm:"size:256;not null;comment:网站名"`
+	Logo         string     `json:"logo" gorm:"size:256;comment:网站logo"`
+	Desc         string     `json:"desc" gorm:"size:512;comment:网站描述"`
+	Address      string     `json:"address" gorm:"size:256;not null;comment:网站地址"`
+	Group        string     `json:"group" gorm:"column:group_name;size:64;index;comment:分组"`
+	Sort         int        `json:"sort" gorm:"default:0;comment:排序,越小越靠前"`
+	IsVisible    int8       `json:"isVisible" gorm:"default:1;comment:是否显示0:否,1:是"`
+	HealthStatus int8       `json:"healthStatus" gorm:"default:0;comment:可访问状态0:未检测,1:正常,2:失效"`
+	HealthCode   int        `json:"healthCode" gorm:"default:0;comment:最近一次检测的HTTP状态码"`
+	HealthError  string     `json:"healthError" gorm:"size:512;comment:最近一次检测的错误信息"`
+	FailCount    int        `json:"failCount" gorm:"default:0;comment:连续检测失败次数"`
+	CheckedAt    *time.Time `json:"checkedAt" gorm:"comment:最近一次检测时间"`
 }
 
 // TableName 指定表名
 func (Link) TableName() string {
 	return "m_link"
 }
+
+// LinkHealthStatus 友情链接可访问状态常量
+const (
+	LinkHealthUnknown = 0 // 未检测
+	LinkHealthOK      = 1 // 正常
+	LinkHealthDead    = 2 // 失效
+)
+
+// IsDead 检查友情链接是否已失效
+func (l *Link) IsDead() bool {
+	return l.HealthStatus == LinkHealthDead
+}
+
+// ScopeVisibleLinks 前台显示的友情链接，按分组和排序排列
+func ScopeVisibleLinks(db *gorm.DB) *gorm.DB {
+	return db.Where("is_visible = ?", 1).Order("group_name ASC, sort ASC, id ASC")
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// 登录结果
+const (
+	LoginResultSuccess        = "success"         // 登录成功
+	LoginResultBadCredentials = "bad_credentials" // 账号或密码错误
+	LoginResultDisabled       = "disabled"        // 账户已禁用
+	LoginResultLocked         = "locked"          // 失败次数过多被锁定
+	LoginResultTwoFactor      = "2fa_required"    // 密码正确，等待两步验证
+	LoginResultBadTwoFactor   = "bad_2fa"         // 两步验证码错误
+)
+
+// LoginLog 登录审计日志，记录每一次成功和失败的登录
+type LoginLog struct {
+	Id        int       `json:"id" gorm:"column:id;primaryKey;autoIncrement;comment:主键ID"`
+	UserId    int       `json:"userId" gorm:"index;comment:用户ID，账号不存在时为0"`
+	Account   string    `json:"account" gorm:"size:100;index;comment:登录时提交的账号"`
+	IP        string    `json:"ip" gorm:"size:64;index;comment:IP地址"`
+	UserAgent string    `json:"userAgent" gorm:"size:256;comment:客户端"`
+	Success   bool      `json:"success" gorm:"not null;default:false;comment:是否成功"`
+	Result    string    `json:"result" gorm:"size:32;not null;comment:登录结果"`
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at;autoCreateTime;index;comment:登录时间"`
+}
+
+// TableName 指定表名
+func (LoginLog) TableName() string {
+	return "m_login_log"
+}
+
+// LoginThrottle 账号或IP的登录失败状态，用于重启后保留锁定
+type LoginThrottle struct {
+	Id          int        `json:"id" gorm:"column:id;primaryKey;autoIncrement;comment:主键ID"`
+	Key         string     `json:"key" gorm:"column:throttle_key;size:191;not null;uniqueIndex;comment:account:账号或ip:地址"`
+	Failures    int        `json:"failures" gorm:"not null;default:0;comment:连续失败次数"`
+	LastFailure time.Time  `json:"lastFailure" gorm:"comment:最近一次失败时间"`
+	LockedUntil *time.Time `json:"lockedUntil" gorm:"comment:锁定截止时间"`
+	ExpiresAt   time.Time  `json:"expiresAt" gorm:"not null;index;comment:过期时间"`
+}
+
+// TableName 指定表名
+func (LoginThrottle) TableName() string {
+	return "m_login_throttle"
+}
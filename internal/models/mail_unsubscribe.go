@@ -0,0 +1,25 @@
+package models
+
+import "strconv"
+
+// MailUnsubscribe 邮件退订记录，记录邮箱不再接收某个会话的通知
+type MailUnsubscribe struct {
+	BaseModel
+	Email  string `json:"email" gorm:"size:256;not null;uniqueIndex:idx_mail_unsubscribe_email_thread;comment:邮箱"`
+	Thread string `json:"thread" gorm:"size:64;not null;uniqueIndex:idx_mail_unsubscribe_email_thread;comment:会话标识"`
+}
+
+// TableName 指定表名
+func (MailUnsubscribe) TableName() string {
+	return "m_mail_unsubscribe"
+}
+
+// ArticleThread 文章新评论通知的会话标识
+func ArticleThread(articleId int) string {
+	return "article:" + strconv.Itoa(articleId)
+}
+
+// CommentThread 评论回复通知的会话标识，以顶层评论区分
+func CommentThread(topCommentId int) string {
+	return "comment:" + strconv.Itoa(topCommentId)
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SchemaMigration 已执行的数据迁移，每个迁移只执行一次
+type SchemaMigration struct {
+	Version   string    `json:"version" gorm:"column:version;size:64;primaryKey;comment:迁移版本"`
+	AppliedAt time.Time `json:"appliedAt" gorm:"column:applied_at;autoCreateTime;comment:执行时间"`
+}
+
+// TableName 指定表名
+func (SchemaMigration) TableName() string {
+	return "m_schema_migration"
+}
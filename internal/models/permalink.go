@@ -0,0 +1,153 @@
+package models
+
+import (
+	"matuto-blog/pkg/utils"
+	"strconv"
+)
+
+// 固定链接类型常量
+const (
+	PermalinkArticle  = "article"
+	PermalinkPage     = "page"
+	PermalinkCategory = "category"
+	PermalinkTag      = "tag"
+)
+
+// PermalinkConfig 固定链接规则配置
+type PermalinkConfig struct {
+	Article  string
+	Page     string
+	Category string
+	Tag      string
+}
+
+// DefaultPermalinkConfig 默认固定链接规则
+var DefaultPermalinkConfig = PermalinkConfig{
+	Article:  "/article/{slug}",
+	Page:     "/{slug}",
+	Category: "/category/{slug}",
+	Tag:      "/tag/{slug}",
+}
+
+// permalinkPatterns 按匹配顺序排列的固定链接规则
+var permalinkPatterns = mustCompilePermalinks(DefaultPermalinkConfig)
+
+// permalinkEntry 固定链接规则条目
+type permalinkEntry struct {
+	kind    string
+	pattern *utils.PermalinkPattern
+}
+
+// SetPermalinkPatterns 设置固定链接规则，未配置的类型使用默认规则
+func SetPermalinkPatterns(cfg PermalinkConfig) error {
+	if cfg.Article == "" {
+		cfg.Article = DefaultPermalinkConfig.Article
+	}
+	if cfg.Page == "" {
+		cfg.Page = DefaultPermalinkConfig.Page
+	}
+	if cfg.Category == "" {
+		cfg.Category = DefaultPermalinkConfig.Category
+	}
+	if cfg.Tag == "" {
+		cfg.Tag = DefaultPermalinkConfig.Tag
+	}
+
+	patterns, err := compilePermalinks(cfg)
+	if err != nil {
+		return err
+	}
+	permalinkPatterns = patterns
+	return nil
+}
+
+// MatchPermalink 根据路径匹配固定链接规则，返回类型及占位符的值
+func MatchPermalink(path string) (string, map[string]string, bool) {
+	for _, entry := range permalinkPatterns {
+		if values, ok := entry.pattern.Match(path); ok {
+			return entry.kind, values, true
+		}
+	}
+	return "", nil, false
+}
+
+// Permalink 获取文章的固定链接路径
+func (a Article) Permalink() string {
+	kind := PermalinkArticle
+	if a.Type == ArticleTypePage {
+		kind = PermalinkPage
+	}
+
+	date := a.CreatedAt
+	if a.PublishAt != nil {
+		date = *a.PublishAt
+	}
+	return buildPermalink(kind, map[string]string{
+		"id":    strconv.Itoa(a.Id),
+		"slug":  slugOrId(a.Slug, a.Id),
+		"year":  date.Format("2006"),
+		"month": date.Format("01"),
+		"day":   date.Format("02"),
+	})
+}
+
+// Permalink 获取分类的固定链接路径
+func (c Category) Permalink() string {
+	return buildPermalink(PermalinkCategory, map[string]string{
+		"id":   strconv.Itoa(c.Id),
+		"slug": slugOrId(c.Slug, c.Id),
+	})
+}
+
+// Permalink 获取标签的固定链接路径
+func (t Tag) Permalink() string {
+	return buildPermalink(PermalinkTag, map[string]string{
+		"id":   strconv.Itoa(t.Id),
+		"slug": slugOrId(t.Slug, t.Id),
+	})
+}
+
+// buildPermalink 按类型生成固定链接
+func buildPermalink(kind string, values map[string]string) string {
+	for _, entry := range permalinkPatterns {
+		if entry.kind == kind {
+			return entry.pattern.Build(values)
+		}
+	}
+	return "/"
+}
+
+// slugOrId 历史数据没有slug时使用id代替
+func slugOrId(slug string, id int) string {
+	if slug == "" {
+		return strconv.Itoa(id)
+	}
+	return slug
+}
+
+// compilePermalinks 编译固定链接规则，文章优先于页面匹配
+func compilePermalinks(cfg PermalinkConfig) ([]permalinkEntry, error) {
+	var entries []permalinkEntry
+	for _, item := range []struct{ kind, raw string }{
+		{PermalinkArticle, cfg.Article},
+		{PermalinkCategory, cfg.Category},
+		{PermalinkTag, cfg.Tag},
+		{PermalinkPage, cfg.Page},
+	} {
+		pattern, err := utils.CompilePermalink(item.raw)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, permalinkEntry{kind: item.kind, pattern: pattern})
+	}
+	return entries, nil
+}
+
+// mustCompilePermalinks 编译默认固定链接规则
+func mustCompilePermalinks(cfg PermalinkConfig) []permalinkEntry {
+	entries, err := compilePermalinks(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return entries
+}
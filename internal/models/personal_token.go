@@ -0,0 +1,42 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// PersonalTokenPrefix 个人访问令牌的前缀，用于和JWT区分，也便于密钥扫描工具识别
+const PersonalTokenPrefix = "mbt_"
+
+// PersonalToken 个人访问令牌，用于脚本和CI调用API，只保存令牌的哈希
+type PersonalToken struct {
+	Id         int        `json:"id" gorm:"column:id;primaryKey;autoIncrement;comment:主键ID"`
+	UserId     int        `json:"userId" gorm:"not null;index;comment:用户ID"`
+	Name       string     `json:"name" gorm:"size:100;not null;comment:令牌名称"`
+	TokenHint  string     `json:"tokenHint" gorm:"size:16;comment:令牌开头几位，用于辨认"`
+	TokenHash  string     `json:"-" gorm:"size:64;not null;uniqueIndex;comment:令牌SHA-256哈希"`
+	Scopes     string     `json:"-" gorm:"size:512;not null;comment:权限范围，逗号分隔"`
+	ExpiresAt  time.Time  `json:"expiresAt" gorm:"not null;comment:过期时间"`
+	LastUsedAt *time.Time `json:"lastUsedAt" gorm:"comment:最近使用时间"`
+	LastUsedIP string     `json:"lastUsedIp" gorm:"size:64;comment:最近使用的IP"`
+	RevokedAt  *time.Time `json:"revokedAt" gorm:"comment:吊销时间"`
+	CreatedAt  time.Time  `json:"createdAt" gorm:"column:created_at;autoCreateTime;comment:创建时间"`
+}
+
+// TableName 指定表名
+func (PersonalToken) TableName() string {
+	return "m_personal_token"
+}
+
+// ScopeList 令牌的权限范围
+func (t *PersonalToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// Active 令牌在指定时间是否可用
+func (t *PersonalToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,56 @@
+package models
+
+// 用户角色
+const (
+	RoleAdmin       = "admin"       // 管理员：全部权限
+	RoleEditor      = "editor"      // 编辑：管理所有文章、分类标签、评论、附件和友情链接
+	RoleAuthor      = "author"      // 作者：撰写并发布自己的文章，上传附件
+	RoleContributor = "contributor" // 投稿者：只能撰写自己的草稿，由编辑审核发布
+)
+
+// 权限
+const (
+	PermArticleWrite      = "article.write"       // 撰写文章，只能修改自己的文章
+	PermArticlePublish    = "article.publish"     // 发布或定时发布文章
+	PermArticleEditOthers = "article.edit_others" // 查看、修改和删除他人的文章
+	PermTaxonomyManage    = "taxonomy.manage"     // 管理分类和标签
+	PermCommentManage     = "comment.manage"      // 审核和删除评论
+	PermAttachUpload      = "attach.upload"       // 上传附件，只能删除自己上传的附件
+	PermAttachManage      = "attach.manage"       // 删除他人的附件，回收闲置附件
+	PermLinkManage        = "link.manage"         // 管理友情链接
+	PermSiteManage        = "site.manage"         // 站点维护，如重建搜索索引
+	PermUserManage        = "user.manage"         // 管理用户和角色
+)
+
+// AllPermissions 所有权限
+var AllPermissions = []string{
+	PermArticleWrite, PermArticlePublish, PermArticleEditOthers, PermTaxonomyManage, PermCommentManage,
+	PermAttachUpload, PermAttachManage, PermLinkManage, PermSiteManage, PermUserManage,
+}
+
+// RolePermissions 各角色拥有的权限
+var RolePermissions = map[string][]string{
+	RoleAdmin: AllPermissions,
+	RoleEditor: {
+		PermArticleWrite, PermArticlePublish, PermArticleEditOthers, PermTaxonomyManage, PermCommentManage,
+		PermAttachUpload, PermAttachManage, PermLinkManage,
+	},
+	RoleAuthor:      {PermArticleWrite, PermArticlePublish, PermAttachUpload},
+	RoleContributor: {PermArticleWrite},
+}
+
+// ValidRole 是否为有效的角色
+func ValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// RoleHasPermission 角色是否拥有指定权限
+func RoleHasPermission(role, permission string) bool {
+	for _, p := range RolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
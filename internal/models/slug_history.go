@@ -0,0 +1,21 @@
+package models
+
+// SlugHistory slug历史记录模型，用于将旧链接永久重定向到新链接
+type SlugHistory struct {
+	BaseModel
+	Type     string `json:"type" gorm:"size:32;not null;index:idx_slug_history_type_slug;comment:类型:article/category/tag"`
+	Slug     string `json:"slug" gorm:"size:128;not null;index:idx_slug_history_type_slug;comment:旧slug"`
+	TargetId int    `json:"targetId" gorm:"not null;index;comment:目标id"`
+}
+
+// TableName 指定表名
+func (SlugHistory) TableName() string {
+	return "m_slug_history"
+}
+
+// SlugType slug历史记录类型常量，文章和页面共用article类型
+const (
+	SlugTypeArticle  = "article"  // 文章及页面
+	SlugTypeCategory = "category" // 分类
+	SlugTypeTag      = "tag"      // 标签
+)
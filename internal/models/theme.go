@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ThemeSetting 主题的设置值和启用状态，同一时间只有一个主题处于启用状态
+type ThemeSetting struct {
+	Id        int       `json:"id" gorm:"column:id;primaryKey;autoIncrement;comment:主键ID"`
+	Theme     string    `json:"theme" gorm:"size:64;not null;uniqueIndex;comment:主题目录名"`
+	Active    bool      `json:"active" gorm:"not null;default:false;comment:是否为当前主题"`
+	Settings  string    `json:"settings" gorm:"type:text;comment:主题设置，JSON格式"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"column:updated_at;autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 指定表名
+func (ThemeSetting) TableName() string {
+	return "m_theme_setting"
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RecoveryCode 两步验证的一次性恢复码，丢失身份验证器时代替验证码使用，只保存哈希
+type RecoveryCode struct {
+	Id        int        `json:"id" gorm:"column:id;primaryKey;autoIncrement;comment:主键ID"`
+	UserId    int        `json:"userId" gorm:"not null;index;comment:用户ID"`
+	CodeHash  string     `json:"-" gorm:"size:128;not null;comment:恢复码哈希"`
+	UsedAt    *time.Time `json:"usedAt" gorm:"comment:使用时间"`
+	CreatedAt time.Time  `json:"createdAt" gorm:"column:created_at;autoCreateTime;comment:创建时间"`
+}
+
+// TableName 指定表名
+func (RecoveryCode) TableName() string {
+	return "m_user_recovery_code"
+}
@@ -3,26 +3,33 @@ package models
 import (
 	"gorm.io/gorm"
 	"matuto-blog/pkg/utils"
+	"slices"
 	"time"
 )
 
 // User 博客用户模型
 type User struct {
-	Id        int       `json:"id" gorm:"column:id;primaryKey;autoIncrement;comment:主键ID"`
-	Account   string    `json:"account" gorm:"column:account;uniqueIndex;size:100;comment:账号"`
-	Username  string    `json:"username" gorm:"column:username;uniqueIndex;size:50;not null;comment:用户名"`
-	Password  string    `json:"-" gorm:"column:password;size:100;not null;comment:密码"`
-	Avatar    string    `json:"avatar" gorm:"column:avatar;size:255;comment:头像URL"`
-	Email     string    `json:"email" gorm:"column:email;uniqueIndex;size:100;comment:邮箱"`
-	Status    int       `json:"status" gorm:"column:status;default:0;comment:状态:0正常,1禁用"`
-	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime;comment:创建时间"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime;comment:更新时间"`
+	Id           int       `json:"id" gorm:"column:id;primaryKey;autoIncrement;comment:主键ID"`
+	Account      string    `json:"account" gorm:"column:account;uniqueIndex;size:100;comment:账号"`
+	Username     string    `json:"username" gorm:"column:username;uniqueIndex;size:50;not null;comment:用户名"`
+	Password     string    `json:"-" gorm:"column:password;size:100;not null;comment:密码"`
+	Avatar       string    `json:"avatar" gorm:"column:avatar;size:255;comment:头像URL"`
+	Email        string    `json:"email" gorm:"column:email;uniqueIndex;size:100;comment:邮箱"`
+	Status       int       `json:"status" gorm:"column:status;default:1;comment:状态:1正常,0禁用"`
+	Role         string    `json:"role" gorm:"column:role;size:32;not null;default:'';comment:角色:admin/editor/author/contributor"`
+	TotpSecret   string    `json:"-" gorm:"column:totp_secret;size:64;comment:TOTP密钥，启用前保存待确认的密钥"`
+	TotpEnabled  bool      `json:"totpEnabled" gorm:"column:totp_enabled;not null;default:false;comment:是否启用两步验证"`
+	TotpLastStep int64     `json:"-" gorm:"column:totp_last_step;not null;default:0;comment:最近一次使用的TOTP时间步，防止验证码重放"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime;comment:创建时间"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime;comment:更新时间"`
+	// Scopes 通过个人访问令牌认证时令牌的权限范围，nil表示不限制
+	Scopes []string `json:"-" gorm:"-"`
 }
 
 // UserStatus 用户状态常量
 const (
-	UserStatusActive   = 0 // 激活
-	UserStatusDisabled = 1 // 禁用
+	UserStatusActive   = StatusActive   // 激活
+	UserStatusDisabled = StatusDisabled // 禁用
 )
 
 // 使用密码工具类的配置
@@ -53,6 +60,28 @@ func (u *User) IsActive() bool {
 	return u.Status == UserStatusActive
 }
 
+// Can 检查用户角色是否拥有指定权限，通过令牌认证时还需在令牌的权限范围内
+func (u *User) Can(permission string) bool {
+	if u.Scopes != nil && !slices.Contains(u.Scopes, permission) {
+		return false
+	}
+	return RoleHasPermission(u.Role, permission)
+}
+
+// Permissions 用户当前可以使用的权限
+func (u *User) Permissions() []string {
+	if u.Scopes == nil {
+		return RolePermissions[u.Role]
+	}
+	permissions := []string{}
+	for _, permission := range RolePermissions[u.Role] {
+		if slices.Contains(u.Scopes, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
 // ScopeByUsername 按用户名查询
 func ScopeByUsername(Account string) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
@@ -2,11 +2,15 @@ package main
 
 import (
 	"matuto-blog/config"
+	"matuto-blog/internal/api/controllers"
 	"matuto-blog/internal/api/router"
 	database2 "matuto-blog/internal/database"
+	"matuto-blog/internal/jobs"
+	"matuto-blog/pkg/cache"
 	"matuto-blog/pkg/logger"
+	"matuto-blog/pkg/mailer"
 	"matuto-blog/pkg/storage"
-	"matuto-blog/pkg/utils"
+	"time"
 )
 
 func main() {
@@ -18,26 +22,89 @@ func main() {
 
 	// 初始化数据库
 	if err := database2.Init(); err != nil {
-		logger.Error("Warning: Failed to initialize database: %v", err)
+		logger.Error("Warning: Failed to initialize database:", err)
 		logger.Error("Continuing without database connection...")
 		return
 	} else {
 		// 初始化数据库表
 		if err := database2.InitTables(database2.GetDB()); err != nil {
-			logger.Error("Warning: Failed to initialize database tables: %v", err)
+			logger.Error("Warning: Failed to initialize database tables:", err)
 		}
 
 		// 创建数据库索引
 		if err := database2.CreateIndexes(database2.GetDB()); err != nil {
-			logger.Error("Warning: Failed to create database indexes: %v", err)
+			logger.Error("Warning: Failed to create database indexes:", err)
 		}
 	}
 
+	// 首次安装，用户表为空时生成安装令牌
+	if err := controllers.InitSetup(); err != nil {
+		logger.Error("Warning: Failed to check setup status:", err)
+	}
+
+	// 初始化登录防护
+	if err := controllers.InitLoginGuard(); err != nil {
+		logger.Error("Warning: Failed to initialize login guard:", err)
+	}
+
 	// 初始化存储系统
 	if err := storage.InitStorage(); err != nil {
-		logger.Error("Warning: Failed to initialize storage: %v", err)
+		logger.Error("Warning: Failed to initialize storage:", err)
+	}
+
+	// 初始化缓存
+	if err := cache.InitCache(); err != nil {
+		logger.Error("Warning: Failed to initialize cache:", err)
+	}
+
+	// 初始化全文索引
+	if err := controllers.InitSearchIndex(config.GetString("search.index_path")); err != nil {
+		logger.Error("Warning: Failed to initialize search index:", err)
+	}
+
+	// 初始化邮件服务
+	if err := mailer.InitMailer(); err != nil {
+		logger.Error("Warning: Failed to initialize mailer:", err)
 	}
 
+	// 初始化垃圾评论检查
+	if err := controllers.InitCommentSpam(); err != nil {
+		logger.Error("Warning: Failed to initialize comment spam checks:", err)
+	}
+
+	// 初始化分片上传
+	if err := controllers.InitChunkUpload(); err != nil {
+		logger.Error("Warning: Failed to initialize chunked upload:", err)
+	}
+
+	// 启动定时发布任务
+	publisher := jobs.NewArticlePublisher(time.Duration(config.GetInt("scheduler.publish_interval_seconds")) * time.Second)
+	publisher.OnPublish(controllers.OnArticlesPublished)
+	publisher.Start()
+	defer publisher.Stop()
+
+	// 启动友情链接检测任务
+	linkChecker := jobs.NewLinkChecker(
+		time.Duration(config.GetInt("links.check_interval_hours"))*time.Hour,
+		time.Duration(config.GetInt("links.check_timeout_seconds"))*time.Second,
+		config.GetInt("links.dead_after_failures"),
+	)
+	linkChecker.OnChange(controllers.OnLinksChanged)
+	controllers.SetLinkChecker(linkChecker)
+	linkChecker.Start()
+	defer linkChecker.Stop()
+
+	// 启动附件回收任务
+	attachCollector := jobs.NewAttachCollector(
+		time.Duration(config.GetInt("attachments.gc_interval_hours"))*time.Hour,
+		config.GetInt("attachments.unused_days"),
+		config.GetBool("attachments.auto_delete"),
+	)
+	attachCollector.OnCollect(controllers.OnAttachmentsCollected)
+	controllers.SetAttachCollector(attachCollector)
+	attachCollector.Start()
+	defer attachCollector.Stop()
+
 	// 初始化路由
 	r := router.InitRoutes()
 
@@ -49,12 +116,6 @@ func main() {
 
 	logger.Info("Server starting on port " + port)
 
-	password, err := utils.HashDefaultConfigPassword("123456")
-	if err != nil {
-		logger.Fatal("Failed to hash password:", err)
-	}
-	logger.Info("Password:", password)
-
 	if err := r.Run(":" + port); err != nil {
 		logger.Fatal("Failed to start server:", err)
 	}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Cache 缓存接口，写入时可附加标签，按标签批量失效
+type Cache interface {
+	// Get 获取缓存，不存在或已过期时返回false
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set 写入缓存，ttl为0时永不过期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+
+	// Delete 删除缓存
+	Delete(ctx context.Context, keys ...string) error
+
+	// InvalidateTags 删除带有任一标签的缓存
+	InvalidateTags(ctx context.Context, tags ...string) error
+
+	// Clear 清空缓存
+	Clear(ctx context.Context) error
+
+	// GetCacheType 获取缓存类型
+	GetCacheType() string
+}
+
+// Remember 读取缓存，未命中时调用load加载并以JSON格式写入缓存
+// 缓存读写失败不影响load结果的返回
+func Remember[T any](ctx context.Context, c Cache, key string, ttl time.Duration, tags []string, load func() (T, error)) (T, error) {
+	if c != nil {
+		if data, ok, err := c.Get(ctx, key); err == nil && ok {
+			var value T
+			if err := json.Unmarshal(data, &value); err == nil {
+				return value, nil
+			}
+		}
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	if c != nil {
+		if data, err := json.Marshal(value); err == nil {
+			_ = c.Set(ctx, key, data, ttl, tags...)
+		}
+	}
+	return value, nil
+}
+
+// NopCache 不缓存任何内容的实现，用于关闭缓存
+type NopCache struct{}
+
+// NewNopCache 创建空缓存
+func NewNopCache() *NopCache {
+	return &NopCache{}
+}
+
+// Get 获取缓存
+func (n *NopCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return nil, false, nil
+}
+
+// Set 写入缓存
+func (n *NopCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	return nil
+}
+
+// Delete 删除缓存
+func (n *NopCache) Delete(ctx context.Context, keys ...string) error {
+	return nil
+}
+
+// InvalidateTags 按标签失效缓存
+func (n *NopCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	return nil
+}
+
+// Clear 清空缓存
+func (n *NopCache) Clear(ctx context.Context) error {
+	return nil
+}
+
+// GetCacheType 获取缓存类型
+func (n *NopCache) GetCacheType() string {
+	return "none"
+}
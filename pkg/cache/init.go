@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"fmt"
+	"matuto-blog/config"
+	"matuto-blog/pkg/logger"
+	"time"
+)
+
+var (
+	// defaultCache 全局缓存，未初始化时不缓存
+	defaultCache Cache = NewNopCache()
+	// redisClient 外部注入的Redis客户端
+	redisClient RedisClient
+)
+
+// SetRedisClient 设置Redis客户端，需在InitCache之前调用
+func SetRedisClient(client RedisClient) {
+	redisClient = client
+}
+
+// InitCache 根据配置初始化全局缓存
+func InitCache() error {
+	driver := config.GetString("cache.driver")
+	if driver == "" {
+		driver = "memory"
+	}
+	logger.Info("Cache driver:", driver)
+
+	switch driver {
+	case "memory":
+		defaultCache = NewMemoryCache(config.GetInt("cache.capacity"))
+	case "redis":
+		client := redisClient
+		if client == nil {
+			logger.Warn("Redis client not configured, falling back to local redis stand-in")
+			client = NewLocalRedis()
+		}
+		defaultCache = NewRedisCache(client, config.GetString("cache.redis.prefix"))
+	case "none":
+		defaultCache = NewNopCache()
+	default:
+		return fmt.Errorf("不支持的缓存类型: %s", driver)
+	}
+	return nil
+}
+
+// Default 获取全局缓存
+func Default() Cache {
+	return defaultCache
+}
+
+// DefaultTTL 配置的默认缓存时间
+func DefaultTTL() time.Duration {
+	return time.Duration(config.GetInt("cache.ttl_seconds")) * time.Second
+}
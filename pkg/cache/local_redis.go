@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// localRedisValue 本地Redis中的值，字符串和集合二选一
+type localRedisValue struct {
+	data      []byte
+	set       map[string]struct{}
+	expiresAt time.Time
+}
+
+// LocalRedis 进程内实现的RedisClient，用于开发和测试环境替代真实Redis
+type LocalRedis struct {
+	mu     sync.Mutex
+	values map[string]*localRedisValue
+}
+
+// NewLocalRedis 创建本地Redis替身
+func NewLocalRedis() *LocalRedis {
+	return &LocalRedis{values: make(map[string]*localRedisValue)}
+}
+
+// Get 获取字符串值
+func (l *LocalRedis) Get(ctx context.Context, key string) ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	value := l.lookup(key)
+	if value == nil || value.set != nil {
+		return nil, ErrRedisNil
+	}
+	return value.data, nil
+}
+
+// Set 设置字符串值
+func (l *LocalRedis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry := &localRedisValue{data: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	l.values[key] = entry
+	return nil
+}
+
+// Del 删除键
+func (l *LocalRedis) Del(ctx context.Context, keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		delete(l.values, key)
+	}
+	return nil
+}
+
+// SAdd 向集合添加成员
+func (l *LocalRedis) SAdd(ctx context.Context, key string, members ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	value := l.lookup(key)
+	if value == nil || value.set == nil {
+		value = &localRedisValue{set: make(map[string]struct{})}
+		l.values[key] = value
+	}
+	for _, member := range members {
+		value.set[member] = struct{}{}
+	}
+	return nil
+}
+
+// SMembers 获取集合成员
+func (l *LocalRedis) SMembers(ctx context.Context, key string) ([]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	value := l.lookup(key)
+	if value == nil {
+		return nil, nil
+	}
+	members := make([]string, 0, len(value.set))
+	for member := range value.set {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// Keys 按前缀查找键
+func (l *LocalRedis) Keys(ctx context.Context, pattern string) ([]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prefix := strings.TrimSuffix(pattern, "*")
+	var keys []string
+	for key := range l.values {
+		if strings.HasPrefix(key, prefix) && l.lookup(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// lookup 获取未过期的值，过期时删除，调用方需持有锁
+func (l *LocalRedis) lookup(key string) *localRedisValue {
+	value, ok := l.values[key]
+	if !ok {
+		return nil
+	}
+	if !value.expiresAt.IsZero() && time.Now().After(value.expiresAt) {
+		delete(l.values, key)
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrRedisNil 键不存在，RedisClient实现在Get未命中时返回该错误
+var ErrRedisNil = errors.New("redis: nil")
+
+// RedisClient Redis命令子集，可使用go-redis等客户端包装实现
+type RedisClient interface {
+	// Get 对应 GET，键不存在时返回ErrRedisNil
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set 对应 SET key value [PX ttl]，ttl为0时不过期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Del 对应 DEL
+	Del(ctx context.Context, keys ...string) error
+
+	// SAdd 对应 SADD
+	SAdd(ctx context.Context, key string, members ...string) error
+
+	// SMembers 对应 SMEMBERS
+	SMembers(ctx context.Context, key string) ([]string, error)
+
+	// Keys 对应 KEYS，仅支持末尾带*的前缀匹配
+	Keys(ctx context.Context, pattern string) ([]string, error)
+}
+
+// RedisCache 基于Redis的缓存，标签以集合形式保存其下的键
+type RedisCache struct {
+	client RedisClient
+	prefix string
+}
+
+// NewRedisCache 创建Redis缓存，所有键都会加上prefix前缀
+func NewRedisCache(client RedisClient, prefix string) *RedisCache {
+	return &RedisCache{client: client, prefix: prefix}
+}
+
+// Get 获取缓存
+func (r *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, r.dataKey(key))
+	if errors.Is(err, ErrRedisNil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set 写入缓存并登记标签
+func (r *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	if err := r.client.Set(ctx, r.dataKey(key), value, ttl); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if err := r.client.SAdd(ctx, r.tagKey(tag), key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete 删除缓存
+func (r *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	dataKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		dataKeys = append(dataKeys, r.dataKey(key))
+	}
+	return r.client.Del(ctx, dataKeys...)
+}
+
+// InvalidateTags 删除带有任一标签的缓存
+func (r *RedisCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		keys, err := r.client.SMembers(ctx, r.tagKey(tag))
+		if err != nil {
+			return err
+		}
+		if err := r.Delete(ctx, keys...); err != nil {
+			return err
+		}
+		if err := r.client.Del(ctx, r.tagKey(tag)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clear 删除当前前缀下的所有键
+func (r *RedisCache) Clear(ctx context.Context) error {
+	keys, err := r.client.Keys(ctx, r.prefix+"*")
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.client.Del(ctx, keys...)
+}
+
+// GetCacheType 获取缓存类型
+func (r *RedisCache) GetCacheType() string {
+	return "redis"
+}
+
+// dataKey 缓存数据的键
+func (r *RedisCache) dataKey(key string) string {
+	return r.prefix + "data:" + key
+}
+
+// tagKey 标签集合的键
+func (r *RedisCache) tagKey(tag string) string {
+	return r.prefix + "tag:" + tag
+}
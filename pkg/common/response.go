@@ -2,6 +2,7 @@ package common
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,24 +26,26 @@ type PageResponse struct {
 
 // 响应码定义
 const (
-	CodeSuccess      = 200 // 成功
-	CodeBadRequest   = 400 // 请求错误
-	CodeUnauthorized = 401 // 未授权
-	CodeForbidden    = 403 // 禁止访问
-	CodeNotFound     = 404 // 未找到
-	CodeConflict     = 409 // 冲突
-	CodeServerError  = 500 // 服务器错误
+	CodeSuccess         = 200 // 成功
+	CodeBadRequest      = 400 // 请求错误
+	CodeUnauthorized    = 401 // 未授权
+	CodeForbidden       = 403 // 禁止访问
+	CodeNotFound        = 404 // 未找到
+	CodeConflict        = 409 // 冲突
+	CodeTooManyRequests = 429 // 请求过于频繁
+	CodeServerError     = 500 // 服务器错误
 )
 
 // 响应消息定义
 const (
-	MsgSuccess      = "操作成功"
-	MsgBadRequest   = "请求参数错误"
-	MsgUnauthorized = "未授权访问"
-	MsgForbidden    = "禁止访问"
-	MsgNotFound     = "资源不存在"
-	MsgConflict     = "资源冲突"
-	MsgServerError  = "服务器内部错误"
+	MsgSuccess         = "操作成功"
+	MsgBadRequest      = "请求参数错误"
+	MsgUnauthorized    = "未授权访问"
+	MsgForbidden       = "禁止访问"
+	MsgNotFound        = "资源不存在"
+	MsgConflict        = "资源冲突"
+	MsgTooManyRequests = "请求过于频繁，请稍后再试"
+	MsgServerError     = "服务器内部错误"
 )
 
 // Success 成功响应
@@ -142,6 +145,20 @@ func Conflict(c *gin.Context, message string) {
 	Error(c, CodeConflict, message)
 }
 
+// TooManyRequests 请求过于频繁响应，retryAfter为客户端需要等待的秒数，
+// 大于0时同时写入Retry-After响应头和响应数据
+func TooManyRequests(c *gin.Context, message string, retryAfter int) {
+	if message == "" {
+		message = MsgTooManyRequests
+	}
+	if retryAfter <= 0 {
+		Error(c, CodeTooManyRequests, message)
+		return
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	ErrorResponse(c, CodeTooManyRequests, message, gin.H{"retryAfter": retryAfter})
+}
+
 // ServerError 服务器错误响应
 func ServerError(c *gin.Context, message string) {
 	if message == "" {
@@ -0,0 +1,224 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Feed 订阅源
+type Feed struct {
+	Title       string    // 标题
+	Link        string    // 站点链接
+	FeedURL     string    // 订阅源自身地址
+	Description string    // 描述
+	Language    string    // 语言
+	Author      string    // 默认作者
+	Updated     time.Time // 最后更新时间
+	Items       []*Item   // 条目
+}
+
+// Item 订阅条目
+type Item struct {
+	Id          string    // 唯一标识
+	Title       string    // 标题
+	Link        string    // 链接
+	Description string    // 摘要
+	Content     string    // 正文HTML
+	Author      string    // 作者
+	Categories  []string  // 分类
+	Published   time.Time // 发布时间
+	Updated     time.Time // 更新时间
+}
+
+// ContentType 订阅源内容类型
+const (
+	ContentTypeRSS  = "application/rss+xml; charset=utf-8"
+	ContentTypeAtom = "application/atom+xml; charset=utf-8"
+)
+
+// rss RSS 2.0 根节点
+type rss struct {
+	XMLName   xml.Name    `xml:"rss"`
+	Version   string      `xml:"version,attr"`
+	ContentNS string      `xml:"xmlns:content,attr"`
+	AtomNS    string      `xml:"xmlns:atom,attr"`
+	Channel   *rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	AtomLink      *atomLink  `xml:"atom:link,omitempty"`
+	Description   string     `xml:"description"`
+	Language      string     `xml:"language,omitempty"`
+	LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+	Generator     string     `xml:"generator"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Guid        *rssGuid `xml:"guid"`
+	Description cdata    `xml:"description"`
+	Content     *cdata   `xml:"content:encoded,omitempty"`
+	Author      string   `xml:"author,omitempty"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+}
+
+type rssGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+// atomFeed Atom 根节点
+type atomFeed struct {
+	XMLName   xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Title     string       `xml:"title"`
+	Id        string       `xml:"id"`
+	Links     []*atomLink  `xml:"link"`
+	Updated   string       `xml:"updated"`
+	Subtitle  string       `xml:"subtitle,omitempty"`
+	Author    *atomAuthor  `xml:"author,omitempty"`
+	Generator string       `xml:"generator"`
+	Entries   []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string          `xml:"title"`
+	Id         string          `xml:"id"`
+	Links      []*atomLink     `xml:"link"`
+	Published  string          `xml:"published,omitempty"`
+	Updated    string          `xml:"updated"`
+	Author     *atomAuthor     `xml:"author,omitempty"`
+	Categories []*atomCategory `xml:"category"`
+	Summary    *atomText       `xml:"summary,omitempty"`
+	Content    *atomText       `xml:"content,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// generator 生成器名称
+const generator = "MatutoBlog"
+
+// ToRSS 生成RSS 2.0文档
+func (f *Feed) ToRSS() ([]byte, error) {
+	channel := &rssChannel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Description: f.Description,
+		Language:    f.Language,
+		Generator:   generator,
+	}
+	if f.FeedURL != "" {
+		channel.AtomLink = &atomLink{Href: f.FeedURL, Rel: "self", Type: "application/rss+xml"}
+	}
+	if !f.Updated.IsZero() {
+		channel.LastBuildDate = f.Updated.Format(time.RFC1123Z)
+	}
+
+	for _, item := range f.Items {
+		rssItem := &rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Guid:        &rssGuid{IsPermaLink: item.Id == item.Link, Value: item.Id},
+			Description: cdata{Value: item.Description},
+			Author:      item.Author,
+			Categories:  item.Categories,
+		}
+		if item.Content != "" {
+			rssItem.Content = &cdata{Value: item.Content}
+		}
+		if !item.Published.IsZero() {
+			rssItem.PubDate = item.Published.Format(time.RFC1123Z)
+		}
+		channel.Items = append(channel.Items, rssItem)
+	}
+
+	doc := rss{
+		Version:   "2.0",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		Channel:   channel,
+	}
+	return marshal(doc)
+}
+
+// ToAtom 生成Atom文档
+func (f *Feed) ToAtom() ([]byte, error) {
+	doc := atomFeed{
+		Title:     f.Title,
+		Id:        f.Link,
+		Subtitle:  f.Description,
+		Generator: generator,
+		Updated:   f.Updated.Format(time.RFC3339),
+		Links:     []*atomLink{{Href: f.Link, Rel: "alternate", Type: "text/html"}},
+	}
+	if f.Author != "" {
+		doc.Author = &atomAuthor{Name: f.Author}
+	}
+	if f.FeedURL != "" {
+		doc.Links = append(doc.Links, &atomLink{Href: f.FeedURL, Rel: "self", Type: "application/atom+xml"})
+	}
+
+	for _, item := range f.Items {
+		updated := item.Updated
+		if updated.IsZero() {
+			updated = item.Published
+		}
+		entry := &atomEntry{
+			Title:   item.Title,
+			Id:      item.Id,
+			Links:   []*atomLink{{Href: item.Link, Rel: "alternate", Type: "text/html"}},
+			Updated: updated.Format(time.RFC3339),
+		}
+		if !item.Published.IsZero() {
+			entry.Published = item.Published.Format(time.RFC3339)
+		}
+		if item.Author != "" {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, &atomCategory{Term: category})
+		}
+		if item.Description != "" {
+			entry.Summary = &atomText{Type: "html", Value: item.Description}
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Value: item.Content}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return marshal(doc)
+}
+
+// marshal 序列化为带XML声明的文档
+func marshal(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
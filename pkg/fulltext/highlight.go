@@ -0,0 +1,124 @@
+package fulltext
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// htmlTagRegexp 匹配HTML标签
+var htmlTagRegexp = regexp.MustCompile(`(?s)<(script|style)[^>]*>.*?</(script|style)>|<[^>]*>`)
+
+// PlainText 去除HTML标签并合并空白字符，用于生成索引正文
+func PlainText(content string) string {
+	text := htmlTagRegexp.ReplaceAllString(content, " ")
+	return strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+}
+
+// Highlight 转义文本并使用<mark>包裹命中的关键词
+func Highlight(text string, keywords []string) string {
+	runes := []rune(text)
+	return renderRanges(runes, matchRanges(runes, keywords), 0, len(runes))
+}
+
+// Snippet 截取首个关键词附近指定长度的片段并高亮，未命中时从开头截取
+func Snippet(text string, keywords []string, length int) string {
+	runes := []rune(text)
+	ranges := matchRanges(runes, keywords)
+
+	start := 0
+	if len(ranges) > 0 {
+		start = ranges[0][0] - length/4
+		if start < 0 {
+			start = 0
+		}
+	}
+	end := start + length
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	b.WriteString(renderRanges(runes, ranges, start, end))
+	if end < len(runes) {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+// matchRanges 从左到右查找关键词出现的位置，优先匹配较长的关键词，忽略大小写
+func matchRanges(runes []rune, keywords []string) [][2]int {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var patterns [][]rune
+	for _, keyword := range keywords {
+		if keyword != "" {
+			patterns = append(patterns, []rune(strings.ToLower(keyword)))
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		return len(patterns[i]) > len(patterns[j])
+	})
+
+	var ranges [][2]int
+	for i := 0; i < len(lower); {
+		matched := 0
+		for _, pattern := range patterns {
+			if hasPrefixRunes(lower[i:], pattern) {
+				matched = len(pattern)
+				break
+			}
+		}
+		if matched == 0 {
+			i++
+			continue
+		}
+		ranges = append(ranges, [2]int{i, i + matched})
+		i += matched
+	}
+	return ranges
+}
+
+// renderRanges 输出[start, end)范围内的文本，命中部分使用<mark>包裹
+func renderRanges(runes []rune, ranges [][2]int, start, end int) string {
+	var b strings.Builder
+	pos := start
+	for _, r := range ranges {
+		from, to := r[0], r[1]
+		if to <= start || from >= end {
+			continue
+		}
+		if from < pos {
+			from = pos
+		}
+		if to > end {
+			to = end
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:from])))
+		b.WriteString("<mark>" + html.EscapeString(string(runes[from:to])) + "</mark>")
+		pos = to
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	return b.String()
+}
+
+// hasPrefixRunes 判断rune切片是否以指定前缀开头
+func hasPrefixRunes(runes, prefix []rune) bool {
+	if len(runes) < len(prefix) {
+		return false
+	}
+	for i, r := range prefix {
+		if runes[i] != r {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package fulltext
+
+import "testing"
+
+func TestPlainText(t *testing.T) {
+	got := PlainText("<p>Hello&nbsp;<b>world</b></p><script>alert(1)</script>\n<style>p{}</style> &lt;x&gt;")
+	if want := "Hello world <x>"; got != want {
+		t.Errorf("PlainText = %q, want %q", got, want)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text     string
+		keywords []string
+		want     string
+	}{
+		{"Go and go", []string{"go"}, "<mark>Go</mark> and <mark>go</mark>"},
+		{"<b>go</b>", []string{"go"}, "&lt;b&gt;<mark>go</mark>&lt;/b&gt;"},
+		{"全文检索", []string{"全文", "全文检索"}, "<mark>全文检索</mark>"},
+		{"nothing", []string{"go"}, "nothing"},
+		{"empty keyword", []string{""}, "empty keyword"},
+	}
+	for _, tt := range tests {
+		if got := Highlight(tt.text, tt.keywords); got != tt.want {
+			t.Errorf("Highlight(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa keyword bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	if got, want := Snippet(text, []string{"keyword"}, 20), "...aaaa <mark>keyword</mark> bbbbbbb..."; got != want {
+		t.Errorf("Snippet = %q, want %q", got, want)
+	}
+	if got, want := Snippet("short text", []string{"missing"}, 20), "short text"; got != want {
+		t.Errorf("Snippet = %q, want %q", got, want)
+	}
+	if got, want := Snippet(text, nil, 5), "aaaaa..."; got != want {
+		t.Errorf("Snippet = %q, want %q", got, want)
+	}
+}
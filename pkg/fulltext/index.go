@@ -0,0 +1,300 @@
+package fulltext
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// 文档字段
+const (
+	fieldTitle = iota
+	fieldSummary
+	fieldContent
+	fieldTags
+	fieldCategories
+	fieldCount
+)
+
+// fieldBoosts 各字段的权重，标题、标签和分类命中比正文更相关
+var fieldBoosts = [fieldCount]float64{3, 1.5, 1, 2, 2}
+
+// DefaultSnippetLength 默认摘要片段长度（字符数）
+const DefaultSnippetLength = 160
+
+// Document 索引文档
+type Document struct {
+	Id         int
+	Title      string
+	Summary    string
+	Content    string // 纯文本正文
+	Tags       []string
+	Categories []string
+	Published  time.Time
+}
+
+// Hit 搜索命中结果，Title和Snippet为已转义并高亮的HTML
+type Hit struct {
+	Id      int     `json:"id"`
+	Score   float64 `json:"score"`
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet"`
+}
+
+// Result 搜索结果
+type Result struct {
+	Total int   `json:"total"`
+	Hits  []Hit `json:"hits"`
+}
+
+// Index 基于倒排表的全文索引，文档持久化到本地文件，倒排表在加载时重建
+type Index struct {
+	mu       sync.RWMutex
+	path     string
+	docs     map[int]*Document
+	lengths  map[int]float64
+	postings map[string]map[int]float64
+	totalLen float64
+}
+
+// Open 打开索引文件，文件不存在时创建空索引，path为空时仅保存在内存中
+func Open(path string) (*Index, error) {
+	idx := &Index{
+		path:     path,
+		docs:     make(map[int]*Document),
+		lengths:  make(map[int]float64),
+		postings: make(map[string]map[int]float64),
+	}
+	if path == "" {
+		return idx, nil
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开索引文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var docs []*Document
+	if err := gob.NewDecoder(file).Decode(&docs); err != nil {
+		return nil, fmt.Errorf("读取索引文件失败: %w", err)
+	}
+	for _, doc := range docs {
+		idx.add(doc)
+	}
+	return idx, nil
+}
+
+// Len 索引中的文档数量
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Put 添加或更新文档
+func (idx *Index) Put(docs ...*Document) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, doc := range docs {
+		idx.remove(doc.Id)
+		idx.add(doc)
+	}
+	return idx.save()
+}
+
+// Delete 删除文档
+func (idx *Index) Delete(ids ...int) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, id := range ids {
+		idx.remove(id)
+	}
+	return idx.save()
+}
+
+// Reset 清空索引并使用给定文档重建
+func (idx *Index) Reset(docs []*Document) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.docs = make(map[int]*Document)
+	idx.lengths = make(map[int]float64)
+	idx.postings = make(map[string]map[int]float64)
+	idx.totalLen = 0
+	for _, doc := range docs {
+		idx.add(doc)
+	}
+	return idx.save()
+}
+
+// Search 按BM25相关度搜索，所有查询词都命中且通过filter的文档才会返回，filter为nil时不过滤
+func (idx *Index) Search(query string, offset, limit int, filter func(id int) bool) *Result {
+	result := &Result{Hits: []Hit{}}
+	terms := QueryTerms(query)
+	if len(terms) == 0 {
+		return result
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	if len(idx.docs) == 0 {
+		return result
+	}
+
+	total := float64(len(idx.docs))
+	avgLen := idx.totalLen / total
+	scores := make(map[int]float64)
+	matched := make(map[int]int)
+	for _, term := range terms {
+		list := idx.postings[term]
+		if len(list) == 0 {
+			return result
+		}
+		df := float64(len(list))
+		idf := math.Log(1 + (total-df+0.5)/(df+0.5))
+		for id, tf := range list {
+			norm := 1 - bm25B + bm25B*idx.lengths[id]/avgLen
+			scores[id] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+			matched[id]++
+		}
+	}
+
+	ids := make([]int, 0, len(scores))
+	for id := range scores {
+		if matched[id] == len(terms) && (filter == nil || filter(id)) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := ids[i], ids[j]
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		if !idx.docs[a].Published.Equal(idx.docs[b].Published) {
+			return idx.docs[a].Published.After(idx.docs[b].Published)
+		}
+		return a > b
+	})
+
+	result.Total = len(ids)
+	if offset >= len(ids) {
+		return result
+	}
+	end := offset + limit
+	if limit <= 0 || end > len(ids) {
+		end = len(ids)
+	}
+
+	keywords := append(queryKeywords(query), terms...)
+	for _, id := range ids[offset:end] {
+		doc := idx.docs[id]
+		text := doc.Summary
+		if len(matchRanges([]rune(text), keywords)) == 0 {
+			text = doc.Content
+		}
+		result.Hits = append(result.Hits, Hit{
+			Id:      id,
+			Score:   scores[id],
+			Title:   Highlight(doc.Title, keywords),
+			Snippet: Snippet(text, keywords, DefaultSnippetLength),
+		})
+	}
+	return result
+}
+
+// fieldTokens 按字段对文档分词
+func fieldTokens(doc *Document) [fieldCount][]string {
+	var tokens [fieldCount][]string
+	tokens[fieldTitle] = Tokenize(doc.Title)
+	tokens[fieldSummary] = Tokenize(doc.Summary)
+	tokens[fieldContent] = Tokenize(doc.Content)
+	tokens[fieldTags] = Tokenize(strings.Join(doc.Tags, " "))
+	tokens[fieldCategories] = Tokenize(strings.Join(doc.Categories, " "))
+	return tokens
+}
+
+// add 将文档写入倒排表，调用方需持有写锁
+func (idx *Index) add(doc *Document) {
+	var length float64
+	for field, tokens := range fieldTokens(doc) {
+		for _, token := range tokens {
+			list, ok := idx.postings[token]
+			if !ok {
+				list = make(map[int]float64)
+				idx.postings[token] = list
+			}
+			list[doc.Id] += fieldBoosts[field]
+		}
+		length += float64(len(tokens))
+	}
+	idx.docs[doc.Id] = doc
+	idx.lengths[doc.Id] = length
+	idx.totalLen += length
+}
+
+// remove 从倒排表中移除文档，调用方需持有写锁
+func (idx *Index) remove(id int) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for _, tokens := range fieldTokens(doc) {
+		for _, token := range tokens {
+			if list, ok := idx.postings[token]; ok {
+				delete(list, id)
+				if len(list) == 0 {
+					delete(idx.postings, token)
+				}
+			}
+		}
+	}
+	idx.totalLen -= idx.lengths[id]
+	delete(idx.lengths, id)
+	delete(idx.docs, id)
+}
+
+// save 将文档写入临时文件后替换索引文件，调用方需持有锁
+func (idx *Index) save() error {
+	if idx.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return fmt.Errorf("创建索引目录失败: %w", err)
+	}
+
+	docs := make([]*Document, 0, len(idx.docs))
+	for _, doc := range idx.docs {
+		docs = append(docs, doc)
+	}
+
+	tmp := idx.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("创建索引文件失败: %w", err)
+	}
+	if err := gob.NewEncoder(file).Encode(docs); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("写入索引文件失败: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入索引文件失败: %w", err)
+	}
+	return os.Rename(tmp, idx.path)
+}
@@ -0,0 +1,134 @@
+package fulltext
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// hitIds 搜索结果中的文档ID
+func hitIds(result *Result) []int {
+	ids := make([]int, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.Id)
+	}
+	return ids
+}
+
+// testDocuments 测试文档，发布时间按ID递增
+func testDocuments() []*Document {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []*Document{
+		{Id: 1, Title: "Go语言入门", Content: "介绍Go的基础语法", Published: base},
+		{Id: 2, Title: "数据库优化", Content: "MySQL索引设计，顺便提到Go的驱动", Published: base.Add(time.Hour)},
+		{Id: 3, Title: "周末随笔", Content: "今天天气不错", Tags: []string{"Go"}, Published: base.Add(2 * time.Hour)},
+		{Id: 4, Title: "全文检索实现", Summary: "倒排索引与BM25", Content: "使用Go实现全文检索", Published: base.Add(3 * time.Hour)},
+		{Id: 5, Title: "随笔", Content: "天气 天气 天气", Published: base.Add(4 * time.Hour)},
+		{Id: 6, Title: "随笔", Content: "天气", Published: base.Add(5 * time.Hour)},
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	idx, _ := Open("")
+	if err := idx.Put(testDocuments()...); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		// 标题权重最高，其次是标签，正文最低；都在正文命中时较短的文档排在前面
+		{"field boosts", "go", []int{1, 3, 2, 4}},
+		// 所有查询词都命中才返回
+		{"all terms", "go 检索", []int{4}},
+		{"cjk bigram", "全文检索", []int{4}},
+		// 词频更高的文档得分更高
+		{"term frequency", "天气", []int{5, 6, 3}},
+		{"no match", "python", []int{}},
+		{"empty query", " ", []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := idx.Search(tt.query, 0, 10, nil)
+			if got := hitIds(result); !reflect.DeepEqual(got, tt.want) || result.Total != len(tt.want) {
+				t.Errorf("Search(%q) = %v (total %d), want %v", tt.query, got, result.Total, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchTieBreak(t *testing.T) {
+	idx, _ := Open("")
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	idx.Put(
+		&Document{Id: 1, Title: "same", Published: base},
+		&Document{Id: 2, Title: "same", Published: base.Add(time.Hour)},
+		&Document{Id: 3, Title: "same", Published: base},
+	)
+	// 得分相同时按发布时间倒序，再按ID倒序
+	if got := hitIds(idx.Search("same", 0, 10, nil)); !reflect.DeepEqual(got, []int{2, 3, 1}) {
+		t.Errorf("Search = %v, want [2 3 1]", got)
+	}
+}
+
+func TestSearchPagingAndFilter(t *testing.T) {
+	idx, _ := Open("")
+	idx.Put(testDocuments()...)
+
+	page := idx.Search("go", 1, 2, nil)
+	if got := hitIds(page); !reflect.DeepEqual(got, []int{3, 2}) || page.Total != 4 {
+		t.Errorf("page = %v (total %d), want [3 2] (total 4)", got, page.Total)
+	}
+	if got := idx.Search("go", 10, 2, nil); len(got.Hits) != 0 || got.Total != 4 {
+		t.Errorf("offset past end = %v (total %d)", hitIds(got), got.Total)
+	}
+	filtered := idx.Search("go", 0, 10, func(id int) bool { return id%2 == 0 })
+	if got := hitIds(filtered); !reflect.DeepEqual(got, []int{2, 4}) || filtered.Total != 2 {
+		t.Errorf("filtered = %v (total %d), want [2 4]", got, filtered.Total)
+	}
+
+	hit := idx.Search("检索", 0, 1, nil).Hits[0]
+	if hit.Title != "全文<mark>检索</mark>实现" {
+		t.Errorf("Title = %q", hit.Title)
+	}
+	if hit.Snippet != "使用Go实现全文<mark>检索</mark>" {
+		t.Errorf("Snippet = %q, want match from content when summary has none", hit.Snippet)
+	}
+}
+
+func TestPutDeletePersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.gob")
+	idx, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.Put(testDocuments()...)
+
+	// 更新文档后旧内容不再命中
+	idx.Put(&Document{Id: 1, Title: "Rust入门"})
+	if got := hitIds(idx.Search("rust", 0, 10, nil)); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("after update = %v, want [1]", got)
+	}
+	if err := idx.Delete(2, 99); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Len() != 5 {
+		t.Errorf("Len = %d, want 5", reopened.Len())
+	}
+	if got := hitIds(reopened.Search("go", 0, 10, nil)); !reflect.DeepEqual(got, []int{3, 4}) {
+		t.Errorf("reopened search = %v, want [3 4]", got)
+	}
+
+	reopened.Reset(nil)
+	if reopened.Len() != 0 || reopened.totalLen != 0 || len(reopened.postings) != 0 {
+		t.Error("Reset should clear the index")
+	}
+}
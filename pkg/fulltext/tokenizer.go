@@ -0,0 +1,107 @@
+package fulltext
+
+import (
+	"strings"
+	"unicode"
+)
+
+// isCJK 判断是否为中日韩字符，此类字符之间没有空格分词
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
+
+// isWordRune 判断是否为英文单词或数字的组成字符
+func isWordRune(r rune) bool {
+	return !isCJK(r) && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// splitRuns 将文本切分为连续的CJK片段和单词片段，其余字符作为分隔符丢弃
+func splitRuns(text string) (runs [][]rune, cjk []bool) {
+	var current []rune
+	currentCJK := false
+	flush := func() {
+		if len(current) > 0 {
+			runs = append(runs, current)
+			cjk = append(cjk, currentCJK)
+			current = nil
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			if !currentCJK {
+				flush()
+			}
+			currentCJK = true
+			current = append(current, r)
+		case isWordRune(r):
+			if currentCJK {
+				flush()
+			}
+			currentCJK = false
+			current = append(current, unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return runs, cjk
+}
+
+// Tokenize 索引分词，英文按单词切分并转小写，CJK文本同时输出单字和二元组
+// 二元组保证多字查询的精度，单字保证单字查询也能命中
+func Tokenize(text string) []string {
+	runs, cjk := splitRuns(text)
+	var tokens []string
+	for i, run := range runs {
+		if !cjk[i] {
+			tokens = append(tokens, string(run))
+			continue
+		}
+		for j := range run {
+			tokens = append(tokens, string(run[j]))
+			if j+1 < len(run) {
+				tokens = append(tokens, string(run[j:j+2]))
+			}
+		}
+	}
+	return tokens
+}
+
+// QueryTerms 查询分词，CJK片段只取二元组（单字片段取单字），结果去重
+func QueryTerms(query string) []string {
+	runs, cjk := splitRuns(query)
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(term string) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	for i, run := range runs {
+		if !cjk[i] || len(run) == 1 {
+			add(string(run))
+			continue
+		}
+		for j := 0; j+1 < len(run); j++ {
+			add(string(run[j : j+2]))
+		}
+	}
+	return terms
+}
+
+// queryKeywords 用于高亮的关键词，即查询中的原始片段
+func queryKeywords(query string) []string {
+	runs, _ := splitRuns(query)
+	keywords := make([]string, 0, len(runs))
+	for _, run := range runs {
+		keywords = append(keywords, strings.ToLower(string(run)))
+	}
+	return keywords
+}
@@ -0,0 +1,43 @@
+package fulltext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"Hello, World! Go1.22", []string{"hello", "world", "go1", "22"}},
+		{"全文检索", []string{"全", "全文", "文", "文检", "检", "检索", "索"}},
+		{"Go语言", []string{"go", "语", "语言", "言"}},
+		{"字", []string{"字"}},
+		{"ひらがな", []string{"ひ", "ひら", "ら", "らが", "が", "がな", "な"}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestQueryTerms(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"  ", nil},
+		{"Go go GO", []string{"go"}},
+		{"全文检索", []string{"全文", "文检", "检索"}},
+		{"搜 索引", []string{"搜", "索引"}},
+		{"gin 框架", []string{"gin", "框架"}},
+	}
+	for _, tt := range tests {
+		if got := QueryTerms(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("QueryTerms(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
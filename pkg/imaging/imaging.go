@@ -0,0 +1,209 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// 图片格式
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatWebP = "webp"
+)
+
+var (
+	// ErrUnsupportedFormat 不支持处理的图片格式
+	ErrUnsupportedFormat = errors.New("imaging: 不支持的图片格式")
+	// ErrTooLarge 图片像素数超过限制
+	ErrTooLarge = errors.New("imaging: 图片尺寸过大")
+)
+
+// DetectFormat 根据文件头识别图片格式，无法识别时返回空字符串
+func DetectFormat(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return FormatJPEG
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return FormatPNG
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return FormatGIF
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return FormatWebP
+	default:
+		return ""
+	}
+}
+
+// CheckSize 读取图片头中的宽高，像素数超过maxPixels时返回ErrTooLarge，maxPixels不大于0表示不限制
+// 解码时按宽高分配内存，很小的文件也可以声明极大的尺寸，解码前需要先检查
+func CheckSize(data []byte, maxPixels int) error {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("imaging: 读取图片尺寸失败: %w", err)
+	}
+	if maxPixels > 0 && int64(cfg.Width)*int64(cfg.Height) > int64(maxPixels) {
+		return fmt.Errorf("%w: %dx%d", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+	return nil
+}
+
+// Decode 解码图片并按EXIF方向信息旋转，返回图片和格式，像素数超过maxPixels的图片返回ErrTooLarge
+// 标准库不支持解码WebP，WebP图片返回ErrUnsupportedFormat
+func Decode(data []byte, maxPixels int) (image.Image, string, error) {
+	format := DetectFormat(data)
+	if format != FormatJPEG && format != FormatPNG && format != FormatGIF {
+		return nil, format, ErrUnsupportedFormat
+	}
+	if err := CheckSize(data, maxPixels); err != nil {
+		return nil, format, err
+	}
+	var img image.Image
+	var err error
+	switch format {
+	case FormatJPEG:
+		img, err = jpeg.Decode(bytes.NewReader(data))
+		if err == nil {
+			img = applyOrientation(img, jpegOrientation(data))
+		}
+	case FormatPNG:
+		img, err = png.Decode(bytes.NewReader(data))
+	case FormatGIF:
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, format, fmt.Errorf("imaging: 解码图片失败: %w", err)
+	}
+	return img, format, nil
+}
+
+// Encode 按格式编码图片，GIF只保留第一帧并编码为PNG
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case FormatJPEG:
+		if quality <= 0 || quality > 100 {
+			quality = jpeg.DefaultQuality
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case FormatPNG, FormatGIF:
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		return encoder.Encode(w, img)
+	case FormatWebP:
+		return EncodeWebP(w, img)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// OutputFormat 派生图片使用的格式，GIF的派生图片使用PNG
+func OutputFormat(format string) string {
+	if format == FormatGIF {
+		return FormatPNG
+	}
+	return format
+}
+
+// Extension 图片格式对应的文件扩展名
+func Extension(format string) string {
+	if format == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + format
+}
+
+// MimeType 图片格式对应的MIME类型
+func MimeType(format string) string {
+	return "image/" + format
+}
+
+// Resize 将图片等比缩小到宽度不超过maxWidth，图片本身不超过时原样返回
+// 使用区域平均采样，缩小时不会产生锯齿
+func Resize(img image.Image, maxWidth int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if maxWidth <= 0 || srcW <= maxWidth {
+		return img
+	}
+	dstW := maxWidth
+	dstH := max(1, (srcH*dstW+srcW/2)/srcW)
+
+	src := toRGBA(img)
+	// 先水平缩放再垂直缩放，中间结果使用浮点数避免精度损失
+	xWeights := areaWeights(srcW, dstW)
+	yWeights := areaWeights(srcH, dstH)
+	tmp := make([]float32, dstW*srcH*4)
+	for y := 0; y < srcH; y++ {
+		row := src.Pix[y*src.Stride:]
+		for x, weights := range xWeights {
+			var r, g, b, a float32
+			for _, w := range weights {
+				p := row[w.index*4 : w.index*4+4 : w.index*4+4]
+				r += float32(p[0]) * w.weight
+				g += float32(p[1]) * w.weight
+				b += float32(p[2]) * w.weight
+				a += float32(p[3]) * w.weight
+			}
+			o := (y*dstW + x) * 4
+			tmp[o], tmp[o+1], tmp[o+2], tmp[o+3] = r, g, b, a
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y, weights := range yWeights {
+		for x := 0; x < dstW; x++ {
+			var sum [4]float32
+			for _, w := range weights {
+				o := (w.index*dstW + x) * 4
+				for c := 0; c < 4; c++ {
+					sum[c] += tmp[o+c] * w.weight
+				}
+			}
+			o := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[o+c] = uint8(min(255, sum[c]+0.5))
+			}
+		}
+	}
+	return dst
+}
+
+// sampleWeight 采样权重
+type sampleWeight struct {
+	index  int
+	weight float32
+}
+
+// areaWeights 计算缩小时每个目标像素覆盖的源像素及其权重
+func areaWeights(srcSize, dstSize int) [][]sampleWeight {
+	scale := float64(srcSize) / float64(dstSize)
+	weights := make([][]sampleWeight, dstSize)
+	for i := range weights {
+		start, end := float64(i)*scale, float64(i+1)*scale
+		for j := int(start); j < srcSize && float64(j) < end; j++ {
+			overlap := min(end, float64(j+1)) - max(start, float64(j))
+			if overlap > 0 {
+				weights[i] = append(weights[i], sampleWeight{index: j, weight: float32(overlap / scale)})
+			}
+		}
+	}
+	return weights
+}
+
+// toRGBA 转换为从原点开始的RGBA图片
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
@@ -0,0 +1,96 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// encodePNG 生成指定尺寸的PNG图片
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 10), uint8(y * 10), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withPNGSize 修改PNG头中声明的宽高并重新计算校验和
+func withPNGSize(data []byte, width, height uint32) []byte {
+	out := bytes.Clone(data)
+	// 8字节签名之后是IHDR块：长度(4) 类型(4) 宽(4) 高(4) ...
+	binary.BigEndian.PutUint32(out[16:], width)
+	binary.BigEndian.PutUint32(out[20:], height)
+	length := binary.BigEndian.Uint32(out[8:])
+	crc := crc32.ChecksumIEEE(out[12 : 16+length])
+	binary.BigEndian.PutUint32(out[16+length:], crc)
+	return out
+}
+
+func TestDecode(t *testing.T) {
+	data := encodePNG(t, 8, 4)
+	img, format, err := Decode(data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != FormatPNG || img.Bounds().Dx() != 8 || img.Bounds().Dy() != 4 {
+		t.Errorf("Decode = %s %v", format, img.Bounds())
+	}
+}
+
+func TestDecodeMaxPixels(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		maxPixels int
+		err       error
+	}{
+		{"within limit", encodePNG(t, 8, 4), 32, nil},
+		{"no limit", encodePNG(t, 8, 4), 0, nil},
+		{"over limit", encodePNG(t, 8, 4), 31, ErrTooLarge},
+		// 文件很小但声明了50000x50000的尺寸，应在分配内存解码前拒绝
+		{"declared huge", withPNGSize(encodePNG(t, 8, 4), 50000, 50000), 40000000, ErrTooLarge},
+		{"unsupported", []byte("not an image"), 0, ErrUnsupportedFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Decode(tt.data, tt.maxPixels)
+			if tt.err == nil && err != nil {
+				t.Fatalf("Decode error = %v", err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("Decode error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		data   string
+		format string
+	}{
+		{"\xff\xd8\xff\xe0", FormatJPEG},
+		{"\x89PNG\r\n\x1a\n", FormatPNG},
+		{"GIF89a", FormatGIF},
+		{"RIFF\x00\x00\x00\x00WEBPVP8 ", FormatWebP},
+		{"BM", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat([]byte(tt.data)); got != tt.format {
+			t.Errorf("DetectFormat(%q) = %q, want %q", tt.data, got, tt.format)
+		}
+	}
+}
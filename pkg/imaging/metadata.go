@@ -0,0 +1,207 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// StripMetadata 去除图片中的EXIF、XMP、文本等元数据，其中可能包含GPS位置和设备信息
+// 不需要重新编码时逐段删除元数据，保持原图质量；JPEG带有旋转方向时按方向旋转后重新编码
+// 无法识别的格式原样返回，需要旋转的图片像素数超过maxPixels时返回ErrTooLarge
+func StripMetadata(data []byte, quality, maxPixels int) ([]byte, error) {
+	switch DetectFormat(data) {
+	case FormatJPEG:
+		if orientation := jpegOrientation(data); orientation > 1 && orientation <= 8 {
+			img, _, err := Decode(data, maxPixels)
+			if err != nil {
+				return nil, err
+			}
+			var buf bytes.Buffer
+			if err := Encode(&buf, img, FormatJPEG, quality); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		}
+		return stripJPEG(data), nil
+	case FormatPNG:
+		return stripPNG(data), nil
+	case FormatWebP:
+		return stripWebP(data), nil
+	default:
+		return data, nil
+	}
+}
+
+// jpegSegments 遍历JPEG在图像数据之前的标记段，fn返回false时停止，返回图像数据的起始位置
+func jpegSegments(data []byte, fn func(marker byte, segment []byte) bool) int {
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xff {
+		marker := data[pos+1]
+		if marker == 0xff { // 填充字节
+			pos++
+			continue
+		}
+		if marker == 0xda { // SOS，之后是图像数据
+			return pos
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return pos
+		}
+		if !fn(marker, data[pos:end]) {
+			return pos
+		}
+		pos = end
+	}
+	return pos
+}
+
+// stripJPEG 删除APP1(EXIF/XMP)、APP13(IPTC)和注释段，保留JFIF、ICC和Adobe等影响显示的段
+func stripJPEG(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	rest := jpegSegments(data, func(marker byte, segment []byte) bool {
+		if marker != 0xe1 && marker != 0xed && marker != 0xfe {
+			out = append(out, segment...)
+		}
+		return true
+	})
+	return append(out, data[rest:]...)
+}
+
+// jpegOrientation 读取JPEG的EXIF方向，没有方向信息时返回1
+func jpegOrientation(data []byte) int {
+	orientation := 1
+	jpegSegments(data, func(marker byte, segment []byte) bool {
+		if marker != 0xe1 || !bytes.HasPrefix(segment[4:], []byte("Exif\x00\x00")) {
+			return true
+		}
+		if value := exifOrientation(segment[10:]); value > 0 {
+			orientation = value
+		}
+		return false
+	})
+	return orientation
+}
+
+// exifOrientation 从TIFF结构的IFD0中读取方向标签(0x0112)
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 0
+}
+
+// applyOrientation 按EXIF方向旋转或翻转图片
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	src := toRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		for x := 0; x < dstW; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // 水平翻转
+				sx, sy = w-1-x, y
+			case 3: // 旋转180度
+				sx, sy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				sx, sy = x, h-1-y
+			case 5: // 沿主对角线翻转
+				sx, sy = y, x
+			case 6: // 顺时针旋转90度
+				sx, sy = y, h-1-x
+			case 7: // 沿副对角线翻转
+				sx, sy = w-1-y, h-1-x
+			case 8: // 逆时针旋转90度
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], src.Pix[sy*src.Stride+sx*4:])
+		}
+	}
+	return dst
+}
+
+// stripPNG 删除文本、EXIF和时间块
+func stripPNG(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:8]...)
+	pos := 8
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return data
+		}
+		switch string(data[pos+4 : pos+8]) {
+		case "tEXt", "zTXt", "iTXt", "eXIf", "tIME":
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	return append(out, data[pos:]...)
+}
+
+// stripWebP 删除EXIF和XMP块，并清除VP8X中对应的标志位
+func stripWebP(data []byte) []byte {
+	if len(data) < 12 {
+		return data
+	}
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	pos := 12
+	for pos+8 <= len(data) {
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + size + size&1
+		if end > len(data) {
+			return data
+		}
+		switch string(data[pos : pos+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[pos:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= 0x08 | 0x04
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out
+}
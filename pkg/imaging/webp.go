@@ -0,0 +1,679 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"sort"
+)
+
+// WebP无损编码(VP8L)
+// 使用减绿变换和预测变换去除像素相关性，再用LZ77和范式哈夫曼编码压缩，不使用颜色缓存
+
+const (
+	vp8lSignature     = 0x2f
+	vp8lMaxDimension  = 1 << 14
+	vp8lPredictorBits = 4 // 预测变换的分块大小为16x16
+	vp8lMaxCodeLength = 15
+	vp8lMaxLength     = 4096 // LZ77最大匹配长度
+	vp8lMinLength     = 3    // LZ77最短匹配长度，更短的匹配不如直接编码像素
+	vp8lDistanceBias  = 120  // 距离码大于120时表示直接距离，不使用二维距离映射
+	vp8lMaxDistance   = 1<<20 - vp8lDistanceBias
+	vp8lHashBits      = 16
+	vp8lMaxChain      = 32
+
+	vp8lTransformPredictor     = 0
+	vp8lTransformSubtractGreen = 2
+
+	vp8lNumLiteralCodes  = 256
+	vp8lNumLengthCodes   = 24
+	vp8lNumDistanceCodes = 40
+)
+
+// vp8lCodeLengthOrder 码长的码长写入顺序
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// EncodeWebP 将图片编码为无损WebP
+func EncodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return errors.New("imaging: WebP图片尺寸超出范围")
+	}
+
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+	argb := make([]uint32, width*height)
+	hasAlpha := false
+	for i := range argb {
+		p := nrgba.Pix[i*4 : i*4+4 : i*4+4]
+		argb[i] = uint32(p[3])<<24 | uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+		if p[3] != 0xff {
+			hasAlpha = true
+		}
+	}
+
+	bw := &bitWriter{}
+	bw.writeBits(vp8lSignature, 8)
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	if hasAlpha {
+		bw.writeBits(1, 1)
+	} else {
+		bw.writeBits(0, 1)
+	}
+	bw.writeBits(0, 3) // 版本号
+
+	// 减绿变换
+	bw.writeBits(1, 1)
+	bw.writeBits(vp8lTransformSubtractGreen, 2)
+	subtractGreen(argb)
+
+	// 预测变换，预测模式图作为子图编码
+	bw.writeBits(1, 1)
+	bw.writeBits(vp8lTransformPredictor, 2)
+	bw.writeBits(vp8lPredictorBits-2, 3)
+	modes, modesWidth := predict(argb, width, height)
+	writeEntropyImage(bw, modes, modesWidth, false)
+
+	bw.writeBits(0, 1) // 变换结束
+	writeEntropyImage(bw, argb, width, true)
+
+	payload := bw.bytes()
+	padding := len(payload) & 1
+	header := make([]byte, 20)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(4+8+len(payload)+padding))
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(len(payload)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+	if padding == 1 {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+// subtractGreen 红色和蓝色通道减去绿色通道
+func subtractGreen(argb []uint32) {
+	for i, p := range argb {
+		green := (p >> 8) & 0xff
+		red := (p>>16 - green) & 0xff
+		blue := (p - green) & 0xff
+		argb[i] = p&0xff00ff00 | red<<16 | blue
+	}
+}
+
+// predict 为每个分块选择残差最小的预测模式，将像素替换为预测残差，返回预测模式图及其宽度
+func predict(argb []uint32, width, height int) ([]uint32, int) {
+	blockSize := 1 << vp8lPredictorBits
+	tilesX := (width + blockSize - 1) / blockSize
+	tilesY := (height + blockSize - 1) / blockSize
+	modes := make([]uint32, tilesX*tilesY)
+	residuals := make([]uint32, len(argb))
+
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			x0, y0 := tx*blockSize, ty*blockSize
+			x1, y1 := min(x0+blockSize, width), min(y0+blockSize, height)
+
+			best, bestCost := 0, -1
+			for mode := 0; mode < 14; mode++ {
+				cost := 0
+				for y := y0; y < y1 && (bestCost < 0 || cost < bestCost); y++ {
+					for x := x0; x < x1; x++ {
+						i := y*width + x
+						cost += residualCost(argb[i], predictPixel(argb, width, x, y, mode))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+
+			modes[ty*tilesX+tx] = 0xff000000 | uint32(best)<<8
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					i := y*width + x
+					residuals[i] = subPixels(argb[i], predictPixel(argb, width, x, y, best))
+				}
+			}
+		}
+	}
+	copy(argb, residuals)
+	return modes, tilesX
+}
+
+// predictPixel 计算像素的预测值，第一行使用左侧像素，第一列使用上方像素
+func predictPixel(argb []uint32, width, x, y, mode int) uint32 {
+	i := y*width + x
+	switch {
+	case x == 0 && y == 0:
+		return 0xff000000
+	case y == 0:
+		return argb[i-1]
+	case x == 0:
+		return argb[i-width]
+	}
+
+	// 最右一列的右上像素取当前行最左侧的像素，按行展开后恰好是i-width+1
+	left, top, topLeft, topRight := argb[i-1], argb[i-width], argb[i-width-1], argb[i-width+1]
+	switch mode {
+	case 0:
+		return 0xff000000
+	case 1:
+		return left
+	case 2:
+		return top
+	case 3:
+		return topRight
+	case 4:
+		return topLeft
+	case 5:
+		return average2(average2(left, topRight), top)
+	case 6:
+		return average2(left, topLeft)
+	case 7:
+		return average2(left, top)
+	case 8:
+		return average2(topLeft, top)
+	case 9:
+		return average2(top, topRight)
+	case 10:
+		return average2(average2(left, topLeft), average2(top, topRight))
+	case 11:
+		return selectPixel(left, top, topLeft)
+	case 12:
+		return clampAddSubtractFull(left, top, topLeft)
+	default:
+		return clampAddSubtractHalf(average2(left, top), topLeft)
+	}
+}
+
+// channel 取像素的第shift位开始的通道值
+func channel(p uint32, shift uint) int {
+	return int(p>>shift) & 0xff
+}
+
+// average2 逐通道求平均
+func average2(a, b uint32) uint32 {
+	var out uint32
+	for shift := uint(0); shift < 32; shift += 8 {
+		out |= uint32((channel(a, shift)+channel(b, shift))/2) << shift
+	}
+	return out
+}
+
+// selectPixel 选择左侧和上方像素中更接近梯度预测值的一个
+func selectPixel(left, top, topLeft uint32) uint32 {
+	distLeft, distTop := 0, 0
+	for shift := uint(0); shift < 32; shift += 8 {
+		estimate := channel(left, shift) + channel(top, shift) - channel(topLeft, shift)
+		distLeft += abs(estimate - channel(left, shift))
+		distTop += abs(estimate - channel(top, shift))
+	}
+	if distLeft < distTop {
+		return left
+	}
+	return top
+}
+
+// clampAddSubtractFull 逐通道计算a+b-c并截断到0~255
+func clampAddSubtractFull(a, b, c uint32) uint32 {
+	var out uint32
+	for shift := uint(0); shift < 32; shift += 8 {
+		out |= uint32(clamp255(channel(a, shift)+channel(b, shift)-channel(c, shift))) << shift
+	}
+	return out
+}
+
+// clampAddSubtractHalf 逐通道计算a+(a-b)/2并截断到0~255
+func clampAddSubtractHalf(a, b uint32) uint32 {
+	var out uint32
+	for shift := uint(0); shift < 32; shift += 8 {
+		ca := channel(a, shift)
+		out |= uint32(clamp255(ca+(ca-channel(b, shift))/2)) << shift
+	}
+	return out
+}
+
+// subPixels 逐通道相减，结果对256取模
+func subPixels(a, b uint32) uint32 {
+	var out uint32
+	for shift := uint(0); shift < 32; shift += 8 {
+		out |= uint32((channel(a, shift)-channel(b, shift))&0xff) << shift
+	}
+	return out
+}
+
+// residualCost 估算残差的编码代价，按有符号值的绝对值累加
+func residualCost(p, pred uint32) int {
+	cost := 0
+	for shift := uint(0); shift < 32; shift += 8 {
+		cost += abs(int(int8(channel(p, shift) - channel(pred, shift))))
+	}
+	return cost
+}
+
+func clamp255(v int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return v
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// vp8lToken LZ77编码结果，length为0时表示一个像素
+type vp8lToken struct {
+	pixel    uint32
+	length   int
+	distance int
+}
+
+// writeEntropyImage 编码像素数据，主图需要写入元前缀码标志
+func writeEntropyImage(bw *bitWriter, argb []uint32, width int, isMain bool) {
+	bw.writeBits(0, 1) // 不使用颜色缓存
+	if isMain {
+		bw.writeBits(0, 1) // 所有像素共用一组前缀码
+	}
+
+	tokens := backwardReferences(argb, width)
+
+	// green、red、blue、alpha、distance五个前缀码
+	histograms := [5][]int{
+		make([]int, vp8lNumLiteralCodes+vp8lNumLengthCodes),
+		make([]int, vp8lNumLiteralCodes),
+		make([]int, vp8lNumLiteralCodes),
+		make([]int, vp8lNumLiteralCodes),
+		make([]int, vp8lNumDistanceCodes),
+	}
+	for _, t := range tokens {
+		if t.length == 0 {
+			histograms[0][(t.pixel>>8)&0xff]++
+			histograms[1][(t.pixel>>16)&0xff]++
+			histograms[2][t.pixel&0xff]++
+			histograms[3][t.pixel>>24]++
+			continue
+		}
+		code, _, _ := prefixEncode(t.length)
+		histograms[0][vp8lNumLiteralCodes+code]++
+		code, _, _ = prefixEncode(t.distance + vp8lDistanceBias)
+		histograms[4][code]++
+	}
+
+	var codes [5]*prefixCode
+	for i, histogram := range histograms {
+		codes[i] = writePrefixCode(bw, histogram)
+	}
+
+	for _, t := range tokens {
+		if t.length == 0 {
+			codes[0].write(bw, int(t.pixel>>8)&0xff)
+			codes[1].write(bw, int(t.pixel>>16)&0xff)
+			codes[2].write(bw, int(t.pixel&0xff))
+			codes[3].write(bw, int(t.pixel>>24))
+			continue
+		}
+		code, extraBits, extra := prefixEncode(t.length)
+		codes[0].write(bw, vp8lNumLiteralCodes+code)
+		bw.writeBits(extra, extraBits)
+		code, extraBits, extra = prefixEncode(t.distance + vp8lDistanceBias)
+		codes[4].write(bw, code)
+		bw.writeBits(extra, extraBits)
+	}
+}
+
+// backwardReferences 用哈希链查找重复的像素序列，生成LZ77编码结果
+func backwardReferences(argb []uint32, width int) []vp8lToken {
+	n := len(argb)
+	head := make([]int32, 1<<vp8lHashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	chain := make([]int32, n)
+	hashAt := func(i int) uint32 {
+		key := uint64(argb[i])<<32 | uint64(argb[i+1])
+		return uint32((key * 0x9E3779B97F4A7C15) >> (64 - vp8lHashBits))
+	}
+	insert := func(i int) {
+		if i+1 < n {
+			h := hashAt(i)
+			chain[i] = head[h]
+			head[h] = int32(i)
+		}
+	}
+	matchLength := func(i, candidate int) int {
+		maxLength := min(n-i, vp8lMaxLength)
+		length := 0
+		for length < maxLength && argb[candidate+length] == argb[i+length] {
+			length++
+		}
+		return length
+	}
+
+	tokens := make([]vp8lToken, 0, n/2)
+	for i := 0; i < n; {
+		bestLength, bestDistance := 0, 0
+		if i+1 < n {
+			// 优先尝试左侧和上方像素，这两个距离的编码代价最小
+			for _, distance := range []int{1, width} {
+				if distance > 0 && distance <= i && distance <= vp8lMaxDistance {
+					if length := matchLength(i, i-distance); length > bestLength {
+						bestLength, bestDistance = length, distance
+					}
+				}
+			}
+			candidate := head[hashAt(i)]
+			for depth := 0; candidate >= 0 && i-int(candidate) <= vp8lMaxDistance &&
+				depth < vp8lMaxChain && bestLength < vp8lMaxLength; depth++ {
+				if length := matchLength(i, int(candidate)); length > bestLength {
+					bestLength, bestDistance = length, i-int(candidate)
+				}
+				candidate = chain[candidate]
+			}
+		}
+
+		if bestLength >= vp8lMinLength {
+			tokens = append(tokens, vp8lToken{length: bestLength, distance: bestDistance})
+			for j := i; j < i+bestLength; j++ {
+				insert(j)
+			}
+			i += bestLength
+			continue
+		}
+		tokens = append(tokens, vp8lToken{pixel: argb[i]})
+		insert(i)
+		i++
+	}
+	return tokens
+}
+
+// prefixEncode 将长度或距离编码为前缀码、额外位数和额外位的值
+func prefixEncode(value int) (int, uint, uint32) {
+	d := value - 1
+	if d < 4 {
+		return d, 0, 0
+	}
+	highest := 0
+	for v := d; v > 1; v >>= 1 {
+		highest++
+	}
+	second := (d >> (highest - 1)) & 1
+	extraBits := uint(highest - 1)
+	return 2*highest + second, extraBits, uint32(d & (1<<extraBits - 1))
+}
+
+// prefixCode 范式哈夫曼编码，codes为按位反转后的码字
+type prefixCode struct {
+	lengths []int
+	codes   []uint32
+}
+
+// write 写入符号，只有一个符号的编码不占用位
+func (p *prefixCode) write(bw *bitWriter, symbol int) {
+	bw.writeBits(p.codes[symbol], uint(p.lengths[symbol]))
+}
+
+// writePrefixCode 根据直方图生成前缀码并写入码表
+func writePrefixCode(bw *bitWriter, histogram []int) *prefixCode {
+	var used []int
+	for symbol, count := range histogram {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+	code := &prefixCode{lengths: make([]int, len(histogram)), codes: make([]uint32, len(histogram))}
+
+	// 不超过两个符号且符号值小于256时使用简单码表
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < 256) {
+		bw.writeBits(1, 1)
+		if len(used) == 0 {
+			used = []int{0}
+		}
+		bw.writeBits(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			bw.writeBits(0, 1)
+			bw.writeBits(uint32(used[0]), 1)
+		} else {
+			bw.writeBits(1, 1)
+			bw.writeBits(uint32(used[0]), 8)
+		}
+		if len(used) == 2 {
+			bw.writeBits(uint32(used[1]), 8)
+			code.lengths[used[0]], code.lengths[used[1]] = 1, 1
+			code.codes[used[1]] = 1
+		}
+		return code
+	}
+
+	// 只有一个符号时补充一个符号，保证码表完整
+	if len(used) == 1 {
+		dummy := 0
+		if used[0] == 0 {
+			dummy = 1
+		}
+		histogram = append([]int(nil), histogram...)
+		histogram[dummy] = 1
+	}
+	code.lengths = huffmanLengths(histogram, vp8lMaxCodeLength)
+	code.codes = canonicalCodes(code.lengths)
+
+	// 码长序列的游程编码：0~15为码长，16重复上一个非零码长，17和18重复0
+	type clToken struct {
+		symbol    int
+		extraBits uint
+		extra     uint32
+	}
+	var tokens []clToken
+	lengths := code.lengths
+	for i := 0; i < len(lengths); {
+		value := lengths[i]
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == value {
+			run++
+		}
+		i += run
+		if value == 0 {
+			for run > 0 {
+				switch {
+				case run >= 11:
+					n := min(run, 138)
+					tokens = append(tokens, clToken{18, 7, uint32(n - 11)})
+					run -= n
+				case run >= 3:
+					tokens = append(tokens, clToken{17, 3, uint32(run - 3)})
+					run = 0
+				default:
+					tokens = append(tokens, clToken{0, 0, 0})
+					run--
+				}
+			}
+			continue
+		}
+		tokens = append(tokens, clToken{value, 0, 0})
+		run--
+		for run > 0 {
+			if run >= 3 {
+				n := min(run, 6)
+				tokens = append(tokens, clToken{16, 2, uint32(n - 3)})
+				run -= n
+			} else {
+				tokens = append(tokens, clToken{value, 0, 0})
+				run--
+			}
+		}
+	}
+
+	clHistogram := make([]int, 19)
+	clUsed := 0
+	for _, t := range tokens {
+		if clHistogram[t.symbol] == 0 {
+			clUsed++
+		}
+		clHistogram[t.symbol]++
+	}
+	if clUsed == 1 {
+		if tokens[0].symbol == 0 {
+			clHistogram[1] = 1
+		} else {
+			clHistogram[0] = 1
+		}
+	}
+	clLengths := huffmanLengths(clHistogram, 7)
+	clCodes := canonicalCodes(clLengths)
+
+	numCodes := 4
+	for i := len(vp8lCodeLengthOrder) - 1; i >= 4; i-- {
+		if clLengths[vp8lCodeLengthOrder[i]] != 0 {
+			numCodes = i + 1
+			break
+		}
+	}
+	bw.writeBits(0, 1) // 普通码表
+	bw.writeBits(uint32(numCodes-4), 4)
+	for i := 0; i < numCodes; i++ {
+		bw.writeBits(uint32(clLengths[vp8lCodeLengthOrder[i]]), 3)
+	}
+	bw.writeBits(0, 1) // 码长数量等于字母表大小
+	for _, t := range tokens {
+		bw.writeBits(clCodes[t.symbol], uint(clLengths[t.symbol]))
+		bw.writeBits(t.extra, t.extraBits)
+	}
+	return code
+}
+
+// huffmanLengths 计算不超过maxLength的哈夫曼码长
+// 码长超限时提高低频符号的计数后重新构建，保证码表始终完整
+func huffmanLengths(histogram []int, maxLength int) []int {
+	for minCount := 1; ; minCount *= 2 {
+		type node struct {
+			count       int
+			symbol      int
+			left, right int
+		}
+		var nodes []node
+		for symbol, count := range histogram {
+			if count > 0 {
+				nodes = append(nodes, node{count: max(count, minCount), symbol: symbol, left: -1, right: -1})
+			}
+		}
+		lengths := make([]int, len(histogram))
+		if len(nodes) == 1 {
+			lengths[nodes[0].symbol] = 1
+			return lengths
+		}
+
+		// 按计数排序后用两个队列合并，叶子队列和内部节点队列都保持有序
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].count < nodes[j].count })
+		leaves := len(nodes)
+		leaf, inner := 0, leaves
+		pick := func() int {
+			if leaf < leaves && (inner >= len(nodes) || nodes[leaf].count <= nodes[inner].count) {
+				leaf++
+				return leaf - 1
+			}
+			inner++
+			return inner - 1
+		}
+		for i := 0; i < leaves-1; i++ {
+			a := pick()
+			b := pick()
+			nodes = append(nodes, node{count: nodes[a].count + nodes[b].count, symbol: -1, left: a, right: b})
+		}
+
+		depths := make([]int, len(nodes))
+		tooLong := false
+		for i := len(nodes) - 1; i >= 0; i-- {
+			if nodes[i].left >= 0 {
+				depths[nodes[i].left] = depths[i] + 1
+				depths[nodes[i].right] = depths[i] + 1
+				continue
+			}
+			if depths[i] > maxLength {
+				tooLong = true
+			}
+			lengths[nodes[i].symbol] = depths[i]
+		}
+		if !tooLong {
+			return lengths
+		}
+	}
+}
+
+// canonicalCodes 按码长分配范式哈夫曼码字，并按位反转以便低位优先写入
+func canonicalCodes(lengths []int) []uint32 {
+	var counts [vp8lMaxCodeLength + 1]uint32
+	for _, length := range lengths {
+		if length > 0 {
+			counts[length]++
+		}
+	}
+	var next [vp8lMaxCodeLength + 2]uint32
+	code := uint32(0)
+	for length := 1; length <= vp8lMaxCodeLength; length++ {
+		code = (code + counts[length-1]) << 1
+		next[length] = code
+	}
+
+	codes := make([]uint32, len(lengths))
+	for symbol, length := range lengths {
+		if length == 0 {
+			continue
+		}
+		c := next[length]
+		next[length]++
+		reversed := uint32(0)
+		for i := 0; i < length; i++ {
+			reversed = reversed<<1 | (c>>i)&1
+		}
+		codes[symbol] = reversed
+	}
+	return codes
+}
+
+// bitWriter 低位优先的位写入器
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+// writeBits 写入value的低n位
+func (b *bitWriter) writeBits(value uint32, n uint) {
+	if n == 0 {
+		return
+	}
+	b.acc |= uint64(value&(1<<n-1)) << b.nbits
+	b.nbits += n
+	for b.nbits >= 8 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc >>= 8
+		b.nbits -= 8
+	}
+}
+
+// bytes 补齐最后一个字节并返回写入的数据
+func (b *bitWriter) bytes() []byte {
+	if b.nbits > 0 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc, b.nbits = 0, 0
+	}
+	return b.buf
+}
@@ -0,0 +1,91 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// webpTestImages 覆盖渐变、透明、随机噪点、重复图案和极端尺寸的测试图片
+func webpTestImages() map[string]*image.NRGBA {
+	images := make(map[string]*image.NRGBA)
+	fill := func(name string, width, height int, pixel func(x, y int) color.NRGBA) {
+		img := image.NewNRGBA(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				img.SetNRGBA(x, y, pixel(x, y))
+			}
+		}
+		images[name] = img
+	}
+
+	fill("single pixel", 1, 1, func(x, y int) color.NRGBA { return color.NRGBA{12, 34, 56, 255} })
+	fill("gradient", 64, 48, func(x, y int) color.NRGBA {
+		return color.NRGBA{uint8(x * 4), uint8(y * 5), uint8(x + y), 255}
+	})
+	fill("alpha", 40, 30, func(x, y int) color.NRGBA {
+		return color.NRGBA{uint8(x * 6), 200, uint8(y * 8), uint8((x + y) * 4)}
+	})
+	r := rand.New(rand.NewSource(1))
+	fill("noise", 37, 23, func(x, y int) color.NRGBA {
+		return color.NRGBA{uint8(r.Intn(256)), uint8(r.Intn(256)), uint8(r.Intn(256)), 255}
+	})
+	fill("pattern", 200, 120, func(x, y int) color.NRGBA {
+		if (x/8+y/8)%2 == 0 {
+			return color.NRGBA{255, 255, 255, 255}
+		}
+		return color.NRGBA{20, 40, 200, 255}
+	})
+	fill("flat", 300, 200, func(x, y int) color.NRGBA { return color.NRGBA{90, 90, 90, 255} })
+	fill("column", 1, 97, func(x, y int) color.NRGBA { return color.NRGBA{uint8(y), 0, 255 - uint8(y), 255} })
+	return images
+}
+
+// TestEncodeWebPRoundTrip 编码结果使用golang.org/x/image/webp解码后应与原图逐像素一致
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	for name, img := range webpTestImages() {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := EncodeWebP(&buf, img); err != nil {
+				t.Fatal(err)
+			}
+			if DetectFormat(buf.Bytes()) != FormatWebP {
+				t.Fatal("output is not recognised as WebP")
+			}
+			decoded, err := webp.Decode(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("webp.Decode: %v", err)
+			}
+			if decoded.Bounds() != img.Bounds() {
+				t.Fatalf("bounds = %v, want %v", decoded.Bounds(), img.Bounds())
+			}
+			for y := 0; y < img.Bounds().Dy(); y++ {
+				for x := 0; x < img.Bounds().Dx(); x++ {
+					got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+					if want := img.NRGBAAt(x, y); got != want {
+						t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+
+			// 去除元数据后仍是有效的WebP
+			if _, err := webp.Decode(bytes.NewReader(stripWebP(buf.Bytes()))); err != nil {
+				t.Errorf("decode after stripWebP: %v", err)
+			}
+		})
+	}
+}
+
+func TestEncodeWebPInvalidSize(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, image.NewNRGBA(image.Rect(0, 0, 0, 10))); err == nil {
+		t.Error("empty image should be rejected")
+	}
+	if err := EncodeWebP(&buf, image.NewNRGBA(image.Rect(0, 0, vp8lMaxDimension+1, 1))); err == nil {
+		t.Error("image wider than 16384 should be rejected")
+	}
+}
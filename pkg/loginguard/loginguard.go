@@ -0,0 +1,209 @@
+// Package loginguard 登录防暴力破解，按账号和IP记录连续失败次数，
+// 超过允许的次数后按指数退避临时锁定。失败状态保存在内存中，可以接入持久化存储在重启后保留锁定
+package loginguard
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBackoffShift 退避倍数的上限，避免移位溢出
+const maxBackoffShift = 20
+
+// State 某个账号或IP的失败状态
+type State struct {
+	Failures    int       `json:"failures"`    // 连续失败次数
+	LastFailure time.Time `json:"lastFailure"` // 最近一次失败时间
+	LockedUntil time.Time `json:"lockedUntil"` // 锁定截止时间，零值表示未锁定
+	ExpiresAt   time.Time `json:"expiresAt"`   // 状态过期时间，过期后重新计数
+}
+
+// Locked 在指定时间是否处于锁定状态，返回剩余锁定时间
+func (s *State) Locked(now time.Time) (time.Duration, bool) {
+	if s == nil || !now.Before(s.LockedUntil) {
+		return 0, false
+	}
+	return s.LockedUntil.Sub(now), true
+}
+
+// Store 失败状态存储，Get在状态不存在时返回nil
+type Store interface {
+	Get(key string) (*State, error)
+	Set(key string, state *State) error
+	Delete(key string) error
+}
+
+// Policy 锁定策略
+type Policy struct {
+	MaxAttempts int           // 允许的连续失败次数，达到后开始锁定
+	BaseLockout time.Duration // 首次锁定时长，之后每次失败翻倍
+	MaxLockout  time.Duration // 最长锁定时长
+	Window      time.Duration // 没有新的失败超过该时间后重新计数
+}
+
+// lockout 第failures次失败后的锁定时长，未达到允许次数时为0
+func (p Policy) lockout(failures int) time.Duration {
+	if p.MaxAttempts <= 0 || failures < p.MaxAttempts {
+		return 0
+	}
+	shift := failures - p.MaxAttempts
+	if shift > maxBackoffShift {
+		return p.MaxLockout
+	}
+	lock := p.BaseLockout << shift
+	if p.MaxLockout > 0 && lock > p.MaxLockout {
+		lock = p.MaxLockout
+	}
+	return lock
+}
+
+// Guard 登录防护，账号和IP分别使用各自的策略
+type Guard struct {
+	memory  *MemoryStore
+	backend Store
+	account Policy
+	ip      Policy
+	mu      sync.Mutex
+}
+
+// New 创建登录防护，backend为nil时只在内存中保存状态
+func New(account, ip Policy, backend Store) *Guard {
+	return &Guard{
+		memory:  NewMemoryStore(),
+		backend: backend,
+		account: account,
+		ip:      ip,
+	}
+}
+
+// AccountKey 账号的状态键，账号不区分大小写
+func AccountKey(account string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+// IPKey IP的状态键
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check 检查账号和IP是否被锁定，返回剩余锁定时间，为0时允许登录
+// 持久化存储读取失败时仍返回内存中的结果和错误
+func (g *Guard) Check(account, ip string) (time.Duration, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	var wait time.Duration
+	var firstErr error
+	for _, key := range []string{AccountKey(account), IPKey(ip)} {
+		state, err := g.load(key, now)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if remaining, locked := state.Locked(now); locked && remaining > wait {
+			wait = remaining
+		}
+	}
+	return wait, firstErr
+}
+
+// Fail 记录一次登录失败，返回因此产生的锁定时间，为0时未锁定
+func (g *Guard) Fail(account, ip string) (time.Duration, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	var wait time.Duration
+	var firstErr error
+	record := func(key string, policy Policy) {
+		state, err := g.load(key, now)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if state == nil {
+			state = &State{}
+		}
+		state.Failures++
+		state.LastFailure = now
+		if lock := policy.lockout(state.Failures); lock > 0 {
+			state.LockedUntil = now.Add(lock)
+			if lock > wait {
+				wait = lock
+			}
+		}
+		state.ExpiresAt = now.Add(policy.Window)
+		if state.LockedUntil.After(state.ExpiresAt) {
+			state.ExpiresAt = state.LockedUntil
+		}
+		if err := g.save(key, state); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	record(AccountKey(account), g.account)
+	record(IPKey(ip), g.ip)
+	return wait, firstErr
+}
+
+// Succeed 登录成功后清除账号的失败记录，IP的记录保留，防止用自己的账号重置IP计数
+func (g *Guard) Succeed(account string) error {
+	return g.Unlock(AccountKey(account))
+}
+
+// Unlock 清除指定键的失败记录和锁定
+func (g *Guard) Unlock(key string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.memory.Delete(key)
+	if g.backend != nil {
+		return g.backend.Delete(key)
+	}
+	return nil
+}
+
+// State 读取指定键当前的失败状态，没有记录时返回nil
+func (g *Guard) State(key string) (*State, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	state, err := g.load(key, time.Now())
+	if state == nil || state.Failures == 0 {
+		return nil, err
+	}
+	copied := *state
+	return &copied, err
+}
+
+// load 读取状态，内存中没有时从持久化存储读取并缓存，过期的状态视为不存在；调用方需持有锁
+func (g *Guard) load(key string, now time.Time) (*State, error) {
+	if state, ok := g.memory.lookup(key, now); ok {
+		return state, nil
+	}
+	if g.backend == nil {
+		return nil, nil
+	}
+	state, err := g.backend.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if state != nil && !now.Before(state.ExpiresAt) {
+		state = nil
+	}
+	// 没有记录时也缓存空状态，避免每次登录都查询持久化存储
+	cached := state
+	if cached == nil {
+		cached = &State{ExpiresAt: now.Add(g.account.Window)}
+	}
+	g.memory.Set(key, cached)
+	return state, nil
+}
+
+// save 写入内存和持久化存储；调用方需持有锁
+func (g *Guard) save(key string, state *State) error {
+	g.memory.Set(key, state)
+	if g.backend != nil {
+		return g.backend.Set(key, state)
+	}
+	return nil
+}
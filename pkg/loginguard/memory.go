@@ -0,0 +1,69 @@
+package loginguard
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval 内存存储清理过期状态的最小间隔
+const sweepInterval = time.Minute
+
+// MemoryStore 内存中的失败状态存储，过期的状态在写入时定期清理
+type MemoryStore struct {
+	mu        sync.Mutex
+	items     map[string]*State
+	lastSweep time.Time
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: make(map[string]*State)}
+}
+
+// Get 读取状态，不存在或已过期时返回nil
+func (m *MemoryStore) Get(key string) (*State, error) {
+	state, _ := m.lookup(key, time.Now())
+	return state, nil
+}
+
+// Set 写入状态
+func (m *MemoryStore) Set(key string, state *State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		for k, item := range m.items {
+			if !now.Before(item.ExpiresAt) {
+				delete(m.items, k)
+			}
+		}
+		m.lastSweep = now
+	}
+	m.items[key] = state
+	return nil
+}
+
+// Delete 删除状态
+func (m *MemoryStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, key)
+	return nil
+}
+
+// lookup 读取状态，第二个返回值表示内存中是否有未过期的记录
+func (m *MemoryStore) lookup(key string, now time.Time) (*State, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+	if !now.Before(state.ExpiresAt) {
+		delete(m.items, key)
+		return nil, false
+	}
+	return state, true
+}
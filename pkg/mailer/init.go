@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"fmt"
+	"matuto-blog/config"
+	"matuto-blog/pkg/logger"
+	"time"
+)
+
+// defaultMailer 全局邮件服务，未初始化时为nil，不发送邮件
+var defaultMailer *Mailer
+
+// InitMailer 根据配置初始化全局邮件服务
+func InitMailer() error {
+	driver := config.GetString("mail.driver")
+	if driver == "" {
+		driver = "log"
+	}
+	logger.Info("Mail driver:", driver)
+
+	var sender Sender
+	var err error
+	switch driver {
+	case "smtp":
+		sender, err = NewSMTPSender(SMTPConfig{
+			Host:     config.GetString("mail.smtp.host"),
+			Port:     config.GetInt("mail.smtp.port"),
+			Username: config.GetString("mail.smtp.username"),
+			Password: config.GetString("mail.smtp.password"),
+			Security: config.GetString("mail.smtp.security"),
+			Timeout:  time.Duration(config.GetInt("mail.smtp.timeout_seconds")) * time.Second,
+		})
+	case "file":
+		sender, err = NewFileSender(config.GetString("mail.file_path"))
+	case "log":
+		sender = LogSender{}
+	case "none":
+		defaultMailer = nil
+		return nil
+	default:
+		return fmt.Errorf("不支持的邮件发送方式: %s", driver)
+	}
+	if err != nil {
+		return fmt.Errorf("初始化邮件发送器失败: %v", err)
+	}
+
+	dir := config.GetString("mail.template_path")
+	if !templateDirExists(dir) {
+		return fmt.Errorf("邮件模板目录不存在: %s", dir)
+	}
+	m, err := New(sender, config.GetString("mail.from"), dir)
+	if err != nil {
+		return err
+	}
+	defaultMailer = m
+	return nil
+}
+
+// Default 获取全局邮件服务，未启用时返回nil
+func Default() *Mailer {
+	return defaultMailer
+}
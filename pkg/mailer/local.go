@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"matuto-blog/pkg/logger"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileSender 将邮件保存为.eml文件，用于本地测试
+type FileSender struct {
+	dir string
+}
+
+// NewFileSender 创建文件发送器
+func NewFileSender(dir string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建邮件目录失败: %w", err)
+	}
+	return &FileSender{dir: dir}, nil
+}
+
+// Send 保存邮件
+func (s *FileSender) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return fmt.Errorf("编码邮件失败: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000"), strings.Join(msg.To, "_"))
+	name = strings.NewReplacer("/", "_", "\\", "_", " ", "", "<", "", ">", "", "\"", "").Replace(name)
+	return os.WriteFile(filepath.Join(s.dir, name), data, 0644)
+}
+
+// GetSenderType 获取发送器类型
+func (s *FileSender) GetSenderType() string {
+	return "file"
+}
+
+// LogSender 只在日志中输出邮件内容，用于本地测试
+type LogSender struct{}
+
+// Send 输出邮件
+func (LogSender) Send(ctx context.Context, msg *Message) error {
+	logger.WithField("to", msg.To).
+		WithField("subject", msg.Subject).
+		Info("Mail:\n" + msg.Text)
+	return nil
+}
+
+// GetSenderType 获取发送器类型
+func (LogSender) GetSenderType() string {
+	return "log"
+}
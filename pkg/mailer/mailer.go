@@ -0,0 +1,135 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Message 邮件
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+	Headers map[string]string // 额外的邮件头，如List-Unsubscribe
+}
+
+// Sender 邮件发送器
+type Sender interface {
+	// Send 发送邮件
+	Send(ctx context.Context, msg *Message) error
+
+	// GetSenderType 获取发送器类型
+	GetSenderType() string
+}
+
+// Recipients 收件人地址，不含显示名称
+func (m *Message) Recipients() ([]string, error) {
+	recipients := make([]string, 0, len(m.To))
+	for _, to := range m.To {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return nil, fmt.Errorf("收件人地址无效: %s", to)
+		}
+		recipients = append(recipients, addr.Address)
+	}
+	return recipients, nil
+}
+
+// Bytes 按RFC 5322编码邮件，正文为multipart/alternative的纯文本和HTML
+func (m *Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	body := multipart.NewWriter(&buf)
+
+	to := make([]string, 0, len(m.To))
+	for _, addr := range m.To {
+		to = append(to, encodeAddress(addr))
+	}
+
+	headers := map[string]string{
+		"From":         encodeAddress(m.From),
+		"To":           strings.Join(to, ", "),
+		"Subject":      mime.BEncoding.Encode("UTF-8", m.Subject),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"Message-ID":   messageId(m.From),
+		"MIME-Version": "1.0",
+		"Content-Type": `multipart/alternative; boundary="` + body.Boundary() + `"`,
+	}
+	for key, value := range m.Headers {
+		headers[key] = value
+	}
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var out bytes.Buffer
+	for _, key := range keys {
+		out.WriteString(key + ": " + headers[key] + "\r\n")
+	}
+	out.WriteString("\r\n")
+
+	parts := []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", m.Text},
+		{"text/html; charset=UTF-8", m.HTML},
+	}
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+		writer, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	out.Write(buf.Bytes())
+	return out.Bytes(), nil
+}
+
+// messageId 生成邮件ID
+func messageId(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domain = addr.Address[at+1:]
+		}
+	}
+	random := make([]byte, 12)
+	rand.Read(random)
+	return "<" + hex.EncodeToString(random) + "@" + domain + ">"
+}
+
+// encodeAddress 编码地址中的非ASCII显示名称，无法解析的地址原样返回
+func encodeAddress(address string) string {
+	addr, err := mail.ParseAddress(address)
+	if err != nil {
+		return address
+	}
+	return addr.String()
+}
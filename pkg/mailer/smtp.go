@@ -0,0 +1,138 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTP加密方式
+const (
+	SMTPSecurityNone     = "none"     // 不加密
+	SMTPSecurityStartTLS = "starttls" // 明文连接后升级为TLS，服务器支持时使用
+	SMTPSecuritySSL      = "ssl"      // 直接使用TLS连接，通常为465端口
+)
+
+// SMTPConfig SMTP配置
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Security string
+	Timeout  time.Duration
+}
+
+// SMTPSender 通过SMTP服务器发送邮件
+type SMTPSender struct {
+	config SMTPConfig
+}
+
+// NewSMTPSender 创建SMTP发送器
+func NewSMTPSender(config SMTPConfig) (*SMTPSender, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("SMTP服务器地址不能为空")
+	}
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	if config.Security == "" {
+		config.Security = SMTPSecurityStartTLS
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &SMTPSender{config: config}, nil
+}
+
+// Send 发送邮件
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	recipients, err := msg.Recipients()
+	if err != nil {
+		return err
+	}
+	from, err := (&Message{To: []string{msg.From}}).Recipients()
+	if err != nil {
+		return fmt.Errorf("发件人地址无效: %s", msg.From)
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		return fmt.Errorf("编码邮件失败: %w", err)
+	}
+
+	client, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("连接SMTP服务器失败: %w", err)
+	}
+	defer client.Close()
+
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP认证失败: %w", err)
+		}
+	}
+	if err := client.Mail(from[0]); err != nil {
+		return fmt.Errorf("设置发件人失败: %w", err)
+	}
+	for _, to := range recipients {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("设置收件人失败: %w", err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return client.Quit()
+}
+
+// dial 连接SMTP服务器并按配置启用TLS
+func (s *SMTPSender) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+	tlsConfig := &tls.Config{ServerName: s.config.Host}
+
+	var conn net.Conn
+	var err error
+	if s.config.Security == SMTPSecuritySSL {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(s.config.Timeout))
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if s.config.Security == SMTPSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return nil, err
+			}
+		}
+	}
+	return client, nil
+}
+
+// GetSenderType 获取发送器类型
+func (s *SMTPSender) GetSenderType() string {
+	return "smtp"
+}
@@ -0,0 +1,120 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"html/template"
+	"matuto-blog/pkg/fulltext"
+	"matuto-blog/pkg/logger"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Mailer 使用模板生成邮件并发送
+// 每个模板文件需定义subject和html两个模板，可选定义text作为纯文本正文
+type Mailer struct {
+	sender    Sender
+	from      string
+	templates map[string]*template.Template
+}
+
+// New 创建邮件服务，加载dir下所有.html模板，模板名为不含扩展名的文件名
+func New(sender Sender, from, dir string) (*Mailer, error) {
+	m := &Mailer{sender: sender, from: from, templates: make(map[string]*template.Template)}
+	if dir == "" {
+		return m, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		tmpl, err := template.ParseFiles(file)
+		if err != nil {
+			return nil, fmt.Errorf("解析邮件模板失败: %w", err)
+		}
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		for _, required := range []string{"subject", "html"} {
+			if tmpl.Lookup(required) == nil {
+				return nil, fmt.Errorf("邮件模板 %s 缺少 %s 定义", name, required)
+			}
+		}
+		m.templates[name] = tmpl
+	}
+	return m, nil
+}
+
+// Render 渲染模板生成邮件
+func (m *Mailer) Render(name string, data interface{}) (*Message, error) {
+	tmpl, ok := m.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("邮件模板 '%s' 不存在", name)
+	}
+
+	execute := func(block string) (string, error) {
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, block, data); err != nil {
+			return "", fmt.Errorf("渲染邮件模板失败: %w", err)
+		}
+		return strings.TrimSpace(buf.String()), nil
+	}
+
+	msg := &Message{From: m.from}
+	var err error
+	if msg.Subject, err = execute("subject"); err != nil {
+		return nil, err
+	}
+	if msg.HTML, err = execute("html"); err != nil {
+		return nil, err
+	}
+	if tmpl.Lookup("text") != nil {
+		if msg.Text, err = execute("text"); err != nil {
+			return nil, err
+		}
+		// 纯文本正文不需要HTML转义
+		msg.Text = html.UnescapeString(msg.Text)
+	} else {
+		msg.Text = fulltext.PlainText(msg.HTML)
+	}
+	// 主题中的HTML转义字符还原为原文
+	msg.Subject = fulltext.PlainText(msg.Subject)
+	return msg, nil
+}
+
+// Send 渲染模板并发送给收件人
+func (m *Mailer) Send(ctx context.Context, to []string, name string, data interface{}, headers map[string]string) error {
+	msg, err := m.Render(name, data)
+	if err != nil {
+		return err
+	}
+	msg.To = to
+	msg.Headers = headers
+	return m.sender.Send(ctx, msg)
+}
+
+// SendAsync 在后台发送邮件，失败时记录日志
+func (m *Mailer) SendAsync(to []string, name string, data interface{}, headers map[string]string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := m.Send(ctx, to, name, data, headers); err != nil {
+			logger.Error("Failed to send mail", name, "to", to, ":", err)
+		}
+	}()
+}
+
+// GetSenderType 获取发送器类型
+func (m *Mailer) GetSenderType() string {
+	return m.sender.GetSenderType()
+}
+
+// templateDirExists 检查模板目录是否存在
+func templateDirExists(dir string) bool {
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,345 @@
+// Package resumable 实现分片上传的会话存储，会话状态和已接收的分片保存在磁盘上，
+// 服务重启或网络中断后客户端可以查询缺少的分片继续上传
+package resumable
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 会话目录中的文件
+const (
+	sessionFile = "session.json"
+	dataFile    = "data"
+)
+
+var (
+	ErrSessionNotFound  = errors.New("resumable: 上传会话不存在或已过期")
+	ErrInvalidSize      = errors.New("resumable: 文件或分片大小无效")
+	ErrChunkIndex       = errors.New("resumable: 分片序号超出范围")
+	ErrChunkSize        = errors.New("resumable: 分片大小不正确")
+	ErrChecksumMismatch = errors.New("resumable: 分片校验和不匹配")
+	ErrIncomplete       = errors.New("resumable: 分片未全部上传")
+	ErrTooManySessions  = errors.New("resumable: 未完成的上传会话过多")
+	ErrQuotaExceeded    = errors.New("resumable: 未完成上传的文件总大小超过限制")
+)
+
+// Session 分片上传会话，除最后一个分片外每个分片大小均为ChunkSize
+type Session struct {
+	ID          string    `json:"id"`
+	Filename    string    `json:"filename"`
+	Size        int64     `json:"size"`
+	ChunkSize   int64     `json:"chunkSize"`
+	TotalChunks int       `json:"totalChunks"`
+	Hash        string    `json:"hash,omitempty"` // 客户端声明的完整文件SHA-256，完成时校验
+	Owner       int       `json:"owner"`          // 创建会话的用户，其他用户不能继续上传
+	Uploaded    []int     `json:"uploaded"`       // 已接收的分片序号，从0开始
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ChunkLength 指定分片的大小
+func (s *Session) ChunkLength(index int) int64 {
+	if index == s.TotalChunks-1 {
+		return s.Size - int64(index)*s.ChunkSize
+	}
+	return s.ChunkSize
+}
+
+// Missing 尚未接收的分片序号
+func (s *Session) Missing() []int {
+	received := make(map[int]bool, len(s.Uploaded))
+	for _, index := range s.Uploaded {
+		received[index] = true
+	}
+	missing := []int{}
+	for i := 0; i < s.TotalChunks; i++ {
+		if !received[i] {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// Complete 是否已接收全部分片
+func (s *Session) Complete() bool {
+	return len(s.Uploaded) == s.TotalChunks
+}
+
+// Store 分片上传会话存储，每个会话一个目录，分片按偏移量直接写入同一个数据文件
+type Store struct {
+	dir         string
+	ttl         time.Duration
+	maxSessions int   // 每个用户未完成的会话数上限，0表示不限制
+	maxBytes    int64 // 每个用户未完成会话的文件总大小上限，0表示不限制
+	mu          sync.Mutex
+}
+
+// NewStore 创建会话存储，超过ttl没有更新的会话视为过期
+func NewStore(dir string, ttl time.Duration) (*Store, error) {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("resumable: 创建目录失败: %w", err)
+	}
+	return &Store{dir: dir, ttl: ttl}, nil
+}
+
+// SetOwnerLimits 设置每个用户未完成会话的数量和文件总大小上限，0表示不限制
+// 每个会话创建时都会按文件大小预分配数据文件，限制可以避免单个用户占满磁盘
+func (s *Store) SetOwnerLimits(maxSessions int, maxBytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxSessions = maxSessions
+	s.maxBytes = maxBytes
+}
+
+// Create 创建上传会话并预分配数据文件
+// 创建前清理过期的会话，用户未完成的会话超过数量或总大小上限时返回ErrTooManySessions或ErrQuotaExceeded
+func (s *Store) Create(filename string, size, chunkSize int64, hash string, owner int) (*Session, error) {
+	if size <= 0 || chunkSize <= 0 {
+		return nil, ErrInvalidSize
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, pending := 0, int64(0)
+	s.scan(func(session *Session) {
+		if session.Owner == owner {
+			sessions++
+			pending += session.Size
+		}
+	})
+	if s.maxSessions > 0 && sessions >= s.maxSessions {
+		return nil, ErrTooManySessions
+	}
+	if s.maxBytes > 0 && pending+size > s.maxBytes {
+		return nil, ErrQuotaExceeded
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &Session{
+		ID:          id,
+		Filename:    filename,
+		Size:        size,
+		ChunkSize:   chunkSize,
+		TotalChunks: int((size + chunkSize - 1) / chunkSize),
+		Hash:        strings.ToLower(hash),
+		Owner:       owner,
+		Uploaded:    []int{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	dir := s.sessionDir(id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	data, err := os.Create(filepath.Join(dir, dataFile))
+	if err == nil {
+		err = data.Truncate(size)
+		data.Close()
+	}
+	if err == nil {
+		err = s.save(session)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return session, nil
+}
+
+// Get 读取上传会话，会话不存在或已过期时返回ErrSessionNotFound
+func (s *Store) Get(id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(id)
+}
+
+// WriteChunk 校验并保存一个分片，checksum为分片内容的SHA-256十六进制值，返回更新后的会话
+// 重复上传同一分片会覆盖之前的内容
+func (s *Store) WriteChunk(id string, index int, reader io.Reader, checksum string) (*Session, error) {
+	session, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= session.TotalChunks {
+		return nil, ErrChunkIndex
+	}
+
+	// 先在锁外读取分片内容，多出一个字节用于判断是否超出分片大小
+	length := session.ChunkLength(index)
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, reader, length+1); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if int64(buf.Len()) != length {
+		return nil, ErrChunkSize
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), strings.TrimSpace(checksum)) {
+		return nil, ErrChecksumMismatch
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 读取分片期间会话可能已被其他请求更新或删除，重新加载
+	if session, err = s.load(id); err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(filepath.Join(s.sessionDir(id), dataFile), os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	_, err = data.WriteAt(buf.Bytes(), int64(index)*session.ChunkSize)
+	if closeErr := data.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if i := sort.SearchInts(session.Uploaded, index); i == len(session.Uploaded) || session.Uploaded[i] != index {
+		session.Uploaded = append(session.Uploaded, 0)
+		copy(session.Uploaded[i+1:], session.Uploaded[i:])
+		session.Uploaded[i] = index
+	}
+	session.UpdatedAt = time.Now()
+	if err := s.save(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Open 打开已接收全部分片的数据文件，调用方负责关闭
+func (s *Store) Open(id string) (*Session, *os.File, error) {
+	session, err := s.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !session.Complete() {
+		return session, nil, ErrIncomplete
+	}
+	file, err := os.Open(filepath.Join(s.sessionDir(id), dataFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, file, nil
+}
+
+// Remove 删除上传会话及其数据
+func (s *Store) Remove(id string) error {
+	if !validSessionID(id) {
+		return ErrSessionNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return os.RemoveAll(s.sessionDir(id))
+}
+
+// Cleanup 删除所有过期的会话，返回删除的数量
+func (s *Store) Cleanup() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scan(nil)
+}
+
+// scan 遍历所有会话，过期或损坏的会话会被删除，其余会话传给fn，返回删除的数量，调用方需持有锁
+func (s *Store) scan(fn func(*Session)) int {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0
+	}
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() || !validSessionID(entry.Name()) {
+			continue
+		}
+		session, err := s.load(entry.Name())
+		if err == ErrSessionNotFound {
+			removed++
+			continue
+		}
+		if err == nil && fn != nil {
+			fn(session)
+		}
+	}
+	return removed
+}
+
+// load 读取会话，过期或损坏的会话会被删除，调用方需持有锁
+func (s *Store) load(id string) (*Session, error) {
+	if !validSessionID(id) {
+		return nil, ErrSessionNotFound
+	}
+	dir := s.sessionDir(id)
+	content, err := os.ReadFile(filepath.Join(dir, sessionFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			os.RemoveAll(dir)
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	var session Session
+	if err := json.Unmarshal(content, &session); err != nil || time.Since(session.UpdatedAt) > s.ttl {
+		os.RemoveAll(dir)
+		return nil, ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+// save 写入会话状态，先写临时文件再替换，避免中断时留下不完整的状态
+func (s *Store) save(session *Session) error {
+	content, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.sessionDir(session.ID), sessionFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// sessionDir 会话目录
+func (s *Store) sessionDir(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+// newSessionID 生成随机会话ID
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// validSessionID 会话ID只能是32位十六进制字符，防止路径穿越
+func validSessionID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
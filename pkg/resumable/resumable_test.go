@@ -0,0 +1,204 @@
+package resumable
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// checksum 分片内容的SHA-256十六进制值
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// expire 将会话的更新时间改为ttl之前，使其过期
+func expire(t *testing.T, s *Store, id string) {
+	t.Helper()
+	session, err := s.load(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.UpdatedAt = time.Now().Add(-2 * s.ttl)
+	if err := s.save(session); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := NewStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestUploadAndOpen(t *testing.T) {
+	s := newTestStore(t)
+	content := []byte("0123456789abcdefghijXYZ")
+	session, err := s.Create("a.bin", int64(len(content)), 10, strings.ToUpper(checksum(content)), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.TotalChunks != 3 || session.ChunkLength(2) != 3 || session.Hash != checksum(content) {
+		t.Fatalf("unexpected session %+v", session)
+	}
+
+	// 乱序上传，重复上传同一分片不会重复记录
+	for _, index := range []int{2, 0, 0} {
+		chunk := content[index*10 : min(len(content), index*10+10)]
+		if session, err = s.WriteChunk(session.ID, index, bytes.NewReader(chunk), checksum(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(session.Uploaded, []int{0, 2}) || !reflect.DeepEqual(session.Missing(), []int{1}) {
+		t.Fatalf("Uploaded = %v, Missing = %v", session.Uploaded, session.Missing())
+	}
+	if _, _, err := s.Open(session.ID); !errors.Is(err, ErrIncomplete) {
+		t.Fatalf("Open incomplete = %v, want ErrIncomplete", err)
+	}
+
+	chunk := content[10:20]
+	if session, err = s.WriteChunk(session.ID, 1, bytes.NewReader(chunk), checksum(chunk)); err != nil {
+		t.Fatal(err)
+	}
+	if !session.Complete() {
+		t.Fatal("session should be complete")
+	}
+	_, file, err := s.Open(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(file)
+	file.Close()
+	if !bytes.Equal(got, content) {
+		t.Errorf("data = %q, want %q", got, content)
+	}
+
+	if err := s.Remove(session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(session.ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Get after Remove = %v", err)
+	}
+}
+
+func TestWriteChunkRejects(t *testing.T) {
+	s := newTestStore(t)
+	session, err := s.Create("a.bin", 25, 10, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := bytes.Repeat([]byte("x"), 10)
+	last := bytes.Repeat([]byte("y"), 5)
+
+	tests := []struct {
+		name     string
+		id       string
+		index    int
+		data     []byte
+		checksum string
+		err      error
+	}{
+		{"short chunk", session.ID, 0, full[:9], checksum(full[:9]), ErrChunkSize},
+		{"long chunk", session.ID, 0, append(full, 'x'), checksum(append(full, 'x')), ErrChunkSize},
+		{"long last chunk", session.ID, 2, full, checksum(full), ErrChunkSize},
+		{"checksum mismatch", session.ID, 0, full, checksum(last), ErrChecksumMismatch},
+		{"negative index", session.ID, -1, full, checksum(full), ErrChunkIndex},
+		{"index out of range", session.ID, 3, last, checksum(last), ErrChunkIndex},
+		{"unknown session", strings.Repeat("0", 32), 0, full, checksum(full), ErrSessionNotFound},
+		{"path traversal", "../" + session.ID, 0, full, checksum(full), ErrSessionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.WriteChunk(tt.id, tt.index, bytes.NewReader(tt.data), tt.checksum)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("WriteChunk error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	// 被拒绝的分片不会记录为已上传
+	session, _ = s.Get(session.ID)
+	if len(session.Uploaded) != 0 {
+		t.Errorf("Uploaded = %v, want empty", session.Uploaded)
+	}
+	if _, err := s.WriteChunk(session.ID, 2, bytes.NewReader(last), " "+strings.ToUpper(checksum(last))+" "); err != nil {
+		t.Errorf("checksum should ignore case and spaces: %v", err)
+	}
+}
+
+func TestCreateInvalidSize(t *testing.T) {
+	s := newTestStore(t)
+	for _, size := range [][2]int64{{0, 10}, {-1, 10}, {10, 0}} {
+		if _, err := s.Create("a.bin", size[0], size[1], "", 1); !errors.Is(err, ErrInvalidSize) {
+			t.Errorf("Create(%d, %d) = %v, want ErrInvalidSize", size[0], size[1], err)
+		}
+	}
+}
+
+func TestOwnerLimits(t *testing.T) {
+	s := newTestStore(t)
+	s.SetOwnerLimits(2, 100)
+
+	first, err := s.Create("a", 40, 10, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create("b", 70, 10, "", 1); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Create over quota = %v, want ErrQuotaExceeded", err)
+	}
+	if _, err := s.Create("b", 60, 10, "", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create("c", 1, 10, "", 1); !errors.Is(err, ErrTooManySessions) {
+		t.Fatalf("Create over session limit = %v, want ErrTooManySessions", err)
+	}
+	// 限制按用户计算
+	if _, err := s.Create("c", 1, 10, "", 2); err != nil {
+		t.Fatalf("other owner: %v", err)
+	}
+
+	// 创建会话时清理过期会话，过期会话不再占用限额
+	expire(t, s, first.ID)
+	if _, err := s.Create("c", 40, 10, "", 1); err != nil {
+		t.Fatalf("Create after expiry: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.dir, first.ID)); !os.IsNotExist(err) {
+		t.Error("expired session should be removed on create")
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	s := newTestStore(t)
+	active, _ := s.Create("a", 10, 10, "", 1)
+	stale, _ := s.Create("b", 10, 10, "", 1)
+	expire(t, s, stale.ID)
+	// 缺少状态文件的损坏会话同样被清理
+	broken := strings.Repeat("f", 32)
+	os.MkdirAll(filepath.Join(s.dir, broken), 0755)
+	// 不是会话的目录保持不变
+	os.MkdirAll(filepath.Join(s.dir, "other"), 0755)
+
+	if removed := s.Cleanup(); removed != 2 {
+		t.Errorf("Cleanup removed %d, want 2", removed)
+	}
+	if _, err := s.Get(active.ID); err != nil {
+		t.Errorf("active session removed: %v", err)
+	}
+	if _, err := s.Get(stale.ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("stale session = %v, want ErrSessionNotFound", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.dir, "other")); err != nil {
+		t.Error("non-session directory should be kept")
+	}
+}
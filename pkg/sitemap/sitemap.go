@@ -0,0 +1,129 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// MaxURLs 单个sitemap文件允许的最大URL数量
+const MaxURLs = 50000
+
+// ContentType sitemap内容类型
+const ContentType = "application/xml; charset=utf-8"
+
+// 更新频率常量
+const (
+	ChangeFreqDaily   = "daily"
+	ChangeFreqWeekly  = "weekly"
+	ChangeFreqMonthly = "monthly"
+)
+
+const xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// URL sitemap条目
+type URL struct {
+	Loc        string    // 访问地址
+	LastMod    time.Time // 最后修改时间
+	ChangeFreq string    // 更新频率
+	Priority   float64   // 优先级 0.0-1.0
+}
+
+// IndexEntry sitemap索引条目
+type IndexEntry struct {
+	Loc     string    // 子sitemap地址
+	LastMod time.Time // 最后修改时间
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	URLs    []xmlURL `xml:"url"`
+}
+
+type xmlURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Sitemaps []xmlSitemap `xml:"sitemap"`
+}
+
+type xmlSitemap struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// BuildURLSet 生成urlset文档
+func BuildURLSet(urls []URL) ([]byte, error) {
+	if len(urls) > MaxURLs {
+		return nil, fmt.Errorf("sitemap最多包含%d个URL，当前%d个", MaxURLs, len(urls))
+	}
+
+	doc := urlSet{Xmlns: xmlns, URLs: make([]xmlURL, 0, len(urls))}
+	for _, u := range urls {
+		item := xmlURL{Loc: u.Loc, ChangeFreq: u.ChangeFreq}
+		if !u.LastMod.IsZero() {
+			item.LastMod = u.LastMod.Format(time.RFC3339)
+		}
+		if u.Priority > 0 {
+			item.Priority = fmt.Sprintf("%.1f", u.Priority)
+		}
+		doc.URLs = append(doc.URLs, item)
+	}
+	return marshal(doc)
+}
+
+// BuildIndex 生成sitemapindex文档
+func BuildIndex(entries []IndexEntry) ([]byte, error) {
+	doc := sitemapIndex{Xmlns: xmlns, Sitemaps: make([]xmlSitemap, 0, len(entries))}
+	for _, entry := range entries {
+		item := xmlSitemap{Loc: entry.Loc}
+		if !entry.LastMod.IsZero() {
+			item.LastMod = entry.LastMod.Format(time.RFC3339)
+		}
+		doc.Sitemaps = append(doc.Sitemaps, item)
+	}
+	return marshal(doc)
+}
+
+// Split 按指定大小切分URL列表
+func Split(urls []URL, size int) [][]URL {
+	if size <= 0 || size > MaxURLs {
+		size = MaxURLs
+	}
+	var chunks [][]URL
+	for start := 0; start < len(urls); start += size {
+		end := start + size
+		if end > len(urls) {
+			end = len(urls)
+		}
+		chunks = append(chunks, urls[start:end])
+	}
+	return chunks
+}
+
+// LastModOf 获取URL列表中最晚的修改时间
+func LastModOf(urls []URL) time.Time {
+	var latest time.Time
+	for _, u := range urls {
+		if u.LastMod.After(latest) {
+			latest = u.LastMod
+		}
+	}
+	return latest
+}
+
+// marshal 序列化为带XML声明的文档
+func marshal(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,197 @@
+package spam
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"matuto-blog/pkg/fulltext"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// 训练样本类别
+const (
+	classHam = iota
+	classSpam
+)
+
+// classifierData 分类器持久化数据
+type classifierData struct {
+	Docs   [2]int            // 各类别的样本数
+	Tokens map[string][2]int // 各类别中出现该词的样本数
+}
+
+// Classifier 朴素贝叶斯分类器，由管理员的审核结果训练，数据持久化到本地文件
+type Classifier struct {
+	mu   sync.RWMutex
+	path string
+	data classifierData
+}
+
+// OpenClassifier 打开分类器数据文件，文件不存在时创建空分类器，path为空时仅保存在内存中
+func OpenClassifier(path string) (*Classifier, error) {
+	c := &Classifier{path: path, data: classifierData{Tokens: make(map[string][2]int)}}
+	if path == "" {
+		return c, nil
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开分类器文件失败: %w", err)
+	}
+	defer file.Close()
+
+	if err := gob.NewDecoder(file).Decode(&c.data); err != nil {
+		return nil, fmt.Errorf("读取分类器文件失败: %w", err)
+	}
+	if c.data.Tokens == nil {
+		c.data.Tokens = make(map[string][2]int)
+	}
+	return c, nil
+}
+
+// Samples 正常样本数和垃圾样本数
+func (c *Classifier) Samples() (ham, spam int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.data.Docs[classHam], c.data.Docs[classSpam]
+}
+
+// Learn 学习一个样本
+func (c *Classifier) Learn(text string, spam bool) error {
+	return c.update(text, spam, 1)
+}
+
+// Forget 撤销一个样本，用于管理员修改审核结果，计数不会小于0
+func (c *Classifier) Forget(text string, spam bool) error {
+	return c.update(text, spam, -1)
+}
+
+// SpamProbability 计算文本为垃圾内容的概率
+func (c *Classifier) SpamProbability(text string) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	docs := c.data.Docs
+	// 先验概率和每个词的条件概率都做拉普拉斯平滑，对数相加避免下溢
+	score := [2]float64{}
+	for class := range score {
+		score[class] = math.Log(float64(docs[class]+1) / float64(docs[classHam]+docs[classSpam]+2))
+	}
+	for _, token := range uniqueTokens(text) {
+		counts, ok := c.data.Tokens[token]
+		if !ok {
+			continue
+		}
+		for class := range score {
+			score[class] += math.Log(float64(counts[class]+1) / float64(docs[class]+2))
+		}
+	}
+	return 1 / (1 + math.Exp(score[classHam]-score[classSpam]))
+}
+
+// update 更新样本计数并保存
+func (c *Classifier) update(text string, spam bool, delta int) error {
+	class := classHam
+	if spam {
+		class = classSpam
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data.Docs[class] = max(c.data.Docs[class]+delta, 0)
+	for _, token := range uniqueTokens(text) {
+		counts := c.data.Tokens[token]
+		counts[class] = max(counts[class]+delta, 0)
+		if counts == [2]int{} {
+			delete(c.data.Tokens, token)
+		} else {
+			c.data.Tokens[token] = counts
+		}
+	}
+	return c.save()
+}
+
+// save 将数据写入临时文件后替换分类器文件，调用方需持有锁
+func (c *Classifier) save() error {
+	if c.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("创建分类器目录失败: %w", err)
+	}
+
+	tmp := c.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("创建分类器文件失败: %w", err)
+	}
+	if err := gob.NewEncoder(file).Encode(&c.data); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("写入分类器文件失败: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入分类器文件失败: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// uniqueTokens 文本去重后的分词结果
+func uniqueTokens(text string) []string {
+	tokens := fulltext.Tokenize(text)
+	seen := make(map[string]bool, len(tokens))
+	unique := tokens[:0]
+	for _, token := range tokens {
+		if !seen[token] {
+			seen[token] = true
+			unique = append(unique, token)
+		}
+	}
+	return unique
+}
+
+// BayesChecker 贝叶斯分类检查，样本不足时不给出意见
+type BayesChecker struct {
+	classifier    *Classifier
+	minSamples    int
+	hamThreshold  float64
+	spamThreshold float64
+}
+
+// NewBayesChecker 创建贝叶斯分类检查，垃圾概率不高于hamThreshold时通过，不低于spamThreshold时拒绝
+func NewBayesChecker(classifier *Classifier, minSamples int, hamThreshold, spamThreshold float64) *BayesChecker {
+	return &BayesChecker{
+		classifier:    classifier,
+		minSamples:    minSamples,
+		hamThreshold:  hamThreshold,
+		spamThreshold: spamThreshold,
+	}
+}
+
+// Name 检查项名称
+func (c *BayesChecker) Name() string { return "bayes" }
+
+// Check 按垃圾概率给出结论，两类样本都达到minSamples后才生效
+func (c *BayesChecker) Check(in *Input) Result {
+	ham, spam := c.classifier.Samples()
+	if ham < c.minSamples || spam < c.minSamples {
+		return Result{}
+	}
+
+	probability := c.classifier.SpamProbability(in.Text())
+	reason := fmt.Sprintf("垃圾概率%.2f", probability)
+	switch {
+	case probability >= c.spamThreshold:
+		return Result{Verdict: Reject, Reason: reason}
+	case probability <= c.hamThreshold:
+		return Result{Verdict: Approve, Reason: reason}
+	default:
+		return Result{Verdict: Hold, Reason: reason}
+	}
+}
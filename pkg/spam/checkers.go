@@ -0,0 +1,191 @@
+package spam
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HoneypotChecker 蜜罐字段检查，字段被填写时判定为机器人提交
+type HoneypotChecker struct{}
+
+// Name 检查项名称
+func (HoneypotChecker) Name() string { return "honeypot" }
+
+// Check 检查蜜罐字段
+func (HoneypotChecker) Check(in *Input) Result {
+	if strings.TrimSpace(in.Honeypot) != "" {
+		return Result{Verdict: Reject, Reason: "蜜罐字段被填写"}
+	}
+	return Result{}
+}
+
+// TimeTokenChecker 最短提交时间检查，渲染表单时签发带时间戳的令牌，提交过快判定为机器人
+type TimeTokenChecker struct {
+	secret []byte
+	minAge time.Duration
+	maxAge time.Duration
+}
+
+// NewTimeTokenChecker 创建提交时间检查，maxAge为0时令牌不过期
+func NewTimeTokenChecker(secret string, minAge, maxAge time.Duration) *TimeTokenChecker {
+	return &TimeTokenChecker{secret: []byte(secret), minAge: minAge, maxAge: maxAge}
+}
+
+// Name 检查项名称
+func (c *TimeTokenChecker) Name() string { return "time_token" }
+
+// Issue 为提交对象签发令牌
+func (c *TimeTokenChecker) Issue(target string) string {
+	issued := strconv.FormatInt(time.Now().Unix(), 10)
+	return issued + "." + c.sign(target, issued)
+}
+
+// Check 校验令牌签名和签发时间，缺失或无效的令牌转人工审核
+func (c *TimeTokenChecker) Check(in *Input) Result {
+	issued, signature, ok := strings.Cut(in.Token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(c.sign(in.Target, issued))) {
+		return Result{Verdict: Hold, Reason: "表单令牌无效"}
+	}
+	unix, err := strconv.ParseInt(issued, 10, 64)
+	if err != nil {
+		return Result{Verdict: Hold, Reason: "表单令牌无效"}
+	}
+
+	age := time.Since(time.Unix(unix, 0))
+	if age < c.minAge {
+		return Result{Verdict: Reject, Reason: fmt.Sprintf("提交过快(%s)", age.Round(time.Millisecond))}
+	}
+	if c.maxAge > 0 && age > c.maxAge {
+		return Result{Verdict: Hold, Reason: "表单令牌已过期"}
+	}
+	return Result{}
+}
+
+// sign 计算令牌签名
+func (c *TimeTokenChecker) sign(target, issued string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(target + "|" + issued))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RateLimitChecker 按IP限制提交频率，窗口内提交次数超过限制时拒绝
+type RateLimitChecker struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	hits   map[string][]time.Time
+}
+
+// NewRateLimitChecker 创建频率限制检查
+func NewRateLimitChecker(limit int, window time.Duration) *RateLimitChecker {
+	return &RateLimitChecker{limit: limit, window: window, hits: make(map[string][]time.Time)}
+}
+
+// Name 检查项名称
+func (c *RateLimitChecker) Name() string { return "rate_limit" }
+
+// Check 记录本次提交并检查频率
+func (c *RateLimitChecker) Check(in *Input) Result {
+	if c.limit <= 0 || in.IP == "" {
+		return Result{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.hits) > 10000 {
+		c.sweep(now)
+	}
+
+	hits := c.recent(c.hits[in.IP], now)
+	if len(hits) >= c.limit {
+		c.hits[in.IP] = hits
+		return Result{Verdict: Reject, Reason: fmt.Sprintf("%s内提交超过%d次", c.window, c.limit)}
+	}
+	c.hits[in.IP] = append(hits, now)
+	return Result{}
+}
+
+// recent 过滤出窗口内的提交记录
+func (c *RateLimitChecker) recent(hits []time.Time, now time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && now.Sub(hits[i]) >= c.window {
+		i++
+	}
+	return hits[i:]
+}
+
+// sweep 清理过期的IP记录，调用方需持有锁
+func (c *RateLimitChecker) sweep(now time.Time) {
+	for ip, hits := range c.hits {
+		if hits = c.recent(hits, now); len(hits) == 0 {
+			delete(c.hits, ip)
+		} else {
+			c.hits[ip] = hits
+		}
+	}
+}
+
+// KeywordChecker 关键词黑名单检查，命中时拒绝
+type KeywordChecker struct {
+	keywords []string
+}
+
+// NewKeywordChecker 创建关键词检查，关键词不区分大小写
+func NewKeywordChecker(keywords []string) *KeywordChecker {
+	c := &KeywordChecker{}
+	for _, keyword := range keywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			c.keywords = append(c.keywords, keyword)
+		}
+	}
+	return c
+}
+
+// Name 检查项名称
+func (c *KeywordChecker) Name() string { return "keyword" }
+
+// Check 检查是否包含黑名单关键词
+func (c *KeywordChecker) Check(in *Input) Result {
+	text := strings.ToLower(in.Text())
+	for _, keyword := range c.keywords {
+		if strings.Contains(text, keyword) {
+			return Result{Verdict: Reject, Reason: "包含黑名单关键词: " + keyword}
+		}
+	}
+	return Result{}
+}
+
+// linkPattern 匹配正文中的链接
+var linkPattern = regexp.MustCompile(`(?i)(https?://|www\.)`)
+
+// LinkCountChecker 链接数量检查，正文链接过多时转人工审核
+type LinkCountChecker struct {
+	max int
+}
+
+// NewLinkCountChecker 创建链接数量检查，max为负数时不限制
+func NewLinkCountChecker(max int) *LinkCountChecker {
+	return &LinkCountChecker{max: max}
+}
+
+// Name 检查项名称
+func (c *LinkCountChecker) Name() string { return "link_count" }
+
+// Check 统计正文中的链接数量
+func (c *LinkCountChecker) Check(in *Input) Result {
+	if c.max < 0 {
+		return Result{}
+	}
+	if count := len(linkPattern.FindAllStringIndex(in.Content, -1)); count > c.max {
+		return Result{Verdict: Hold, Reason: fmt.Sprintf("包含%d个链接", count)}
+	}
+	return Result{}
+}
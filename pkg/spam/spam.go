@@ -0,0 +1,113 @@
+package spam
+
+import "strings"
+
+// Verdict 检查结论
+type Verdict int
+
+const (
+	Pass    Verdict = iota // 无意见，交由其他检查决定
+	Approve                // 可直接通过
+	Hold                   // 需人工审核
+	Reject                 // 判定为垃圾内容
+)
+
+// String 结论名称
+func (v Verdict) String() string {
+	switch v {
+	case Approve:
+		return "approve"
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	default:
+		return "pass"
+	}
+}
+
+// Input 待检查的提交内容
+type Input struct {
+	Target    string // 提交的对象，如文章ID，用于校验表单令牌
+	Username  string
+	Email     string
+	Website   string
+	Content   string
+	IP        string
+	UserAgent string
+	Honeypot  string // 蜜罐字段，正常用户看不到该字段，应当为空
+	Token     string // 渲染表单时签发的令牌
+}
+
+// Text 参与内容检查的全部文本
+func (in *Input) Text() string {
+	return strings.Join([]string{in.Username, in.Email, in.Website, in.Content}, "\n")
+}
+
+// Result 单项检查结果
+type Result struct {
+	Checker string  `json:"checker"`
+	Verdict Verdict `json:"verdict"`
+	Reason  string  `json:"reason,omitempty"`
+}
+
+// Checker 垃圾内容检查项
+type Checker interface {
+	Name() string
+	Check(in *Input) Result
+}
+
+// Decision 检查链的最终结论
+type Decision struct {
+	Verdict Verdict
+	Results []Result // 给出意见的检查结果
+}
+
+// Reasons 给出意见的检查理由
+func (d *Decision) Reasons() string {
+	reasons := make([]string, 0, len(d.Results))
+	for _, result := range d.Results {
+		reasons = append(reasons, result.Checker+": "+result.Verdict.String()+" "+result.Reason)
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// Pipeline 按顺序执行的检查链
+// 任一检查判定为垃圾内容时立即拒绝；否则有检查要求审核时转人工审核；
+// 只有至少一项检查明确通过时才自动通过，所有检查都无意见时转人工审核
+type Pipeline struct {
+	checkers []Checker
+}
+
+// NewPipeline 创建检查链
+func NewPipeline(checkers ...Checker) *Pipeline {
+	return &Pipeline{checkers: checkers}
+}
+
+// Use 追加检查项
+func (p *Pipeline) Use(checkers ...Checker) {
+	p.checkers = append(p.checkers, checkers...)
+}
+
+// Check 执行检查链
+func (p *Pipeline) Check(in *Input) *Decision {
+	decision := &Decision{Verdict: Pass}
+	for _, checker := range p.checkers {
+		result := checker.Check(in)
+		if result.Verdict == Pass {
+			continue
+		}
+		result.Checker = checker.Name()
+		decision.Results = append(decision.Results, result)
+		if result.Verdict > decision.Verdict {
+			decision.Verdict = result.Verdict
+		}
+		if result.Verdict == Reject {
+			return decision
+		}
+	}
+	if decision.Verdict == Pass {
+		decision.Verdict = Hold
+	}
+	return decision
+}
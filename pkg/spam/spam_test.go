@@ -0,0 +1,208 @@
+package spam
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixedChecker 返回固定结论的检查项
+type fixedChecker struct {
+	name    string
+	verdict Verdict
+	called  *int
+}
+
+func (c fixedChecker) Name() string { return c.name }
+
+func (c fixedChecker) Check(in *Input) Result {
+	if c.called != nil {
+		*c.called++
+	}
+	return Result{Verdict: c.verdict, Reason: c.name}
+}
+
+func TestPipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		verdicts []Verdict
+		want     Verdict
+		results  int
+	}{
+		{"no checkers", nil, Hold, 0},
+		{"all pass", []Verdict{Pass, Pass}, Hold, 0},
+		{"approve", []Verdict{Pass, Approve}, Approve, 1},
+		{"hold wins over approve", []Verdict{Approve, Hold}, Hold, 2},
+		{"reject stops", []Verdict{Approve, Reject, Hold}, Reject, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			pipeline := NewPipeline()
+			for i, verdict := range tt.verdicts {
+				pipeline.Use(fixedChecker{name: "c" + strconv.Itoa(i), verdict: verdict, called: &calls})
+			}
+			decision := pipeline.Check(&Input{})
+			if decision.Verdict != tt.want || len(decision.Results) != tt.results {
+				t.Errorf("Check = %s with %d results, want %s with %d", decision.Verdict, len(decision.Results), tt.want, tt.results)
+			}
+			if tt.want == Reject && calls != tt.results {
+				t.Errorf("checkers after reject should not run, called %d", calls)
+			}
+		})
+	}
+}
+
+func TestCheckers(t *testing.T) {
+	tests := []struct {
+		name    string
+		checker Checker
+		in      Input
+		want    Verdict
+	}{
+		{"honeypot empty", HoneypotChecker{}, Input{}, Pass},
+		{"honeypot filled", HoneypotChecker{}, Input{Honeypot: "x"}, Reject},
+		{"keyword hit", NewKeywordChecker([]string{" Casino ", ""}), Input{Content: "best CASINO online"}, Reject},
+		{"keyword in website", NewKeywordChecker([]string{"casino"}), Input{Website: "http://casino.example"}, Reject},
+		{"keyword miss", NewKeywordChecker([]string{"casino"}), Input{Content: "hello"}, Pass},
+		{"links within limit", NewLinkCountChecker(1), Input{Content: "see https://a.example"}, Pass},
+		{"too many links", NewLinkCountChecker(1), Input{Content: "https://a.example www.b.example"}, Hold},
+		{"links unlimited", NewLinkCountChecker(-1), Input{Content: "https://a http://b http://c"}, Pass},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.checker.Check(&tt.in); got.Verdict != tt.want {
+				t.Errorf("Check = %s (%s), want %s", got.Verdict, got.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeTokenChecker(t *testing.T) {
+	checker := NewTimeTokenChecker("secret", 0, time.Hour)
+	token := checker.Issue("1")
+	issued, _, _ := strings.Cut(token, ".")
+	old := strconv.FormatInt(time.Now().Add(-2*time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name    string
+		checker *TimeTokenChecker
+		in      Input
+		want    Verdict
+	}{
+		{"valid", checker, Input{Target: "1", Token: token}, Pass},
+		{"missing", checker, Input{Target: "1"}, Hold},
+		{"other target", checker, Input{Target: "2", Token: token}, Hold},
+		{"other secret", NewTimeTokenChecker("other", 0, time.Hour), Input{Target: "1", Token: token}, Hold},
+		{"tampered time", checker, Input{Target: "1", Token: old + token[len(issued):]}, Hold},
+		{"expired", checker, Input{Target: "1", Token: old + "." + checker.sign("1", old)}, Hold},
+		{"too fast", NewTimeTokenChecker("secret", time.Minute, 0), Input{Target: "1", Token: token}, Reject},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.checker.Check(&tt.in); got.Verdict != tt.want {
+				t.Errorf("Check = %s (%s), want %s", got.Verdict, got.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitChecker(t *testing.T) {
+	checker := NewRateLimitChecker(2, time.Minute)
+	verdicts := []Verdict{}
+	for i := 0; i < 3; i++ {
+		verdicts = append(verdicts, checker.Check(&Input{IP: "1.2.3.4"}).Verdict)
+	}
+	if verdicts[0] != Pass || verdicts[1] != Pass || verdicts[2] != Reject {
+		t.Errorf("verdicts = %v, want [pass pass reject]", verdicts)
+	}
+	if got := checker.Check(&Input{IP: "5.6.7.8"}).Verdict; got != Pass {
+		t.Errorf("other IP = %s, want pass", got)
+	}
+
+	expired := NewRateLimitChecker(1, time.Minute)
+	expired.hits["1.2.3.4"] = []time.Time{time.Now().Add(-2 * time.Minute)}
+	if got := expired.Check(&Input{IP: "1.2.3.4"}).Verdict; got != Pass {
+		t.Errorf("hits outside the window should not count, got %s", got)
+	}
+}
+
+func TestClassifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bayes.gob")
+	classifier, err := OpenClassifier(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hams := []string{"great article thanks", "thanks for the detailed explanation", "nice post about go", "这篇文章写得很好"}
+	spams := []string{"cheap pills buy now", "buy cheap watches now", "win money casino now", "免费领取优惠券"}
+	for _, text := range hams {
+		if err := classifier.Learn(text, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, text := range spams {
+		if err := classifier.Learn(text, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if p := classifier.SpamProbability("buy cheap pills now"); p < 0.9 {
+		t.Errorf("spam probability = %.2f, want >= 0.9", p)
+	}
+	if p := classifier.SpamProbability("thanks, great explanation"); p > 0.1 {
+		t.Errorf("ham probability = %.2f, want <= 0.1", p)
+	}
+	if p := classifier.SpamProbability("unknown words only"); p < 0.45 || p > 0.55 {
+		t.Errorf("unknown text probability = %.2f, want about 0.5", p)
+	}
+
+	// 数据持久化后重新打开
+	reopened, err := OpenClassifier(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ham, spam := reopened.Samples(); ham != 4 || spam != 4 {
+		t.Errorf("Samples = %d, %d, want 4, 4", ham, spam)
+	}
+
+	// 撤销样本后计数回退，不会小于0
+	for _, text := range spams {
+		reopened.Forget(text, true)
+	}
+	reopened.Forget("cheap", true)
+	if _, spam := reopened.Samples(); spam != 0 {
+		t.Errorf("spam samples = %d, want 0", spam)
+	}
+	if _, ok := reopened.data.Tokens["cheap"]; ok {
+		t.Error("tokens without samples should be removed")
+	}
+}
+
+func TestBayesChecker(t *testing.T) {
+	classifier, _ := OpenClassifier("")
+	checker := NewBayesChecker(classifier, 2, 0.2, 0.8)
+	if got := checker.Check(&Input{Content: "buy cheap pills"}).Verdict; got != Pass {
+		t.Errorf("checker without samples = %s, want pass", got)
+	}
+
+	classifier.Learn("great article thanks", false)
+	classifier.Learn("nice post thanks", false)
+	classifier.Learn("buy cheap pills", true)
+	classifier.Learn("cheap pills online", true)
+
+	tests := []struct {
+		content string
+		want    Verdict
+	}{
+		{"cheap pills", Reject},
+		{"thanks great post", Approve},
+		{"thanks cheap", Hold},
+	}
+	for _, tt := range tests {
+		if got := checker.Check(&Input{Content: tt.content}); got.Verdict != tt.want {
+			t.Errorf("Check(%q) = %s (%s), want %s", tt.content, got.Verdict, got.Reason, tt.want)
+		}
+	}
+}
@@ -1,185 +1,83 @@
 package storage
 
 import (
-	"context"
 	"fmt"
-	"io"
 	"strings"
-	"time"
 )
 
 // AliyunConfig 阿里云OSS配置
 type AliyunConfig struct {
-	Endpoint        string `json:"endpoint"`          // OSS访问域名
+	Endpoint        string `json:"endpoint"`          // OSS访问域名，如oss-cn-hangzhou.aliyuncs.com
 	AccessKeyID     string `json:"access_key_id"`     // AccessKey ID
 	AccessKeySecret string `json:"access_key_secret"` // AccessKey Secret
 	BucketName      string `json:"bucket_name"`       // 存储桶名称
-	Region          string `json:"region"`            // 地域
+	Region          string `json:"region"`            // 地域，如oss-cn-hangzhou
 	UseHTTPS        bool   `json:"use_https"`         // 是否使用HTTPS
 	CustomDomain    string `json:"custom_domain"`     // 自定义域名
 }
 
-// AliyunAdapter 阿里云OSS存储适配器
+// AliyunAdapter 阿里云OSS存储适配器，通过OSS的S3兼容接口访问
 type AliyunAdapter struct {
+	*S3Adapter
 	config *AliyunConfig
-	// 注意：这里暂时不引入阿里云SDK，仅提供接口实现
-	// 在实际使用时需要引入: github.com/aliyun/aliyun-oss-go-sdk/oss
 }
 
-// NewAliyunAdapter 创建阿里云OSS适配器
-func NewAliyunAdapter(config *AliyunConfig) *AliyunAdapter {
+// NewAliyunAdapter 创建阿里云OSS适配器，配置不完整时返回错误
+func NewAliyunAdapter(config *AliyunConfig) (*AliyunAdapter, error) {
 	if config == nil {
-		panic("阿里云OSS配置不能为空")
+		return nil, ErrInvalidConfig
 	}
-
-	// 验证必要配置
-	if config.Endpoint == "" || config.AccessKeyID == "" ||
-		config.AccessKeySecret == "" || config.BucketName == "" {
-		panic("阿里云OSS配置不完整")
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
-	return &AliyunAdapter{
-		config: config,
+	protocol := "http"
+	if config.UseHTTPS {
+		protocol = "https"
 	}
-}
-
-// Upload 上传文件到阿里云OSS
-func (a *AliyunAdapter) Upload(ctx context.Context, path string, reader io.Reader, size int64, contentType string) error {
-	// TODO: 实现阿里云OSS上传逻辑
-	// 这里需要使用阿里云OSS SDK
-	/*
-		client, err := oss.New(a.config.Endpoint, a.config.AccessKeyID, a.config.AccessKeySecret)
-		if err != nil {
-			return &StorageError{
-				Code:    "OSS_CLIENT_FAILED",
-				Message: "创建OSS客户端失败",
-				Err:     err,
-			}
-		}
-
-		bucket, err := client.Bucket(a.config.BucketName)
-		if err != nil {
-			return &StorageError{
-				Code:    "OSS_BUCKET_FAILED",
-				Message: "获取OSS存储桶失败",
-				Err:     err,
-			}
-		}
-
-		options := []oss.Option{}
-		if contentType != "" {
-			options = append(options, oss.ContentType(contentType))
-		}
-
-		err = bucket.PutObject(path, reader, options...)
-		if err != nil {
-			return &StorageError{
-				Code:    "OSS_UPLOAD_FAILED",
-				Message: "OSS上传失败",
-				Err:     err,
-			}
-		}
-	*/
-
-	return fmt.Errorf("阿里云OSS适配器暂未实现，请先安装阿里云OSS SDK")
-}
-
-// Download 从阿里云OSS下载文件
-func (a *AliyunAdapter) Download(ctx context.Context, path string) (io.ReadCloser, error) {
-	// TODO: 实现阿里云OSS下载逻辑
-	return nil, fmt.Errorf("阿里云OSS适配器暂未实现")
-}
-
-// Delete 删除阿里云OSS文件
-func (a *AliyunAdapter) Delete(ctx context.Context, path string) error {
-	// TODO: 实现阿里云OSS删除逻辑
-	return fmt.Errorf("阿里云OSS适配器暂未实现")
-}
-
-// Exists 检查阿里云OSS文件是否存在
-func (a *AliyunAdapter) Exists(ctx context.Context, path string) (bool, error) {
-	// TODO: 实现阿里云OSS文件存在检查逻辑
-	return false, fmt.Errorf("阿里云OSS适配器暂未实现")
-}
-
-// GetURL 获取阿里云OSS文件访问URL
-func (a *AliyunAdapter) GetURL(ctx context.Context, path string) (string, error) {
-	var baseURL string
-
-	// 使用自定义域名或默认域名
-	if a.config.CustomDomain != "" {
-		baseURL = a.config.CustomDomain
-	} else {
-		protocol := "http"
-		if a.config.UseHTTPS {
-			protocol = "https"
-		}
-		baseURL = fmt.Sprintf("%s://%s.%s", protocol, a.config.BucketName, a.config.Endpoint)
+	endpoint := config.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		endpoint = protocol + "://" + endpoint
 	}
 
-	// 确保baseURL以/结尾
-	if !strings.HasSuffix(baseURL, "/") {
-		baseURL += "/"
+	// OSS只支持虚拟主机风格访问
+	adapter, err := NewS3Adapter(&S3Config{
+		Name:            "aliyun",
+		Endpoint:        endpoint,
+		Region:          config.Region,
+		BucketName:      config.BucketName,
+		AccessKeyID:     config.AccessKeyID,
+		SecretAccessKey: config.AccessKeySecret,
+		CustomDomain:    config.CustomDomain,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("阿里云OSS配置无效: %w", err)
 	}
 
-	return baseURL + path, nil
-}
-
-// GetSignedURL 获取阿里云OSS签名URL
-func (a *AliyunAdapter) GetSignedURL(ctx context.Context, path string, expiry time.Duration) (string, error) {
-	// TODO: 实现阿里云OSS签名URL生成逻辑
-	/*
-		client, err := oss.New(a.config.Endpoint, a.config.AccessKeyID, a.config.AccessKeySecret)
-		if err != nil {
-			return "", &StorageError{
-				Code:    "OSS_CLIENT_FAILED",
-				Message: "创建OSS客户端失败",
-				Err:     err,
-			}
-		}
-
-		bucket, err := client.Bucket(a.config.BucketName)
-		if err != nil {
-			return "", &StorageError{
-				Code:    "OSS_BUCKET_FAILED",
-				Message: "获取OSS存储桶失败",
-				Err:     err,
-			}
-		}
-
-		signedURL, err := bucket.SignURL(path, oss.HTTPGet, int64(expiry.Seconds()))
-		if err != nil {
-			return "", &StorageError{
-				Code:    "OSS_SIGN_URL_FAILED",
-				Message: "生成OSS签名URL失败",
-				Err:     err,
-			}
-		}
-
-		return signedURL, nil
-	*/
-
-	return "", fmt.Errorf("阿里云OSS适配器暂未实现")
-}
-
-// GetStorageType 获取存储类型
-func (a *AliyunAdapter) GetStorageType() string {
-	return "aliyun"
+	return &AliyunAdapter{
+		S3Adapter: adapter,
+		config:    config,
+	}, nil
 }
 
-// ValidateConfig 验证阿里云OSS配置
-func (a *AliyunAdapter) ValidateConfig() error {
-	if a.config.Endpoint == "" {
+// Validate 验证阿里云OSS配置是否完整
+func (c *AliyunConfig) Validate() error {
+	if c.Endpoint == "" {
 		return fmt.Errorf("阿里云OSS Endpoint不能为空")
 	}
-	if a.config.AccessKeyID == "" {
+	if c.AccessKeyID == "" {
 		return fmt.Errorf("阿里云OSS AccessKeyID不能为空")
 	}
-	if a.config.AccessKeySecret == "" {
+	if c.AccessKeySecret == "" {
 		return fmt.Errorf("阿里云OSS AccessKeySecret不能为空")
 	}
-	if a.config.BucketName == "" {
+	if c.BucketName == "" {
 		return fmt.Errorf("阿里云OSS BucketName不能为空")
 	}
 	return nil
 }
+
+// ValidateConfig 验证阿里云OSS配置
+func (a *AliyunAdapter) ValidateConfig() error {
+	return a.config.Validate()
+}
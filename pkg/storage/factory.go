@@ -72,6 +72,8 @@ func CreateAdapterFromConfig(config *StorageConfig) (StorageAdapter, error) {
 		return createAliyunAdapter(config)
 	case "tencent":
 		return createTencentAdapter(config)
+	case "s3":
+		return createS3Adapter(config)
 	default:
 		return nil, &StorageError{
 			Code:    "UNSUPPORTED_TYPE",
@@ -116,8 +118,8 @@ func createAliyunAdapter(config *StorageConfig) (StorageAdapter, error) {
 		}
 	}
 
-	adapter := NewAliyunAdapter(&aliyunConfig)
-	if err := adapter.ValidateConfig(); err != nil {
+	adapter, err := NewAliyunAdapter(&aliyunConfig)
+	if err != nil {
 		return nil, &StorageError{
 			Code:    "CONFIG_VALIDATION_FAILED",
 			Message: "配置验证失败",
@@ -149,8 +151,41 @@ func createTencentAdapter(config *StorageConfig) (StorageAdapter, error) {
 		}
 	}
 
-	adapter := NewTencentAdapter(&tencentConfig)
-	if err := adapter.ValidateConfig(); err != nil {
+	adapter, err := NewTencentAdapter(&tencentConfig)
+	if err != nil {
+		return nil, &StorageError{
+			Code:    "CONFIG_VALIDATION_FAILED",
+			Message: "配置验证失败",
+			Err:     err,
+		}
+	}
+
+	return adapter, nil
+}
+
+// createS3Adapter 创建S3协议存储适配器
+func createS3Adapter(config *StorageConfig) (StorageAdapter, error) {
+	// 将配置转换为S3Config
+	configBytes, err := json.Marshal(config.Config)
+	if err != nil {
+		return nil, &StorageError{
+			Code:    "CONFIG_MARSHAL_FAILED",
+			Message: "配置序列化失败",
+			Err:     err,
+		}
+	}
+
+	var s3Config S3Config
+	if err := json.Unmarshal(configBytes, &s3Config); err != nil {
+		return nil, &StorageError{
+			Code:    "CONFIG_UNMARSHAL_FAILED",
+			Message: "配置反序列化失败",
+			Err:     err,
+		}
+	}
+
+	adapter, err := NewS3Adapter(&s3Config)
+	if err != nil {
 		return nil, &StorageError{
 			Code:    "CONFIG_VALIDATION_FAILED",
 			Message: "配置验证失败",
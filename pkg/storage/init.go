@@ -22,20 +22,7 @@ func InitStorage() error {
 	logger.Info("Storage type:", storageType)
 
 	// 根据配置类型创建存储适配器
-	var adapter StorageAdapter
-	var err error
-
-	switch storageType {
-	case "local":
-		adapter, err = initLocalStorage()
-	case "aliyun":
-		adapter, err = initAliyunStorage()
-	case "tencent":
-		adapter, err = initTencentStorage()
-	default:
-		return fmt.Errorf("不支持的存储类型: %s", storageType)
-	}
-
+	adapter, err := initAdapter(storageType)
 	if err != nil {
 		return fmt.Errorf("初始化存储适配器失败: %v", err)
 	}
@@ -44,10 +31,55 @@ func InitStorage() error {
 	RegisterGlobalAdapter(storageType, adapter)
 	SetGlobalDefault(adapter)
 
+	// 注册其他配置可用的适配器，切换存储类型后仍能访问和删除之前上传的文件
+	for _, other := range []string{"local", "aliyun", "tencent", "s3"} {
+		if other == storageType || !adapterConfigured(other) {
+			continue
+		}
+		adapter, err := initAdapter(other)
+		if err != nil {
+			logger.Warn("Skip storage adapter", other+":", err)
+			continue
+		}
+		RegisterGlobalAdapter(other, adapter)
+	}
+
 	logger.Info("Storage system initialized successfully with type:", storageType)
 	return nil
 }
 
+// initAdapter 根据类型创建存储适配器
+func initAdapter(storageType string) (StorageAdapter, error) {
+	switch storageType {
+	case "local":
+		return initLocalStorage()
+	case "aliyun":
+		return initAliyunStorage()
+	case "tencent":
+		return initTencentStorage()
+	case "s3":
+		return initS3Storage()
+	default:
+		return nil, fmt.Errorf("不支持的存储类型: %s", storageType)
+	}
+}
+
+// adapterConfigured 检查存储类型的配置是否完整，只填写了部分配置的存储不会被注册
+func adapterConfigured(storageType string) bool {
+	switch storageType {
+	case "local":
+		return config.GetString("storage.local.base_path") != ""
+	case "aliyun":
+		return aliyunConfig().Validate() == nil
+	case "tencent":
+		return tencentConfig().Validate() == nil
+	case "s3":
+		return s3Config().Validate() == nil
+	default:
+		return false
+	}
+}
+
 // initLocalStorage 初始化本地存储
 func initLocalStorage() (StorageAdapter, error) {
 	basePath := config.GetString("storage.local.base_path")
@@ -66,9 +98,9 @@ func initLocalStorage() (StorageAdapter, error) {
 	return adapter, nil
 }
 
-// initAliyunStorage 初始化阿里云OSS存储
-func initAliyunStorage() (StorageAdapter, error) {
-	aliyunConfig := &AliyunConfig{
+// aliyunConfig 读取阿里云OSS配置
+func aliyunConfig() *AliyunConfig {
+	return &AliyunConfig{
 		Endpoint:        config.GetString("storage.aliyun.endpoint"),
 		AccessKeyID:     config.GetString("storage.aliyun.access_key_id"),
 		AccessKeySecret: config.GetString("storage.aliyun.access_key_secret"),
@@ -77,23 +109,24 @@ func initAliyunStorage() (StorageAdapter, error) {
 		UseHTTPS:        config.GetBool("storage.aliyun.use_https"),
 		CustomDomain:    config.GetString("storage.aliyun.custom_domain"),
 	}
+}
 
-	logger.Info("Aliyun OSS config - Endpoint:", aliyunConfig.Endpoint,
-		"BucketName:", aliyunConfig.BucketName, "Region:", aliyunConfig.Region)
-
-	adapter := NewAliyunAdapter(aliyunConfig)
+// initAliyunStorage 初始化阿里云OSS存储
+func initAliyunStorage() (StorageAdapter, error) {
+	cfg := aliyunConfig()
+	logger.Info("Aliyun OSS config - Endpoint:", cfg.Endpoint,
+		"BucketName:", cfg.BucketName, "Region:", cfg.Region)
 
-	// 验证配置
-	if err := adapter.ValidateConfig(); err != nil {
+	adapter, err := NewAliyunAdapter(cfg)
+	if err != nil {
 		return nil, err
 	}
-
 	return adapter, nil
 }
 
-// initTencentStorage 初始化腾讯云COS存储
-func initTencentStorage() (StorageAdapter, error) {
-	tencentConfig := &TencentConfig{
+// tencentConfig 读取腾讯云COS配置
+func tencentConfig() *TencentConfig {
+	return &TencentConfig{
 		SecretID:     config.GetString("storage.tencent.secret_id"),
 		SecretKey:    config.GetString("storage.tencent.secret_key"),
 		Region:       config.GetString("storage.tencent.region"),
@@ -102,17 +135,46 @@ func initTencentStorage() (StorageAdapter, error) {
 		UseHTTPS:     config.GetBool("storage.tencent.use_https"),
 		CustomDomain: config.GetString("storage.tencent.custom_domain"),
 	}
+}
 
-	logger.Info("Tencent COS config - Region:", tencentConfig.Region,
-		"BucketName:", tencentConfig.BucketName, "AppID:", tencentConfig.AppID)
-
-	adapter := NewTencentAdapter(tencentConfig)
+// initTencentStorage 初始化腾讯云COS存储
+func initTencentStorage() (StorageAdapter, error) {
+	cfg := tencentConfig()
+	logger.Info("Tencent COS config - Region:", cfg.Region,
+		"BucketName:", cfg.BucketName, "AppID:", cfg.AppID)
 
-	// 验证配置
-	if err := adapter.ValidateConfig(); err != nil {
+	adapter, err := NewTencentAdapter(cfg)
+	if err != nil {
 		return nil, err
 	}
+	return adapter, nil
+}
+
+// s3Config 读取S3协议存储配置
+func s3Config() *S3Config {
+	return &S3Config{
+		Endpoint:        config.GetString("storage.s3.endpoint"),
+		Region:          config.GetString("storage.s3.region"),
+		BucketName:      config.GetString("storage.s3.bucket_name"),
+		AccessKeyID:     config.GetString("storage.s3.access_key_id"),
+		SecretAccessKey: config.GetString("storage.s3.secret_access_key"),
+		SessionToken:    config.GetString("storage.s3.session_token"),
+		PathStyle:       config.GetBool("storage.s3.path_style"),
+		CustomDomain:    config.GetString("storage.s3.custom_domain"),
+		PartSize:        int64(config.GetInt("storage.s3.part_size_mb")) << 20,
+	}
+}
+
+// initS3Storage 初始化S3协议存储
+func initS3Storage() (StorageAdapter, error) {
+	cfg := s3Config()
+	logger.Info("S3 storage config - Endpoint:", cfg.Endpoint,
+		"BucketName:", cfg.BucketName, "Region:", cfg.Region, "PathStyle:", cfg.PathStyle)
 
+	adapter, err := NewS3Adapter(cfg)
+	if err != nil {
+		return nil, err
+	}
 	return adapter, nil
 }
 
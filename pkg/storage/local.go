@@ -133,9 +133,12 @@ func (l *LocalAdapter) Exists(ctx context.Context, path string) (bool, error) {
 
 // GetURL 获取文件访问URL
 func (l *LocalAdapter) GetURL(ctx context.Context, path string) (string, error) {
-	// 对路径进行URL编码
-	encodedPath := url.PathEscape(strings.ReplaceAll(path, "\\", "/"))
-	return l.baseURL + encodedPath, nil
+	// 对路径的每一段进行URL编码，保留目录分隔符
+	segments := strings.Split(strings.ReplaceAll(path, "\\", "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return l.baseURL + strings.Join(segments, "/"), nil
 }
 
 // GetSignedURL 获取签名URL（本地存储直接返回普通URL）
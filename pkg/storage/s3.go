@@ -0,0 +1,419 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// s3DefaultPartSize 分片上传默认分片大小
+	s3DefaultPartSize = 8 << 20
+	// s3MinPartSize S3协议要求除最后一片外每片不小于5MB
+	s3MinPartSize = 5 << 20
+	// s3MaxParts 单次分片上传的最大分片数
+	s3MaxParts = 10000
+	// s3MaxPresignExpiry 预签名URL的最长有效期
+	s3MaxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// S3Config S3协议存储配置，适用于AWS S3、MinIO以及阿里云OSS、腾讯云COS的S3兼容接口
+type S3Config struct {
+	Name            string       `json:"name"`              // 存储类型名称，默认为s3
+	Endpoint        string       `json:"endpoint"`          // 服务地址，如https://s3.amazonaws.com、http://127.0.0.1:9000
+	Region          string       `json:"region"`            // 签名使用的地域，默认为us-east-1
+	BucketName      string       `json:"bucket_name"`       // 存储桶名称
+	AccessKeyID     string       `json:"access_key_id"`     // AccessKey ID
+	SecretAccessKey string       `json:"secret_access_key"` // Secret Access Key
+	SessionToken    string       `json:"session_token"`     // 临时凭证的Session Token
+	PathStyle       bool         `json:"path_style"`        // 使用路径风格访问(endpoint/bucket/key)，MinIO需要开启
+	CustomDomain    string       `json:"custom_domain"`     // 自定义域名
+	PartSize        int64        `json:"part_size"`         // 分片上传的分片大小，超过该大小的文件使用分片上传
+	Client          *http.Client `json:"-"`                 // 发送请求的客户端，为空时使用默认客户端
+}
+
+// S3Adapter S3协议存储适配器
+type S3Adapter struct {
+	config   *S3Config
+	endpoint *url.URL
+	signer   *sigV4Signer
+	client   *http.Client
+	partSize int64
+}
+
+// NewS3Adapter 创建S3协议存储适配器
+func NewS3Adapter(config *S3Config) (*S3Adapter, error) {
+	if config == nil {
+		return nil, ErrInvalidConfig
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	endpoint := config.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(strings.TrimRight(endpoint, "/"))
+	if err != nil || u.Host == "" {
+		return nil, &StorageError{
+			Code:    "CONFIG_VALIDATION_FAILED",
+			Message: "S3 Endpoint无效",
+			Err:     err,
+		}
+	}
+
+	region := config.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	partSize := config.PartSize
+	if partSize <= 0 {
+		partSize = s3DefaultPartSize
+	}
+	if partSize < s3MinPartSize {
+		partSize = s3MinPartSize
+	}
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+
+	return &S3Adapter{
+		config:   config,
+		endpoint: u,
+		signer: &sigV4Signer{
+			accessKey:    config.AccessKeyID,
+			secretKey:    config.SecretAccessKey,
+			sessionToken: config.SessionToken,
+			region:       region,
+			service:      "s3",
+		},
+		client:   client,
+		partSize: partSize,
+	}, nil
+}
+
+// Validate 验证S3配置
+func (c *S3Config) Validate() error {
+	if c.Endpoint == "" {
+		return fmt.Errorf("S3 Endpoint不能为空")
+	}
+	if c.BucketName == "" {
+		return fmt.Errorf("S3 BucketName不能为空")
+	}
+	if c.AccessKeyID == "" {
+		return fmt.Errorf("S3 AccessKeyID不能为空")
+	}
+	if c.SecretAccessKey == "" {
+		return fmt.Errorf("S3 SecretAccessKey不能为空")
+	}
+	return nil
+}
+
+// Upload 上传文件，不超过分片大小的文件直接上传，否则使用分片上传
+func (s *S3Adapter) Upload(ctx context.Context, path string, reader io.Reader, size int64, contentType string) error {
+	key := s.objectKey(path)
+
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, reader, s.partSize); err != nil {
+		if err != io.EOF {
+			return &StorageError{Code: "UPLOAD_FAILED", Message: "读取上传内容失败", Err: err}
+		}
+		return s.putObject(ctx, key, buf.Bytes(), contentType)
+	}
+	return s.multipartUpload(ctx, key, &buf, reader, contentType)
+}
+
+// Download 下载文件
+func (s *S3Adapter) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.objectKey(path), nil, nil, nil)
+	if err != nil {
+		return nil, &StorageError{Code: "DOWNLOAD_FAILED", Message: "S3下载失败", Err: err}
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrFileNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, s3ResponseError(resp, "DOWNLOAD_FAILED", "S3下载失败")
+	}
+	return resp.Body, nil
+}
+
+// Delete 删除文件，文件不存在时视为成功
+func (s *S3Adapter) Delete(ctx context.Context, path string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.objectKey(path), nil, nil, nil)
+	if err != nil {
+		return &StorageError{Code: "DELETE_FAILED", Message: "S3删除失败", Err: err}
+	}
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return s3ResponseError(resp, "DELETE_FAILED", "S3删除失败")
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Exists 通过HEAD请求检查文件是否存在
+func (s *S3Adapter) Exists(ctx context.Context, path string) (bool, error) {
+	resp, err := s.do(ctx, http.MethodHead, s.objectKey(path), nil, nil, nil)
+	if err != nil {
+		return false, &StorageError{Code: "CHECK_FILE_FAILED", Message: "检查S3文件失败", Err: err}
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode < 300:
+		return true, nil
+	default:
+		return false, &StorageError{
+			Code:    "CHECK_FILE_FAILED",
+			Message: fmt.Sprintf("检查S3文件失败: HTTP %d", resp.StatusCode),
+		}
+	}
+}
+
+// GetURL 获取文件访问URL，配置了自定义域名时使用自定义域名
+func (s *S3Adapter) GetURL(ctx context.Context, path string) (string, error) {
+	key := s.objectKey(path)
+	if s.config.CustomDomain != "" {
+		baseURL := s.config.CustomDomain
+		if !strings.Contains(baseURL, "://") {
+			baseURL = "https://" + baseURL
+		}
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		return baseURL + canonicalPath(key), nil
+	}
+	return s.objectURL(key, nil).String(), nil
+}
+
+// GetSignedURL 获取预签名的临时访问URL，有效期最长7天
+func (s *S3Adapter) GetSignedURL(ctx context.Context, path string, expiry time.Duration) (string, error) {
+	if expiry <= 0 {
+		expiry = 15 * time.Minute
+	}
+	if expiry > s3MaxPresignExpiry {
+		expiry = s3MaxPresignExpiry
+	}
+	u := s.objectURL(s.objectKey(path), nil)
+	return s.signer.Presign(http.MethodGet, u, expiry, time.Now()), nil
+}
+
+// GetStorageType 获取存储类型
+func (s *S3Adapter) GetStorageType() string {
+	if s.config.Name != "" {
+		return s.config.Name
+	}
+	return "s3"
+}
+
+// putObject 直接上传整个文件
+func (s *S3Adapter) putObject(ctx context.Context, key string, body []byte, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPut, key, nil, contentTypeHeader(contentType), body)
+	if err != nil {
+		return &StorageError{Code: "UPLOAD_FAILED", Message: "S3上传失败", Err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return s3ResponseError(resp, "UPLOAD_FAILED", "S3上传失败")
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// s3CompletedPart 已上传的分片
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// multipartUpload 分片上传，first为已读取的第一片，失败时中止上传以清理已上传的分片
+func (s *S3Adapter) multipartUpload(ctx context.Context, key string, first *bytes.Buffer, reader io.Reader, contentType string) error {
+	uploadId, err := s.createMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		return err
+	}
+
+	var parts []s3CompletedPart
+	buf := first
+	for number := 1; buf.Len() > 0; number++ {
+		if number > s3MaxParts {
+			s.abortMultipartUpload(key, uploadId)
+			return &StorageError{Code: "UPLOAD_FAILED", Message: "文件过大，超过S3分片数量上限"}
+		}
+		etag, err := s.uploadPart(ctx, key, uploadId, number, buf.Bytes())
+		if err != nil {
+			s.abortMultipartUpload(key, uploadId)
+			return err
+		}
+		parts = append(parts, s3CompletedPart{PartNumber: number, ETag: etag})
+
+		buf.Reset()
+		if _, err := io.CopyN(buf, reader, s.partSize); err != nil && err != io.EOF {
+			s.abortMultipartUpload(key, uploadId)
+			return &StorageError{Code: "UPLOAD_FAILED", Message: "读取上传内容失败", Err: err}
+		}
+	}
+
+	if err := s.completeMultipartUpload(ctx, key, uploadId, parts); err != nil {
+		s.abortMultipartUpload(key, uploadId)
+		return err
+	}
+	return nil
+}
+
+// createMultipartUpload 初始化分片上传，返回UploadId
+func (s *S3Adapter) createMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, contentTypeHeader(contentType), nil)
+	if err != nil {
+		return "", &StorageError{Code: "UPLOAD_FAILED", Message: "初始化S3分片上传失败", Err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", s3ResponseError(resp, "UPLOAD_FAILED", "初始化S3分片上传失败")
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		UploadId string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil || result.UploadId == "" {
+		return "", &StorageError{Code: "UPLOAD_FAILED", Message: "解析S3分片上传响应失败", Err: err}
+	}
+	return result.UploadId, nil
+}
+
+// uploadPart 上传一个分片，返回分片的ETag
+func (s *S3Adapter) uploadPart(ctx context.Context, key, uploadId string, number int, body []byte) (string, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadId}}
+	resp, err := s.do(ctx, http.MethodPut, key, query, nil, body)
+	if err != nil {
+		return "", &StorageError{Code: "UPLOAD_FAILED", Message: "S3分片上传失败", Err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", s3ResponseError(resp, "UPLOAD_FAILED", "S3分片上传失败")
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+// completeMultipartUpload 合并已上传的分片
+func (s *S3Adapter) completeMultipartUpload(ctx context.Context, key, uploadId string, parts []s3CompletedPart) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+		Parts   []s3CompletedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return &StorageError{Code: "UPLOAD_FAILED", Message: "生成S3分片合并请求失败", Err: err}
+	}
+
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadId}},
+		http.Header{"Content-Type": {"application/xml"}}, body)
+	if err != nil {
+		return &StorageError{Code: "UPLOAD_FAILED", Message: "S3分片合并失败", Err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return s3ResponseError(resp, "UPLOAD_FAILED", "S3分片合并失败")
+	}
+	defer resp.Body.Close()
+
+	// 合并失败时服务端也可能返回200，需要检查响应内容
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &StorageError{Code: "UPLOAD_FAILED", Message: "读取S3分片合并响应失败", Err: err}
+	}
+	if apiErr := parseS3Error(data); apiErr != nil {
+		return &StorageError{Code: "UPLOAD_FAILED", Message: "S3分片合并失败", Err: apiErr}
+	}
+	return nil
+}
+
+// abortMultipartUpload 中止分片上传，使用独立的上下文保证请求取消后仍能清理
+func (s *S3Adapter) abortMultipartUpload(key, uploadId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	resp, err := s.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadId}}, nil, nil)
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+// do 签名并发送请求
+func (s *S3Adapter) do(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	u := s.objectURL(key, query)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	s.signer.Sign(req, sha256Hex(body), time.Now())
+	return s.client.Do(req)
+}
+
+// objectKey 存储路径转换为对象键
+func (s *S3Adapter) objectKey(path string) string {
+	return strings.TrimLeft(strings.ReplaceAll(path, "\\", "/"), "/")
+}
+
+// objectURL 对象的访问地址，路径风格为endpoint/bucket/key，否则为bucket.endpoint/key
+func (s *S3Adapter) objectURL(key string, query url.Values) *url.URL {
+	u := *s.endpoint
+	path := "/" + key
+	if s.config.PathStyle {
+		path = "/" + s.config.BucketName + path
+	} else {
+		u.Host = s.config.BucketName + "." + u.Host
+	}
+	u.Path = strings.TrimRight(s.endpoint.Path, "/") + path
+	u.RawPath = canonicalPath(u.Path)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+// contentTypeHeader 生成Content-Type请求头
+func contentTypeHeader(contentType string) http.Header {
+	if contentType == "" {
+		return nil
+	}
+	return http.Header{"Content-Type": {contentType}}
+}
+
+// s3APIError S3接口返回的错误
+type s3APIError struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+func (e *s3APIError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// parseS3Error 解析响应中的错误信息，不是错误响应时返回nil
+func parseS3Error(data []byte) *s3APIError {
+	var apiErr s3APIError
+	if xml.Unmarshal(data, &apiErr) != nil || apiErr.Code == "" {
+		return nil
+	}
+	return &apiErr
+}
+
+// s3ResponseError 将失败的响应转换为存储错误，并关闭响应体
+func s3ResponseError(resp *http.Response, code, message string) error {
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if apiErr := parseS3Error(data); apiErr != nil {
+		return &StorageError{Code: code, Message: message, Err: apiErr}
+	}
+	return &StorageError{Code: code, Message: fmt.Sprintf("%s: HTTP %d", message, resp.StatusCode)}
+}
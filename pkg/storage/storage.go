@@ -41,7 +41,7 @@ type UploadResult struct {
 
 // StorageConfig 存储配置
 type StorageConfig struct {
-	Type     string                 `json:"type"`      // 存储类型: local, aliyun, tencent
+	Type     string                 `json:"type"`      // 存储类型: local, aliyun, tencent, s3
 	Config   map[string]interface{} `json:"config"`    // 具体配置
 	BasePath string                 `json:"base_path"` // 基础路径
 	BaseURL  string                 `json:"base_url"`  // 基础URL
@@ -1,11 +1,8 @@
 package storage
 
 import (
-	"context"
 	"fmt"
-	"io"
 	"strings"
-	"time"
 )
 
 // TencentConfig 腾讯云COS配置
@@ -19,157 +16,69 @@ type TencentConfig struct {
 	CustomDomain string `json:"custom_domain"` // 自定义域名
 }
 
-// TencentAdapter 腾讯云COS存储适配器
+// TencentAdapter 腾讯云COS存储适配器，通过COS的S3兼容接口访问
 type TencentAdapter struct {
+	*S3Adapter
 	config *TencentConfig
-	// 注意：这里暂时不引入腾讯云SDK，仅提供接口实现
-	// 在实际使用时需要引入: github.com/tencentyun/cos-go-sdk-v5
 }
 
-// NewTencentAdapter 创建腾讯云COS适配器
-func NewTencentAdapter(config *TencentConfig) *TencentAdapter {
+// NewTencentAdapter 创建腾讯云COS适配器，配置不完整时返回错误
+func NewTencentAdapter(config *TencentConfig) (*TencentAdapter, error) {
 	if config == nil {
-		panic("腾讯云COS配置不能为空")
+		return nil, ErrInvalidConfig
 	}
-
-	// 验证必要配置
-	if config.SecretID == "" || config.SecretKey == "" ||
-		config.Region == "" || config.BucketName == "" {
-		panic("腾讯云COS配置不完整")
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
-	return &TencentAdapter{
-		config: config,
+	protocol := "http"
+	if config.UseHTTPS {
+		protocol = "https"
 	}
-}
-
-// Upload 上传文件到腾讯云COS
-func (t *TencentAdapter) Upload(ctx context.Context, path string, reader io.Reader, size int64, contentType string) error {
-	// TODO: 实现腾讯云COS上传逻辑
-	// 这里需要使用腾讯云COS SDK
-	/*
-		u, _ := url.Parse(fmt.Sprintf("https://%s-%s.cos.%s.myqcloud.com",
-			t.config.BucketName, t.config.AppID, t.config.Region))
-
-		b := &cos.BaseURL{BucketURL: u}
-		client := cos.NewClient(b, &http.Client{
-			Transport: &cos.AuthorizationTransport{
-				SecretID:  t.config.SecretID,
-				SecretKey: t.config.SecretKey,
-			},
-		})
-
-		opt := &cos.ObjectPutOptions{}
-		if contentType != "" {
-			opt.ObjectPutHeaderOptions = &cos.ObjectPutHeaderOptions{
-				ContentType: contentType,
-			}
-		}
-
-		_, err := client.Object.Put(ctx, path, reader, opt)
-		if err != nil {
-			return &StorageError{
-				Code:    "COS_UPLOAD_FAILED",
-				Message: "COS上传失败",
-				Err:     err,
-			}
-		}
-	*/
-
-	return fmt.Errorf("腾讯云COS适配器暂未实现，请先安装腾讯云COS SDK")
-}
-
-// Download 从腾讯云COS下载文件
-func (t *TencentAdapter) Download(ctx context.Context, path string) (io.ReadCloser, error) {
-	// TODO: 实现腾讯云COS下载逻辑
-	return nil, fmt.Errorf("腾讯云COS适配器暂未实现")
-}
-
-// Delete 删除腾讯云COS文件
-func (t *TencentAdapter) Delete(ctx context.Context, path string) error {
-	// TODO: 实现腾讯云COS删除逻辑
-	return fmt.Errorf("腾讯云COS适配器暂未实现")
-}
-
-// Exists 检查腾讯云COS文件是否存在
-func (t *TencentAdapter) Exists(ctx context.Context, path string) (bool, error) {
-	// TODO: 实现腾讯云COS文件存在检查逻辑
-	return false, fmt.Errorf("腾讯云COS适配器暂未实现")
-}
-
-// GetURL 获取腾讯云COS文件访问URL
-func (t *TencentAdapter) GetURL(ctx context.Context, path string) (string, error) {
-	var baseURL string
 
-	// 使用自定义域名或默认域名
-	if t.config.CustomDomain != "" {
-		baseURL = t.config.CustomDomain
-	} else {
-		protocol := "http"
-		if t.config.UseHTTPS {
-			protocol = "https"
-		}
-		baseURL = fmt.Sprintf("%s://%s-%s.cos.%s.myqcloud.com",
-			protocol, t.config.BucketName, t.config.AppID, t.config.Region)
+	// COS的存储桶名称格式为<bucket>-<appid>
+	bucket := config.BucketName
+	if config.AppID != "" && !strings.HasSuffix(bucket, "-"+config.AppID) {
+		bucket += "-" + config.AppID
 	}
 
-	// 确保baseURL以/结尾
-	if !strings.HasSuffix(baseURL, "/") {
-		baseURL += "/"
+	adapter, err := NewS3Adapter(&S3Config{
+		Name:            "tencent",
+		Endpoint:        fmt.Sprintf("%s://cos.%s.myqcloud.com", protocol, config.Region),
+		Region:          config.Region,
+		BucketName:      bucket,
+		AccessKeyID:     config.SecretID,
+		SecretAccessKey: config.SecretKey,
+		CustomDomain:    config.CustomDomain,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("腾讯云COS配置无效: %w", err)
 	}
 
-	return baseURL + path, nil
-}
-
-// GetSignedURL 获取腾讯云COS签名URL
-func (t *TencentAdapter) GetSignedURL(ctx context.Context, path string, expiry time.Duration) (string, error) {
-	// TODO: 实现腾讯云COS签名URL生成逻辑
-	/*
-		u, _ := url.Parse(fmt.Sprintf("https://%s-%s.cos.%s.myqcloud.com",
-			t.config.BucketName, t.config.AppID, t.config.Region))
-
-		b := &cos.BaseURL{BucketURL: u}
-		client := cos.NewClient(b, &http.Client{
-			Transport: &cos.AuthorizationTransport{
-				SecretID:  t.config.SecretID,
-				SecretKey: t.config.SecretKey,
-			},
-		})
-
-		presignedURL, err := client.Object.GetPresignedURL(ctx, http.MethodGet, path,
-			t.config.SecretID, t.config.SecretKey, expiry, nil)
-		if err != nil {
-			return "", &StorageError{
-				Code:    "COS_SIGN_URL_FAILED",
-				Message: "生成COS签名URL失败",
-				Err:     err,
-			}
-		}
-
-		return presignedURL.String(), nil
-	*/
-
-	return "", fmt.Errorf("腾讯云COS适配器暂未实现")
-}
-
-// GetStorageType 获取存储类型
-func (t *TencentAdapter) GetStorageType() string {
-	return "tencent"
+	return &TencentAdapter{
+		S3Adapter: adapter,
+		config:    config,
+	}, nil
 }
 
-// ValidateConfig 验证腾讯云COS配置
-func (t *TencentAdapter) ValidateConfig() error {
-	if t.config.SecretID == "" {
+// Validate 验证腾讯云COS配置是否完整
+func (c *TencentConfig) Validate() error {
+	if c.SecretID == "" {
 		return fmt.Errorf("腾讯云COS SecretID不能为空")
 	}
-	if t.config.SecretKey == "" {
+	if c.SecretKey == "" {
 		return fmt.Errorf("腾讯云COS SecretKey不能为空")
 	}
-	if t.config.Region == "" {
+	if c.Region == "" {
 		return fmt.Errorf("腾讯云COS Region不能为空")
 	}
-	if t.config.BucketName == "" {
+	if c.BucketName == "" {
 		return fmt.Errorf("腾讯云COS BucketName不能为空")
 	}
 	return nil
 }
+
+// ValidateConfig 验证腾讯云COS配置
+func (t *TencentAdapter) ValidateConfig() error {
+	return t.config.Validate()
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// sniffLength 识别内容类型需要读取的文件头长度
+const sniffLength = 512
+
+// Store 通过适配器上传文件，上传过程中计算SHA-256哈希和实际大小
+// contentType为空时根据文件头和扩展名识别
+func Store(ctx context.Context, adapter StorageAdapter, path string, reader io.Reader, size int64, contentType string) (*UploadResult, error) {
+	buffered := bufio.NewReaderSize(reader, sniffLength)
+	if contentType == "" {
+		head, _ := buffered.Peek(sniffLength)
+		contentType = DetectContentType(path, head)
+	}
+
+	hash := sha256.New()
+	counter := &countingReader{reader: io.TeeReader(buffered, hash)}
+	if err := adapter.Upload(ctx, path, counter, size, contentType); err != nil {
+		return nil, err
+	}
+
+	url, err := adapter.GetURL(ctx, path)
+	if err != nil {
+		adapter.Delete(ctx, path)
+		return nil, err
+	}
+
+	return &UploadResult{
+		Path:        path,
+		URL:         url,
+		Size:        counter.count,
+		ContentType: contentType,
+		Hash:        hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// DetectContentType 根据文件头识别内容类型，无法识别时按扩展名判断
+func DetectContentType(filename string, head []byte) string {
+	detected := http.DetectContentType(head)
+	if detected != "application/octet-stream" && !strings.HasPrefix(detected, "text/plain") {
+		return detected
+	}
+	if byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))); byExt != "" {
+		return byExt
+	}
+	return detected
+}
+
+// countingReader 统计读取的字节数
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+// Read 读取并计数
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
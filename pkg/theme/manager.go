@@ -0,0 +1,339 @@
+// Package theme 前台主题管理，从主题根目录发现带有theme.yaml的主题，按当前主题渲染页面，
+// 支持运行时切换主题和重新加载模板
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"io/fs"
+	"matuto-blog/pkg/logger"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin/render"
+)
+
+// ErrThemeNotFound 主题不存在
+var ErrThemeNotFound = errors.New("主题不存在")
+
+// Theme 已发现的主题
+type Theme struct {
+	ID       string   // 主题目录名，也是模板名前缀，如default/index.html
+	Dir      string   // 主题目录
+	Manifest Manifest // 主题描述
+}
+
+// Page 页面模板
+type Page struct {
+	Name  string `json:"name"`  // 页面名，如article-wide.html
+	Theme string `json:"theme"` // 提供该模板的主题
+}
+
+// Manager 主题管理器，实现gin的HTMLRender，渲染时页面名按当前主题解析
+// 所有主题的模板解析到同一个模板集合中，主题之间可以引用彼此的模板
+type Manager struct {
+	root     string
+	funcs    template.FuncMap
+	fallback string
+
+	mu          sync.RWMutex
+	themes      map[string]*Theme
+	current     string
+	templates   *template.Template
+	settings    map[string]map[string]interface{}
+	fingerprint uint64
+
+	stop chan struct{}
+}
+
+// NewManager 创建主题管理器，fallback主题提供当前主题缺少的页面模板
+func NewManager(root string, funcs template.FuncMap, fallback string) *Manager {
+	return &Manager{
+		root:     root,
+		funcs:    funcs,
+		fallback: fallback,
+		themes:   make(map[string]*Theme),
+		current:  fallback,
+		settings: make(map[string]map[string]interface{}),
+	}
+}
+
+// Load 重新发现主题并解析全部模板，失败时保留原有的主题和模板
+func (m *Manager) Load() error {
+	fingerprint, err := m.scanFingerprint()
+	if err != nil {
+		return err
+	}
+	themes, templates, err := m.parse()
+	if err != nil {
+		return err
+	}
+	if _, ok := themes[m.fallback]; !ok {
+		return fmt.Errorf("默认主题不存在: %s", filepath.Join(m.root, m.fallback))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.themes = themes
+	m.templates = templates
+	m.fingerprint = fingerprint
+	if _, ok := themes[m.current]; !ok {
+		logger.Warn("Theme", m.current, "no longer exists, falling back to", m.fallback)
+		m.current = m.fallback
+	}
+	return nil
+}
+
+// Themes 所有主题，按ID排序
+func (m *Manager) Themes() []*Theme {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	list := make([]*Theme, 0, len(m.themes))
+	for _, t := range m.themes {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Get 按ID查找主题
+func (m *Manager) Get(id string) (*Theme, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.themes[id]
+	return t, ok
+}
+
+// Current 当前主题
+func (m *Manager) Current() *Theme {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.themes[m.current]
+}
+
+// Activate 切换当前主题，立即对新的请求生效
+func (m *Manager) Activate(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.themes[id]; !ok {
+		return ErrThemeNotFound
+	}
+	m.current = id
+	return nil
+}
+
+// SetSettings 设置主题的设置值，值按主题的设置项合并默认值
+func (m *Manager) SetSettings(id string, values map[string]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.settings[id] = values
+}
+
+// Settings 主题当前生效的设置，包含未保存设置项的默认值
+func (m *Manager) Settings(id string) map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.themes[id]
+	if !ok {
+		return map[string]interface{}{}
+	}
+	return t.Manifest.Merge(m.settings[id])
+}
+
+// Resolve 将页面名解析为模板名，优先使用当前主题的模板，其次是默认主题的模板
+// 已带主题前缀的完整模板名原样返回
+func (m *Manager) Resolve(page string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.resolve(page)
+}
+
+// resolve 解析模板名，调用方需持有锁
+func (m *Manager) resolve(page string) string {
+	for _, id := range []string{m.current, m.fallback} {
+		name := id + "/" + page
+		if m.templates != nil && m.templates.Lookup(name) != nil {
+			return name
+		}
+	}
+	return page
+}
+
+// Has 当前主题或默认主题中是否存在该页面模板
+func (m *Manager) Has(page string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.resolve(page) != page
+}
+
+// Pages 当前主题可用的页面模板，即当前主题和默认主题根目录下以prefix开头的模板，
+// 子目录中的模板视为局部模板不包含在内，同名模板以当前主题为准
+func (m *Manager) Pages(prefix string) []Page {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.templates == nil {
+		return nil
+	}
+	found := make(map[string]string)
+	for _, id := range []string{m.fallback, m.current} {
+		for _, t := range m.templates.Templates() {
+			page, ok := strings.CutPrefix(t.Name(), id+"/")
+			if ok && !strings.Contains(page, "/") && strings.HasPrefix(page, prefix) {
+				found[page] = id
+			}
+		}
+	}
+	pages := make([]Page, 0, len(found))
+	for name, id := range found {
+		pages = append(pages, Page{Name: name, Theme: id})
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Name < pages[j].Name })
+	return pages
+}
+
+// Instance 实现render.HTMLRender，按当前主题解析页面模板
+func (m *Manager) Instance(page string, data interface{}) render.Render {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return render.HTML{
+		Template: m.templates,
+		Name:     m.resolve(page),
+		Data:     data,
+	}
+}
+
+// Watch 在后台定期检查主题目录，模板或theme.yaml变化时重新加载，重新加载成功后调用onReload
+func (m *Manager) Watch(interval time.Duration, onReload func()) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	m.stop = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.reloadIfChanged(onReload)
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+	logger.Info("Theme watcher started, interval:", interval)
+}
+
+// Stop 停止检查主题目录
+func (m *Manager) Stop() {
+	if m.stop != nil {
+		close(m.stop)
+	}
+}
+
+// reloadIfChanged 主题目录有变化时重新加载，解析失败时继续使用原有模板
+func (m *Manager) reloadIfChanged(onReload func()) {
+	fingerprint, err := m.scanFingerprint()
+	if err != nil {
+		logger.Warn("Failed to scan themes:", err)
+		return
+	}
+	m.mu.RLock()
+	changed := fingerprint != m.fingerprint
+	m.mu.RUnlock()
+	if !changed {
+		return
+	}
+
+	if err := m.Load(); err != nil {
+		logger.Error("Failed to reload themes:", err)
+		// 记录本次变化，文件再次修改后才重新尝试
+		m.mu.Lock()
+		m.fingerprint = fingerprint
+		m.mu.Unlock()
+		return
+	}
+	logger.Info("Themes reloaded")
+	if onReload != nil {
+		onReload()
+	}
+}
+
+// parse 发现主题并解析所有主题的模板，模板名为相对主题根目录的路径，如default/components/header.html
+func (m *Manager) parse() (map[string]*Theme, *template.Template, error) {
+	entries, err := os.ReadDir(m.root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取主题目录失败: %w", err)
+	}
+
+	themes := make(map[string]*Theme)
+	templates := template.New("").Funcs(m.funcs)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(m.root, entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, ManifestFile)); err != nil {
+			continue
+		}
+		manifest, err := loadManifest(dir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("主题%s: %w", entry.Name(), err)
+		}
+		themes[entry.Name()] = &Theme{ID: entry.Name(), Dir: dir, Manifest: *manifest}
+
+		err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".html") {
+				return nil
+			}
+			rel, err := filepath.Rel(m.root, path)
+			if err != nil {
+				return err
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if _, err := templates.New(filepath.ToSlash(rel)).Parse(string(content)); err != nil {
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("解析主题%s的模板失败: %w", entry.Name(), err)
+		}
+	}
+	return themes, templates, nil
+}
+
+// scanFingerprint 根据主题目录下模板和theme.yaml的路径、大小和修改时间计算指纹，用于检测变化
+func (m *Manager) scanFingerprint() (uint64, error) {
+	h := fnv.New64a()
+	err := filepath.WalkDir(m.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if name != ManifestFile && !strings.EqualFold(filepath.Ext(name), ".html") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s|%d|%d\n", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return h.Sum64(), err
+}
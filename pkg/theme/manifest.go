@@ -0,0 +1,196 @@
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"github.com/spf13/viper"
+)
+
+// ManifestFile 主题描述文件名，主题目录下存在该文件才会被识别为主题
+const ManifestFile = "theme.yaml"
+
+// 设置项类型
+const (
+	SettingText     = "text"     // 单行文本
+	SettingTextarea = "textarea" // 多行文本
+	SettingBool     = "bool"     // 开关
+	SettingNumber   = "number"   // 数字
+	SettingSelect   = "select"   // 从options中选择
+	SettingColor    = "color"    // 颜色，如#3b82f6
+)
+
+var (
+	// settingKeyPattern 设置项键名，模板中通过 .theme.settings.<key> 访问，需是合法的标识符
+	settingKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// colorPattern 十六进制颜色
+	colorPattern = regexp.MustCompile(`^#([0-9A-Fa-f]{3}|[0-9A-Fa-f]{6}|[0-9A-Fa-f]{8})$`)
+)
+
+// Setting 主题设置项
+type Setting struct {
+	Key         string      `json:"key" mapstructure:"key"`
+	Label       string      `json:"label" mapstructure:"label"`
+	Type        string      `json:"type" mapstructure:"type"`
+	Default     interface{} `json:"default" mapstructure:"default"`
+	Options     []string    `json:"options,omitempty" mapstructure:"options"`
+	Description string      `json:"description,omitempty" mapstructure:"description"`
+}
+
+// Manifest 主题描述，对应主题目录下的theme.yaml
+type Manifest struct {
+	Name        string    `json:"name" mapstructure:"name"`
+	Version     string    `json:"version" mapstructure:"version"`
+	Author      string    `json:"author" mapstructure:"author"`
+	Description string    `json:"description" mapstructure:"description"`
+	Settings    []Setting `json:"settings" mapstructure:"settings"`
+}
+
+// loadManifest 读取并校验主题目录下的theme.yaml
+func loadManifest(dir string) (*Manifest, error) {
+	v := viper.New()
+	v.SetConfigFile(filepath.Join(dir, ManifestFile))
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{}
+	if err := v.Unmarshal(manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Name == "" {
+		manifest.Name = filepath.Base(dir)
+	}
+
+	keys := make(map[string]bool, len(manifest.Settings))
+	for i := range manifest.Settings {
+		setting := &manifest.Settings[i]
+		if !settingKeyPattern.MatchString(setting.Key) {
+			return nil, fmt.Errorf("设置项键名无效: %q", setting.Key)
+		}
+		if keys[setting.Key] {
+			return nil, fmt.Errorf("设置项重复: %s", setting.Key)
+		}
+		keys[setting.Key] = true
+		if setting.Type == "" {
+			setting.Type = SettingText
+		}
+		if setting.Label == "" {
+			setting.Label = setting.Key
+		}
+		if setting.Default == nil {
+			setting.Default = setting.zero()
+		}
+		value, err := setting.normalize(setting.Default)
+		if err != nil {
+			return nil, fmt.Errorf("设置项%s的默认值无效: %w", setting.Key, err)
+		}
+		setting.Default = value
+	}
+	return manifest, nil
+}
+
+// Defaults 所有设置项的默认值
+func (m *Manifest) Defaults() map[string]interface{} {
+	values := make(map[string]interface{}, len(m.Settings))
+	for _, setting := range m.Settings {
+		values[setting.Key] = setting.Default
+	}
+	return values
+}
+
+// Validate 校验设置值，未知的设置项和类型不符的值返回错误，未提供的设置项使用默认值
+func (m *Manifest) Validate(values map[string]interface{}) (map[string]interface{}, error) {
+	result := m.Defaults()
+	for key, raw := range values {
+		setting := m.setting(key)
+		if setting == nil {
+			return nil, fmt.Errorf("未知的设置项: %s", key)
+		}
+		value, err := setting.normalize(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", setting.Label, err)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// Merge 将已保存的设置合并到默认值上，忽略已从主题中移除或类型不再匹配的设置项
+func (m *Manifest) Merge(values map[string]interface{}) map[string]interface{} {
+	result := m.Defaults()
+	for key, raw := range values {
+		if setting := m.setting(key); setting != nil {
+			if value, err := setting.normalize(raw); err == nil {
+				result[key] = value
+			}
+		}
+	}
+	return result
+}
+
+// setting 查找设置项
+func (m *Manifest) setting(key string) *Setting {
+	for i := range m.Settings {
+		if m.Settings[i].Key == key {
+			return &m.Settings[i]
+		}
+	}
+	return nil
+}
+
+// zero 设置项类型的零值
+func (s *Setting) zero() interface{} {
+	switch s.Type {
+	case SettingBool:
+		return false
+	case SettingNumber:
+		return float64(0)
+	case SettingSelect:
+		if len(s.Options) > 0 {
+			return s.Options[0]
+		}
+	}
+	return ""
+}
+
+// normalize 按设置项类型校验并转换值，数字统一转为float64，与JSON解码结果一致
+func (s *Setting) normalize(value interface{}) (interface{}, error) {
+	switch s.Type {
+	case SettingText, SettingTextarea:
+		if str, ok := value.(string); ok {
+			return str, nil
+		}
+		return nil, errors.New("应为文本")
+	case SettingBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		return nil, errors.New("应为布尔值")
+	case SettingNumber:
+		switch n := value.(type) {
+		case float64:
+			return n, nil
+		case float32:
+			return float64(n), nil
+		case int:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		}
+		return nil, errors.New("应为数字")
+	case SettingSelect:
+		if str, ok := value.(string); ok && slices.Contains(s.Options, str) {
+			return str, nil
+		}
+		return nil, errors.New("不在可选值中")
+	case SettingColor:
+		if str, ok := value.(string); ok && colorPattern.MatchString(str) {
+			return str, nil
+		}
+		return nil, errors.New("应为十六进制颜色，如#3b82f6")
+	}
+	return nil, fmt.Errorf("不支持的设置项类型: %s", s.Type)
+}
@@ -0,0 +1,107 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 6238附录B中SHA1测试用的密钥"12345678901234567890"的Base32编码
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC 6238附录B的SHA1测试向量，验证码取8位结果的后6位
+// https://www.rfc-editor.org/rfc/rfc6238#appendix-B
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCodeRFC6238(t *testing.T) {
+	for _, tt := range rfcVectors {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.code {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	previous, _ := Code(rfcSecret, step-1)
+	old, _ := Code(rfcSecret, step-2)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		skew   int
+		step   int64
+		ok     bool
+	}{
+		{"current", rfcSecret, "050471", 0, step, true},
+		{"with spaces", rfcSecret, " 050 471 ", 0, step, true},
+		{"lowercase secret", strings.ToLower(rfcSecret), "050471", 0, step, true},
+		{"previous within skew", rfcSecret, previous, 1, step - 1, true},
+		{"previous without skew", rfcSecret, previous, 0, 0, false},
+		{"outside skew", rfcSecret, old, 1, 0, false},
+		{"wrong code", rfcSecret, "000000", 1, 0, false},
+		{"wrong length", rfcSecret, "50471", 1, 0, false},
+		{"invalid secret", "!!!", "050471", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := Validate(tt.secret, tt.code, now, tt.skew)
+			if ok != tt.ok || gotStep != tt.step {
+				t.Errorf("Validate = (%d, %v), want (%d, %v)", gotStep, ok, tt.step, tt.ok)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := decodeSecret(secret)
+	if err != nil || len(key) != secretSize {
+		t.Fatalf("decodeSecret(%q) = %d bytes, %v", secret, len(key), err)
+	}
+	code, err := Code(secret, Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Validate(secret, code, time.Now(), 1); !ok {
+		t.Error("generated secret should validate its own code")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	u, err := url.Parse(ProvisioningURI(rfcSecret, "My Blog", "alice@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/My Blog:alice@example.com" {
+		t.Errorf("unexpected uri %s", u)
+	}
+	if strings.Contains(u.RawQuery, "+") {
+		t.Errorf("query should encode spaces as %%20: %s", u.RawQuery)
+	}
+	query := u.Query()
+	if query.Get("secret") != rfcSecret || query.Get("issuer") != "My Blog" ||
+		query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Errorf("unexpected query %v", query)
+	}
+}
@@ -0,0 +1,189 @@
+package utils
+
+import "strings"
+
+// DiffLine 行级差异
+type DiffLine struct {
+	Type    string `json:"type"`    // 差异类型: equal, insert, delete
+	Content string `json:"content"` // 行内容
+	OldLine int    `json:"oldLine"` // 旧文本行号，新增行为0
+	NewLine int    `json:"newLine"` // 新文本行号，删除行为0
+}
+
+// 差异类型常量
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// maxDiffSteps 每次切分时双向搜索的最大步数，差异过大的部分不再细分，直接输出为删除和新增，
+// 避免两段差异很大的长文本比较时占用过多CPU
+const maxDiffSteps = 2048
+
+// DiffLines 按行比较两段文本，使用Myers差分算法输出最短编辑脚本
+// 通过正反双向搜索找到编辑路径的中间点后分治处理，内存占用与行数成线性关系
+func DiffLines(oldText, newText string) []DiffLine {
+	d := &differ{}
+	a := splitLines(oldText)
+	b := splitLines(newText)
+	d.result = make([]DiffLine, 0, len(a)+len(b))
+	d.compare(a, b)
+	return d.result
+}
+
+// differ 按顺序收集差异行并维护行号
+type differ struct {
+	result []DiffLine
+	oldNo  int
+	newNo  int
+}
+
+// emit 追加一行差异
+func (d *differ) emit(diffType string, lines ...string) {
+	for _, content := range lines {
+		line := DiffLine{Type: diffType, Content: content}
+		if diffType != DiffInsert {
+			d.oldNo++
+			line.OldLine = d.oldNo
+		}
+		if diffType != DiffDelete {
+			d.newNo++
+			line.NewLine = d.newNo
+		}
+		d.result = append(d.result, line)
+	}
+}
+
+// compare 比较两组行，公共前缀和后缀直接输出，中间部分在编辑路径的中间点切分后递归比较
+func (d *differ) compare(a, b []string) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	d.emit(DiffEqual, a[:prefix]...)
+
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	switch {
+	case len(x) == 0:
+		d.emit(DiffInsert, y...)
+	case len(y) == 0:
+		d.emit(DiffDelete, x...)
+	default:
+		if i, j, ok := bisect(x, y); ok {
+			d.compare(x[:i], y[:j])
+			d.compare(x[i:], y[j:])
+		} else {
+			d.emit(DiffDelete, x...)
+			d.emit(DiffInsert, y...)
+		}
+	}
+
+	d.emit(DiffEqual, a[len(a)-suffix:]...)
+}
+
+// bisect 从两端同时搜索最短编辑路径，返回路径相遇处的切分点，
+// 两组行没有公共行、无法切分或超过最大搜索步数时返回false
+func bisect(a, b []string) (int, int, bool) {
+	n, m := len(a), len(b)
+	maxD := min((n+m+1)/2, maxDiffSteps)
+	offset := maxD
+	size := 2*maxD + 2
+	// forward[k]、backward[k] 分别为正向和反向搜索在对角线k上到达的最远位置
+	forward := make([]int, size)
+	backward := make([]int, size)
+	for i := range forward {
+		forward[i] = -1
+		backward[i] = -1
+	}
+	forward[offset+1] = 0
+	backward[offset+1] = 0
+
+	delta := n - m
+	// 差值为奇数时路径在正向搜索中相遇，否则在反向搜索中相遇
+	front := delta%2 != 0
+	// 越过边界的对角线不再搜索
+	k1start, k1end, k2start, k2end := 0, 0, 0, 0
+
+	split := func(x, y int) (int, int, bool) {
+		if (x == 0 && y == 0) || (x == n && y == m) {
+			return 0, 0, false
+		}
+		return x, y, true
+	}
+
+	for step := 0; step < maxD; step++ {
+		for k := -step + k1start; k <= step-k1end; k += 2 {
+			i := offset + k
+			var x int
+			if k == -step || (k != step && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[i] = x
+			switch {
+			case x > n:
+				k1end += 2
+			case y > m:
+				k1start += 2
+			case front:
+				j := offset + delta - k
+				if j >= 0 && j < size && backward[j] != -1 && x >= n-backward[j] {
+					return split(x, y)
+				}
+			}
+		}
+
+		for k := -step + k2start; k <= step-k2end; k += 2 {
+			i := offset + k
+			var x int
+			if k == -step || (k != step && backward[i-1] < backward[i+1]) {
+				x = backward[i+1]
+			} else {
+				x = backward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			backward[i] = x
+			switch {
+			case x > n:
+				k2end += 2
+			case y > m:
+				k2start += 2
+			case !front:
+				j := offset + delta - k
+				if j >= 0 && j < size && forward[j] != -1 {
+					fx := forward[j]
+					fy := fx - (j - offset)
+					if fx >= n-x {
+						return split(fx, fy)
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// splitLines 按行切分文本，统一换行符
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// applyDiff 从差异中还原旧文本和新文本的各行，同时校验行号连续
+func applyDiff(t *testing.T, lines []DiffLine) (oldLines, newLines []string) {
+	t.Helper()
+	oldLines, newLines = []string{}, []string{}
+	for _, line := range lines {
+		if line.Type != DiffInsert {
+			oldLines = append(oldLines, line.Content)
+			if line.OldLine != len(oldLines) {
+				t.Fatalf("OldLine = %d, want %d", line.OldLine, len(oldLines))
+			}
+		} else if line.OldLine != 0 {
+			t.Fatalf("insert line has OldLine %d", line.OldLine)
+		}
+		if line.Type != DiffDelete {
+			newLines = append(newLines, line.Content)
+			if line.NewLine != len(newLines) {
+				t.Fatalf("NewLine = %d, want %d", line.NewLine, len(newLines))
+			}
+		} else if line.NewLine != 0 {
+			t.Fatalf("delete line has NewLine %d", line.NewLine)
+		}
+	}
+	return oldLines, newLines
+}
+
+// lcsLength 最长公共子序列长度，用于校验差异是最短编辑脚本
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// countEqual 差异中相同行的数量
+func countEqual(lines []DiffLine) int {
+	n := 0
+	for _, line := range lines {
+		if line.Type == DiffEqual {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string // 每行的差异类型：=相同 +新增 -删除
+	}{
+		{"both empty", "", "", ""},
+		{"insert all", "", "a\nb", "++"},
+		{"delete all", "a\nb\n", "", "--"},
+		{"equal", "a\nb\nc", "a\nb\nc\n", "==="},
+		{"insert middle", "a\nc", "a\nb\nc", "=+="},
+		{"delete middle", "a\nb\nc", "a\nc", "=-="},
+		{"replace", "a\nb\nc", "a\nx\nc", "=-+="},
+		{"crlf", "a\r\nb\r\n", "a\nb", "=="},
+		{"move", "a\nb\nc\nd", "b\nc\nd\na", "-===+"},
+	}
+	symbols := map[string]string{DiffEqual: "=", DiffInsert: "+", DiffDelete: "-"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := DiffLines(tt.old, tt.new)
+			var got strings.Builder
+			for _, line := range lines {
+				got.WriteString(symbols[line.Type])
+			}
+			if got.String() != tt.want {
+				t.Errorf("DiffLines types = %q, want %q", got.String(), tt.want)
+			}
+			oldLines, newLines := applyDiff(t, lines)
+			if !reflect.DeepEqual(oldLines, splitLines(tt.old)) || !reflect.DeepEqual(newLines, splitLines(tt.new)) {
+				t.Errorf("round trip = %q / %q", oldLines, newLines)
+			}
+		})
+	}
+}
+
+// TestDiffLinesRandom 随机文本的差异应能还原两段文本，且相同行数等于最长公共子序列长度
+func TestDiffLinesRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randomLines := func(alphabet int) []string {
+		lines := make([]string, r.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + r.Intn(alphabet)))
+		}
+		return lines
+	}
+	for i := 0; i < 2000; i++ {
+		alphabet := 1 + i%6
+		a, b := randomLines(alphabet), randomLines(alphabet)
+		lines := DiffLines(strings.Join(a, "\n"), strings.Join(b, "\n"))
+
+		oldLines, newLines := applyDiff(t, lines)
+		if !reflect.DeepEqual(oldLines, a) || !reflect.DeepEqual(newLines, b) {
+			t.Fatalf("round trip failed for %q -> %q", a, b)
+		}
+		if got, want := countEqual(lines), lcsLength(a, b); got != want {
+			t.Fatalf("diff of %q -> %q keeps %d lines, want %d", a, b, got, want)
+		}
+	}
+}
+
+// TestDiffLinesLarge 差异很大的长文本超过搜索步数后整体输出为删除和新增，结果仍能还原
+func TestDiffLinesLarge(t *testing.T) {
+	a := make([]string, 20000)
+	b := make([]string, 20000)
+	for i := range a {
+		a[i] = "old " + string(rune('a'+i%26))
+		b[i] = "new " + string(rune('a'+i%26))
+	}
+	a[10000], b[10000] = "same", "same"
+	lines := DiffLines(strings.Join(a, "\n"), strings.Join(b, "\n"))
+	oldLines, newLines := applyDiff(t, lines)
+	if !reflect.DeepEqual(oldLines, a) || !reflect.DeepEqual(newLines, b) {
+		t.Fatal("round trip failed")
+	}
+}
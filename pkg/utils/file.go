@@ -2,6 +2,7 @@ package utils
 
 import (
 	"crypto/md5"
+	"crypto/sha256"
 	"fmt"
 	"github.com/gin-contrib/multitemplate"
 	"io"
@@ -179,6 +180,16 @@ func CalculateFileHash(reader io.Reader) (string, error) {
 	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
 
+// CalculateSHA256 计算内容的SHA-256哈希，同时返回读取的字节数
+func CalculateSHA256(reader io.Reader) (string, int64, error) {
+	hash := sha256.New()
+	n, err := io.Copy(hash, reader)
+	if err != nil {
+		return "", n, err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), n, nil
+}
+
 // CalculateFileHashFromMultipart 从multipart文件计算哈希
 func CalculateFileHashFromMultipart(header *multipart.FileHeader) (string, error) {
 	file, err := header.Open()
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// markdownRenderer Markdown渲染器
+var markdownRenderer = goldmark.New(
+	goldmark.WithExtensions(
+		extension.GFM,           // 支持GitHub Flavored Markdown
+		extension.Table,         // 支持表格
+		extension.Strikethrough, // 支持删除线
+		extension.Linkify,       // 自动链接
+		extension.TaskList,      // 支持任务列表
+	),
+	goldmark.WithParserOptions(
+		parser.WithAutoHeadingID(), // 自动生成标题ID
+	),
+	goldmark.WithRendererOptions(
+		html.WithHardWraps(), // 硬换行
+		html.WithXHTML(),     // XHTML兼容
+	),
+)
+
+// RenderMarkdown 将Markdown渲染为HTML
+func RenderMarkdown(content string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdownRenderer.Convert([]byte(content), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
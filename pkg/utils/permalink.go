@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// permalinkTokens 固定链接支持的占位符及其匹配规则
+var permalinkTokens = map[string]string{
+	"id":    `\d+`,
+	"slug":  `[^/]+`,
+	"year":  `\d{4}`,
+	"month": `\d{2}`,
+	"day":   `\d{2}`,
+}
+
+// permalinkTokenRegexp 匹配 {token} 形式的占位符
+var permalinkTokenRegexp = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// PermalinkPattern 固定链接规则，如 /{year}/{month}/{slug}
+type PermalinkPattern struct {
+	raw    string
+	regex  *regexp.Regexp
+	tokens []string
+}
+
+// CompilePermalink 编译固定链接规则
+func CompilePermalink(raw string) (*PermalinkPattern, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, "/") {
+		return nil, fmt.Errorf("固定链接规则必须以/开头: %s", raw)
+	}
+
+	var expr strings.Builder
+	var tokens []string
+	expr.WriteString("^")
+	last := 0
+	for _, loc := range permalinkTokenRegexp.FindAllStringSubmatchIndex(raw, -1) {
+		token := raw[loc[2]:loc[3]]
+		rule, ok := permalinkTokens[token]
+		if !ok {
+			return nil, fmt.Errorf("不支持的固定链接占位符: {%s}", token)
+		}
+		expr.WriteString(regexp.QuoteMeta(raw[last:loc[0]]))
+		expr.WriteString("(" + rule + ")")
+		tokens = append(tokens, token)
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(raw[last:]))
+	expr.WriteString("/?$")
+
+	if !containsToken(tokens, "id") && !containsToken(tokens, "slug") {
+		return nil, fmt.Errorf("固定链接规则必须包含{id}或{slug}: %s", raw)
+	}
+
+	regex, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, err
+	}
+	return &PermalinkPattern{raw: raw, regex: regex, tokens: tokens}, nil
+}
+
+// String 返回原始规则
+func (p *PermalinkPattern) String() string {
+	return p.raw
+}
+
+// Build 使用占位符的值生成链接
+func (p *PermalinkPattern) Build(values map[string]string) string {
+	return permalinkTokenRegexp.ReplaceAllStringFunc(p.raw, func(token string) string {
+		return values[token[1:len(token)-1]]
+	})
+}
+
+// Match 匹配路径，成功时返回各占位符的值
+func (p *PermalinkPattern) Match(path string) (map[string]string, bool) {
+	matches := p.regex.FindStringSubmatch(path)
+	if matches == nil {
+		return nil, false
+	}
+	values := make(map[string]string, len(p.tokens))
+	for i, token := range p.tokens {
+		values[token] = matches[i+1]
+	}
+	return values, true
+}
+
+// containsToken 检查占位符列表是否包含指定占位符
+func containsToken(tokens []string, token string) bool {
+	for _, t := range tokens {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
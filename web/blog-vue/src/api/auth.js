@@ -13,17 +13,52 @@ export function login(account, password) {
         data: {
             account,
             password
-        }
+        },
+        // 账号或密码错误时返回401，不触发刷新令牌和重新登录提示
+        skipAuthRefresh: true
+    })
+}
+
+/**
+ * 两步验证登录，使用登录返回的挑战令牌和验证码换取访问令牌
+ * @param {string} challengeToken - 挑战令牌
+ * @param {string} code - 验证器中的6位验证码或恢复码
+ * @returns {Promise}
+ */
+export function verifyTwoFactor(challengeToken, code) {
+    return request({
+        url: '/login/2fa',
+        method: 'post',
+        data: {
+            challengeToken,
+            code
+        },
+        skipAuthRefresh: true
     })
 }
 
 /**
- * 用户登出
+ * 用户登出，吊销当前会话的令牌
+ * @param {string} refreshToken - 刷新令牌
  * @returns {Promise}
  */
-export function logout() {
+export function logout(refreshToken) {
     return request({
         url: '/logout',
+        method: 'post',
+        data: {
+            refreshToken
+        }
+    })
+}
+
+/**
+ * 退出所有设备
+ * @returns {Promise}
+ */
+export function logoutAll() {
+    return request({
+        url: '/logout/all',
         method: 'post'
     })
 }
@@ -14,6 +14,56 @@ const service = axios.create({
 // 加载实例
 let loadingInstance = null
 
+// 正在进行的刷新令牌请求，并发的请求共用同一次刷新
+let refreshing = null
+
+/**
+ * 使用刷新令牌换取新的访问令牌，成功后保存新令牌
+ * @returns {Promise<boolean>} 是否刷新成功
+ */
+function refreshToken() {
+    const token = localStorage.getItem('refresh_token')
+    if (!token) {
+        return Promise.resolve(false)
+    }
+    if (!refreshing) {
+        refreshing = axios
+            .post(`${service.defaults.baseURL}/token/refresh`, { refreshToken: token })
+            .then(({ data: res }) => {
+                if (res.code !== 200) {
+                    return false
+                }
+                localStorage.setItem('token', res.data.token)
+                localStorage.setItem('refresh_token', res.data.refreshToken)
+                return true
+            })
+            .catch(() => false)
+            .finally(() => {
+                refreshing = null
+            })
+    }
+    return refreshing
+}
+
+/**
+ * 提示登录过期，确认后清除令牌并跳转到登录页
+ */
+function confirmRelogin() {
+    ElMessageBox.confirm(
+        '您的登录已过期，请重新登录',
+        '登录过期',
+        {
+            confirmButtonText: '重新登录',
+            cancelButtonText: '取消',
+            type: 'warning'
+        }
+    ).then(() => {
+        localStorage.removeItem('token')
+        localStorage.removeItem('refresh_token')
+        router.push('/login')
+    })
+}
+
 // 请求拦截器
 service.interceptors.request.use(
     (config) => {
@@ -46,7 +96,7 @@ service.interceptors.request.use(
 
 // 响应拦截器
 service.interceptors.response.use(
-    (response) => {
+    async (response) => {
         // 关闭加载动画
         if (loadingInstance) {
             loadingInstance.close()
@@ -58,21 +108,15 @@ service.interceptors.response.use(
         // 假设成功状态码为200
         if (res.code !== 200) {
             // 特殊错误码处理
-            if (res.code === 401) {
+            if (res.code === 401 && !response.config.skipAuthRefresh) {
+                // 访问令牌过期时先尝试刷新，每个请求只重试一次
+                const config = response.config
+                if (!config._retried && await refreshToken()) {
+                    config._retried = true
+                    return service(config)
+                }
                 // 未授权，需要重新登录
-                ElMessageBox.confirm(
-                    '您的登录已过期，请重新登录',
-                    '登录过期',
-                    {
-                        confirmButtonText: '重新登录',
-                        cancelButtonText: '取消',
-                        type: 'warning'
-                    }
-                ).then(() => {
-                    // 清除token并跳转到登录页
-                    localStorage.removeItem('token')
-                    router.push('/login')
-                })
+                confirmRelogin()
             }
 
             return Promise.reject(new Error(res.message || 'Error'))
@@ -97,18 +141,7 @@ service.interceptors.response.use(
         switch (status) {
             case 401:
                 // 未授权，需要重新登录
-                ElMessageBox.confirm(
-                    '您的登录已过期，请重新登录',
-                    '登录过期',
-                    {
-                        confirmButtonText: '重新登录',
-                        cancelButtonText: '取消',
-                        type: 'warning'
-                    }
-                ).then(() => {
-                    localStorage.removeItem('token')
-                    router.push('/login')
-                })
+                confirmRelogin()
                 break
             case 403:
                 ElMessage.error('没有权限执行此操作')